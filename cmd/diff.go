@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/spf13/cobra"
+)
+
+// NewDiffCommand creates and returns the diff command
+func NewDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old-output> <new-output>",
+		Short: "Compare two extraction outputs",
+		Long: `Compare two extraction outputs and report the comments that were added, removed or
+edited between them. A summary is printed; use --output to also write the full change list as JSON.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputPath, _ := cmd.Flags().GetString("output")
+
+			older, err := readResult(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read outputs: %w", err)
+			}
+			newer, err := readResult(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to read outputs: %w", err)
+			}
+
+			diff := core.DiffResults(older, newer)
+
+			// Write the machine-readable change list
+			if outputPath != "" {
+				if err := writeDiff(diff, outputPath); err != nil {
+					return fmt.Errorf("failed to write output: %w", err)
+				}
+			}
+
+			printDiffSummary(cmd.OutOrStdout(), diff)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output", "", "Path to write the change list as JSON")
+
+	return cmd
+}
+
+// printDiffSummary writes a human-readable summary of the changes
+func printDiffSummary(w io.Writer, diff *core.ResultDiff) {
+	fmt.Fprintf(w, "%d added, %d removed, %d edited, %d unchanged\n",
+		diff.Summary.Added, diff.Summary.Removed, diff.Summary.Edited, diff.Summary.Unchanged)
+
+	for _, change := range diff.Changes {
+		switch change.Type {
+		case core.ChangeAdded:
+			fmt.Fprintf(w, "+ %s\n", change.Key)
+		case core.ChangeRemoved:
+			fmt.Fprintf(w, "- %s\n", change.Key)
+		case core.ChangeEdited:
+			fmt.Fprintf(w, "~ %s %v\n", change.Key, change.Fields)
+		}
+	}
+}
+
+// writeDiff writes the change list to a JSON file
+func writeDiff(diff *core.ResultDiff, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal changes: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return nil
+}
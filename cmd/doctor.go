@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/extractor"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// NewDoctorCommand creates and returns the doctor command
+func NewDoctorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check access to every configured repository before extracting",
+		Long: `Check, for every configured repository, that the token authenticates, the repository is
+visible, pull requests can be listed and a diff fetched, and that the rate limit budget covers
+the estimated number of API requests. Exits non-zero if any check fails.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			strictSecrets, _ := cmd.Flags().GetBool("strict-secrets")
+
+			// Load configuration
+			config, err := loadConfig(configPath, strictSecrets)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			// Run checks
+			client, err := extractor.New(extractor.Options{Config: config})
+			if err != nil {
+				return fmt.Errorf("failed to create extractor: %w", err)
+			}
+			results, err := client.Check(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to check repositories: %w", err)
+			}
+
+			if err := printChecks(cmd.OutOrStdout(), results, client.Budgets(results)); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+
+			failed := 0
+			for _, result := range results {
+				if result.Failed() {
+					failed++
+				}
+			}
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d repositories failed checks", failed, len(results))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("config", "config.yaml", "Path to configuration file")
+	cmd.Flags().Bool("strict-secrets", false, strictSecretsUsage)
+
+	return cmd
+}
+
+// printChecks writes a table of check results per repository, followed by the rate limit
+// budgets the repositories share
+func printChecks(w io.Writer, results []models.RepositoryCheck, budgets []models.ProviderPlan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tCHECK\tSTATUS\tDETAIL")
+	for _, result := range results {
+		for _, check := range result.Checks {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.URL, check.Name, check.Status, check.Detail)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Each budget is one credential on one host, so a provider can have several
+	for _, budget := range budgets {
+		repositories := "repositories"
+		if len(budget.Repositories) == 1 {
+			repositories = "repository"
+		}
+		if limit := budget.RateLimit; limit != nil {
+			fmt.Fprintf(w, "\n%s on %s (%d %s): %d of %d requests remaining (resets %s), about %d needed\n",
+				budget.Provider, budget.Host, len(budget.Repositories), repositories,
+				limit.Remaining, limit.Limit, limit.Reset.Local().Format(time.RFC3339), budget.EstimatedCalls)
+		}
+		if limit := budget.QueryRateLimit; limit != nil {
+			fmt.Fprintf(w, "%s on %s (%d %s): %d of %d GraphQL points remaining (resets %s), about %d needed\n",
+				budget.Provider, budget.Host, len(budget.Repositories), repositories,
+				limit.Remaining, limit.Limit, limit.Reset.Local().Format(time.RFC3339), budget.EstimatedQueryCost)
+		}
+	}
+
+	return nil
+}
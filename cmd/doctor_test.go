@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintChecks(t *testing.T) {
+	limitA := &models.RateLimit{Limit: 5000, Remaining: 100, Reset: time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)}
+	limitB := &models.RateLimit{Limit: 5000, Remaining: 4000, Reset: time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)}
+	results := []models.RepositoryCheck{
+		{
+			URL:      "https://github.com/customer-a/api",
+			Provider: models.ProviderGitHub,
+			Checks: []models.Check{
+				{Name: "repository", Status: models.CheckOK, Detail: "customer-a/api (private)"},
+			},
+			RateLimit:      limitA,
+			EstimatedCalls: 70,
+		},
+		{
+			URL:      "https://github.com/customer-a/web",
+			Provider: models.ProviderGitHub,
+			Checks: []models.Check{
+				{Name: "repository", Status: models.CheckFailed, Detail: "404 Not Found"},
+			},
+			RateLimit:      limitA,
+			EstimatedCalls: 40,
+		},
+		{
+			URL:      "https://github.com/customer-b/api",
+			Provider: models.ProviderGitHub,
+			Checks: []models.Check{
+				{Name: "repository", Status: models.CheckOK, Detail: "customer-b/api (private)"},
+			},
+			RateLimit:      limitB,
+			EstimatedCalls: 25,
+		},
+	}
+	// Each customer's repositories are extracted with their own token
+	budgets := []models.ProviderPlan{
+		{Provider: models.ProviderGitHub, Host: "github.com", Repositories: []string{results[0].URL, results[1].URL}, EstimatedCalls: 110, RateLimit: limitA},
+		{
+			Provider: models.ProviderGitHub, Host: "github.com", Repositories: []string{results[2].URL}, EstimatedCalls: 25, RateLimit: limitB,
+			EstimatedQueryCost: 10, QueryRateLimit: limitB,
+		},
+		{Provider: "gerrit", Host: "review.example.com", Repositories: []string{"https://review.example.com/kernel"}, EstimatedCalls: 3},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printChecks(&out, results, budgets))
+
+	lines := out.String()
+	assert.Contains(t, lines, "REPOSITORY                         CHECK       STATUS  DETAIL\n")
+	assert.Contains(t, lines, "https://github.com/customer-a/api  repository  ok      customer-a/api (private)\n")
+	assert.Contains(t, lines, "https://github.com/customer-a/web  repository  failed  404 Not Found\n")
+	assert.Contains(t, lines, "github on github.com (2 repositories): 100 of 5000 requests remaining")
+	assert.Contains(t, lines, "about 110 needed\n")
+	assert.Contains(t, lines, "github on github.com (1 repository): 4000 of 5000 requests remaining")
+	assert.Contains(t, lines, "about 25 needed\n")
+	assert.Contains(t, lines, "github on github.com (1 repository): 4000 of 5000 GraphQL points remaining")
+	assert.Contains(t, lines, "about 10 needed\n")
+	assert.NotContains(t, lines, "gerrit on", "budgets without a rate limit are not reported")
+}
+
+func TestDoctorCommand_InvalidConfig(t *testing.T) {
+	cmd := NewDoctorCommand()
+	cmd.SetArgs([]string{"--config", "nonexistent.yaml"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read config file")
+}
@@ -4,71 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"text/tabwriter"
+	"time"
 
-	"github.com/jesper/review-extractor/internal/adapters/github"
-	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/extractor"
 	"github.com/jesper/review-extractor/pkg/models"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
-var (
-	configFile string
-	outputFile string
-)
-
-var extractCmd = &cobra.Command{
-	Use:   "extract",
-	Short: "Extract code review comments from Git platforms",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Load configuration
-		config, err := loadConfig(configFile)
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
-		}
-
-		// Override output file if specified
-		if outputFile != "" {
-			config.OutputFile = outputFile
-		}
-
-		// Create extractors
-		extractors := make(map[models.Provider]core.Extractor)
-
-		// Add GitHub extractor
-		extractors[models.ProviderGitHub] = github.NewExtractor(config.APIToken)
-
-		// Create main extractor
-		extractor := core.NewReviewExtractor(config, extractors)
-
-		// Extract reviews
-		ctx := context.Background()
-		result, err := extractor.ExtractReviews(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to extract reviews: %w", err)
-		}
-
-		// Write output
-		if err := writeOutput(result, config.OutputFile); err != nil {
-			return fmt.Errorf("failed to write output: %w", err)
-		}
-
-		fmt.Printf("Successfully extracted %d reviews from %d repositories\n",
-			result.TotalComments, result.RepositoriesProcessed)
-		return nil
-	},
-}
-
-func init() {
-	extractCmd.Flags().StringVar(&configFile, "config", "", "Path to configuration file")
-	extractCmd.Flags().StringVar(&outputFile, "output", "", "Path to output file (overrides config)")
-	if err := extractCmd.MarkFlagRequired("config"); err != nil {
-		panic(err) // or log.Fatal(err) if you prefer
-	}
-}
-
 // NewExtractCommand creates and returns the extract command
 func NewExtractCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -78,23 +24,49 @@ func NewExtractCommand() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			configPath, _ := cmd.Flags().GetString("config")
 			outputPath, _ := cmd.Flags().GetString("output")
+			suggestionsOnly, _ := cmd.Flags().GetBool("suggestions-only")
+			strictSecrets, _ := cmd.Flags().GetBool("strict-secrets")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 			// Load configuration
-			config, err := loadConfig(configPath)
+			config, err := loadConfig(configPath, strictSecrets)
 			if err != nil {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
-			// Create extractors map
-			extractors := map[models.Provider]core.Extractor{
-				models.ProviderGitHub: github.NewExtractor(config.GitHub.Token),
+			// Override filters if specified
+			if suggestionsOnly {
+				config.SuggestionsOnly = true
 			}
 
 			// Create extractor
-			extractor := core.NewReviewExtractor(config, extractors)
+			client, err := extractor.New(extractor.Options{Config: config})
+			if err != nil {
+				return fmt.Errorf("failed to create extractor: %w", err)
+			}
+
+			if dryRun {
+				plan, err := client.Plan(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to plan extraction: %w", err)
+				}
+				if err := printPlan(cmd.OutOrStdout(), plan); err != nil {
+					return fmt.Errorf("failed to write plan: %w", err)
+				}
+				if failed := plan.Failed(); failed > 0 {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("%d of %d repositories could not be planned", failed, len(plan.Repositories))
+				}
+				return nil
+			}
+
+			// JSON Lines output has no summary, so reviews are written as they are extracted
+			if isJSONL(outputPath) {
+				return extractJSONL(cmd.Context(), client, outputPath)
+			}
 
 			// Extract reviews
-			result, err := extractor.ExtractReviews(cmd.Context())
+			result, err := client.Extract(cmd.Context())
 			if err != nil {
 				return fmt.Errorf("failed to extract reviews: %w", err)
 			}
@@ -110,26 +82,89 @@ func NewExtractCommand() *cobra.Command {
 
 	cmd.Flags().String("config", "config.yaml", "Path to configuration file")
 	cmd.Flags().String("output", "reviews.json", "Path to output file")
+	cmd.Flags().Bool("suggestions-only", false, "Only export comments containing suggested changes")
+	cmd.Flags().Bool("strict-secrets", false, strictSecretsUsage)
+	cmd.Flags().Bool("dry-run", false, "Print the repositories, pull request counts and estimated API requests and duration of the run, without extracting")
 
 	return cmd
 }
 
-// loadConfig loads the configuration from a YAML file
-func loadConfig(path string) (*models.Config, error) {
-	data, err := os.ReadFile(path)
+// extractJSONL streams the reviews of a run into a JSON Lines file. If the run fails, the
+// file keeps the reviews extracted until then.
+func extractJSONL(ctx context.Context, client *extractor.Client, path string) error {
+	output, err := createJSONL(path)
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	_, err = client.Run(ctx, output)
+	closeErr := output.Close()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return fmt.Errorf("failed to extract reviews: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write output: %w", closeErr)
+	}
+	return nil
+}
+
+// printPlan prints the expected cost of a run per repository, followed by the rate limit
+// budget and the totals
+func printPlan(w io.Writer, plan *models.Plan) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tPROVIDER\tPULL REQUESTS\tAPI REQUESTS\tNOTE")
+	for _, repo := range plan.Repositories {
+		if repo.Error != "" {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\t%s\n", repo.URL, repo.Provider, repo.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t\n", repo.URL, repo.Provider, repo.PullRequests, repo.EstimatedCalls)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
 	}
 
-	var config models.Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	fmt.Fprintln(w)
+	for _, provider := range plan.Providers {
+		// Each line is one rate limit budget; a host can have several, one per credential
+		repositories := "repositories"
+		if len(provider.Repositories) == 1 {
+			repositories = "repository"
+		}
+		fmt.Fprintf(w, "%s on %s (%d %s): about %d requests", provider.Provider, provider.Host,
+			len(provider.Repositories), repositories, provider.EstimatedCalls)
+		if limit := provider.RateLimit; limit != nil {
+			fmt.Fprintf(w, ", %d of %d remaining (resets %s)",
+				limit.Remaining, limit.Limit, limit.Reset.Local().Format(time.RFC3339))
+		}
+		if limit := provider.QueryRateLimit; limit != nil {
+			fmt.Fprintf(w, ", about %d GraphQL points, %d of %d remaining (resets %s)", provider.EstimatedQueryCost,
+				limit.Remaining, limit.Limit, limit.Reset.Local().Format(time.RFC3339))
+		}
+		fmt.Fprintf(w, ", estimated duration %s\n", provider.EstimatedDuration.Round(time.Second))
 	}
+	fmt.Fprintf(w, "Plan: %d repositories, %d pull requests, about %d API requests, estimated duration %s\n",
+		len(plan.Repositories), plan.PullRequests, plan.EstimatedCalls, plan.EstimatedDuration.Round(time.Second))
 
-	return &config, nil
+	return nil
 }
 
-// writeOutput writes the extraction result to a JSON file
+// strictSecretsUsage describes the --strict-secrets flag shared by commands loading a configuration
+const strictSecretsUsage = "Reject tokens written literally in the configuration; require ${ENV_VAR}, *_file or *_cmd"
+
+// loadConfig loads and validates the configuration from a YAML file. With strictSecrets,
+// tokens must come from the environment, a file or a command.
+func loadConfig(path string, strictSecrets bool) (*models.Config, error) {
+	cfg, _, err := extractor.LoadConfig(path, extractor.ConfigOptions{StrictSecrets: strictSecrets})
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// writeOutput writes the extraction result to a JSON file, or a JSON Lines file
+// with one review per line if the path ends in .jsonl
 func writeOutput(result *models.ExtractionResult, path string) error {
 	// Create output directory if it doesn't exist
 	dir := filepath.Dir(path)
@@ -137,8 +172,14 @@ func writeOutput(result *models.ExtractionResult, path string) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(result, "", "  ")
+	// Marshal to JSON with indentation, or one compact review per line
+	var data []byte
+	var err error
+	if isJSONL(path) {
+		data, err = marshalJSONL(result)
+	} else {
+		data, err = json.MarshalIndent(result, "", "  ")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to marshal result: %w", err)
 	}
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/jesper/review-extractor/pkg/extractor"
 	"github.com/jesper/review-extractor/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
@@ -33,7 +38,7 @@ func TestLoadConfig(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test loading config
-	loadedConfig, err := loadConfig(configPath)
+	loadedConfig, err := loadConfig(configPath, false)
 	assert.NoError(t, err)
 	assert.Equal(t, config.APIToken, loadedConfig.APIToken)
 	assert.Equal(t, config.OutputFile, loadedConfig.OutputFile)
@@ -42,7 +47,7 @@ func TestLoadConfig(t *testing.T) {
 }
 
 func TestLoadConfig_FileNotFound(t *testing.T) {
-	_, err := loadConfig("nonexistent.yaml")
+	_, err := loadConfig("nonexistent.yaml", false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to read config file")
 }
@@ -55,7 +60,7 @@ func TestLoadConfig_InvalidYAML(t *testing.T) {
 	err := os.WriteFile(configPath, []byte("invalid: yaml: content: [}"), 0644)
 	assert.NoError(t, err)
 
-	_, err = loadConfig(configPath)
+	_, err = loadConfig(configPath, false)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse config file")
 }
@@ -87,6 +92,51 @@ func TestWriteOutput(t *testing.T) {
 	assert.Contains(t, string(data), "total_comments")
 }
 
+// sliceExtractor returns fixed reviews, followed by an error for the second repository
+type sliceExtractor struct {
+	reviews []models.Review
+}
+
+func (s sliceExtractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	if repoURL == "https://github.com/test/broken" {
+		return nil, errors.New("API error")
+	}
+	return s.reviews, nil
+}
+
+func TestExtractJSONL(t *testing.T) {
+	pr := &models.PullRequest{Number: 1, Provider: models.ProviderGitHub, Title: "Test PR",
+		Repository: models.RepositoryRef{Host: "github.com", Owner: "test", Name: "repo"}}
+	config := &models.Config{Repositories: []models.RepositoryConfig{
+		{Provider: "test", URL: "https://github.com/test/repo"},
+		{Provider: "test", URL: "https://github.com/test/broken"},
+	}}
+	client, err := extractor.New(extractor.Options{
+		Config: config,
+		Providers: []extractor.ProviderFactory{{
+			Name:        "test",
+			ValidateURL: func(string) error { return nil },
+			New: func(*models.Config, extractor.CredentialSource) extractor.ProviderExtractor {
+				return sliceExtractor{reviews: []models.Review{
+					{PRID: 1, CommentID: "1", Repo: pr.Repository, PullRequest: pr},
+					{PRID: 1, CommentID: "2", Repo: pr.Repository, PullRequest: pr},
+				}}
+			},
+		}},
+	})
+	assert.NoError(t, err)
+	outputPath := filepath.Join(t.TempDir(), "out", "reviews.jsonl")
+
+	err = extractJSONL(context.Background(), client, outputPath)
+	assert.EqualError(t, err, "failed to extract reviews: failed to extract reviews from https://github.com/test/broken: API error")
+
+	// The reviews of the first repository were written before the second failed
+	result, err := readResult(outputPath)
+	assert.NoError(t, err)
+	assert.Len(t, result.Reviews, 2)
+	assert.Equal(t, []models.PullRequest{*pr}, result.PullRequests)
+}
+
 func TestWriteOutput_CreateDirectory(t *testing.T) {
 	// Create temporary base directory
 	tmpDir := t.TempDir()
@@ -117,3 +167,40 @@ func TestWriteOutput_InvalidPath(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create output directory")
 }
+
+func TestPrintPlan(t *testing.T) {
+	plan := &models.Plan{
+		Repositories: []models.RepositoryPlan{
+			{URL: "https://github.com/customer-a/api", Provider: models.ProviderGitHub, PullRequests: 10, EstimatedCalls: 31},
+			{URL: "https://github.com/customer-a/web", Provider: models.ProviderGitHub, Error: "404 Not Found"},
+		},
+		Providers: []models.ProviderPlan{
+			{
+				Provider:          models.ProviderGitHub,
+				Host:              "github.com",
+				Repositories:      []string{"https://github.com/customer-a/api"},
+				EstimatedCalls:    31,
+				EstimatedDuration: 7750 * time.Millisecond,
+			},
+		},
+		PullRequests:      10,
+		EstimatedCalls:    31,
+		EstimatedDuration: 7750 * time.Millisecond,
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, printPlan(&out, plan))
+	assert.Equal(t, "REPOSITORY                         PROVIDER  PULL REQUESTS  API REQUESTS  NOTE\n"+
+		"https://github.com/customer-a/api  github    10             31            \n"+
+		"https://github.com/customer-a/web  github    -              -             404 Not Found\n"+
+		"\n"+
+		"github on github.com (1 repository): about 31 requests, estimated duration 8s\n"+
+		"Plan: 2 repositories, 10 pull requests, about 31 API requests, estimated duration 8s\n", out.String())
+
+	// With GraphQL, list queries are counted against a budget of their own
+	plan.Providers[0].EstimatedQueryCost = 10
+	plan.Providers[0].QueryRateLimit = &models.RateLimit{Limit: 5000, Remaining: 4990, Reset: time.Now()}
+	out.Reset()
+	assert.NoError(t, printPlan(&out, plan))
+	assert.Contains(t, out.String(), "github on github.com (1 repository): about 31 requests, about 10 GraphQL points, 4990 of 5000 remaining")
+}
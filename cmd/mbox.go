@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jesper/review-extractor/internal/mbox"
+	"github.com/jesper/review-extractor/pkg/extractor"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// NewImportMboxCommand creates and returns the import-mbox command
+func NewImportMboxCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-mbox <mbox-file>...",
+		Short: "Import patch reviews from mailing-list archives",
+		Long: `Import patch reviews from mbox files, e.g. downloaded from a list archive. [PATCH] series
+become pull requests and the replies in their threads become reviews; comments below quoted
+diff lines are anchored to the file and line they quote. No network access is needed.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputPath, _ := cmd.Flags().GetString("output")
+			repoURL, _ := cmd.Flags().GetString("repo")
+			archiveURL, _ := cmd.Flags().GetString("archive-url")
+
+			repo, err := models.ParseRepositoryURL(repoURL)
+			if err != nil {
+				return fmt.Errorf("invalid --repo: %w", err)
+			}
+
+			// Read all files first so threads spanning several archives are reconstructed
+			var messages []*mbox.Message
+			for _, path := range args {
+				read, err := readMbox(path)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", path, err)
+				}
+				messages = append(messages, read...)
+			}
+
+			reviews := mbox.Import(messages, mbox.Options{Repo: repo, ArchiveURL: archiveURL})
+			result := extractor.NewResult(reviews, 1)
+
+			// Write output
+			if err := writeOutput(result, outputPath); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %d reviews of %d patch series from %d messages\n",
+				result.TotalComments, len(result.PullRequests), len(messages))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("repo", "", "URL of the repository the patches were sent for")
+	cmd.Flags().String("archive-url", "", "List archive URL that Message-IDs are appended to, e.g. https://lore.kernel.org/r/")
+	cmd.Flags().String("output", "reviews.json", "Path to output file")
+	if err := cmd.MarkFlagRequired("repo"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// readMbox reads the messages of an mbox file
+func readMbox(path string) ([]*mbox.Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return mbox.Read(file)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMbox = `From alice@example.com Mon Jan  1 00:00:00 2024
+Message-Id: <patch@example.com>
+From: Alice <alice@example.com>
+Date: Mon, 1 Jan 2024 10:00:00 +0000
+Subject: [PATCH] util: fix off-by-one
+
+---
+diff --git a/util.c b/util.c
+--- a/util.c
++++ b/util.c
+@@ -1,3 +1,3 @@
+ int last(int n)
+ {
+-	return n;
++	return n - 1;
+
+From bob@example.com Mon Jan  1 00:00:00 2024
+Message-Id: <reply@example.com>
+In-Reply-To: <patch@example.com>
+From: Bob <bob@example.com>
+Date: Mon, 1 Jan 2024 11:00:00 +0000
+Subject: Re: [PATCH] util: fix off-by-one
+
+> +	return n - 1;
+
+What if n is 0?
+`
+
+func TestImportMboxCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	mboxPath := filepath.Join(tmpDir, "thread.mbox")
+	outputPath := filepath.Join(tmpDir, "reviews.json")
+	assert.NoError(t, os.WriteFile(mboxPath, []byte(testMbox), 0644))
+
+	var out bytes.Buffer
+	cmd := NewImportMboxCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{mboxPath, "--repo", "https://git.example.com/libs/util.git", "--output", outputPath})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Imported 1 reviews of 1 patch series from 2 messages")
+
+	result, err := readResult(outputPath)
+	assert.NoError(t, err)
+	assert.Len(t, result.Reviews, 1)
+	review := result.Reviews[0]
+	assert.Equal(t, "What if n is 0?", review.CommentText)
+	assert.Equal(t, "util.c", review.FilePath)
+	assert.Equal(t, 3, review.LineNumber)
+	assert.Equal(t, models.RepositoryRef{Host: "git.example.com", Owner: "libs", Name: "util", URL: "https://git.example.com/libs/util"}, review.Repo)
+	assert.Len(t, result.PullRequests, 1)
+	assert.Equal(t, 1, result.RepositoriesProcessed)
+}
+
+func TestImportMboxCommand_Errors(t *testing.T) {
+	cmd := NewImportMboxCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"missing.mbox", "--repo", "https://git.example.com/libs/util"})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read missing.mbox")
+
+	cmd = NewImportMboxCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"thread.mbox", "--repo", "util"})
+	assert.ErrorContains(t, cmd.Execute(), "invalid --repo")
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// NewMergeCommand creates and returns the merge command
+func NewMergeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge <output-file>...",
+		Short: "Merge extraction outputs into one",
+		Long: `Merge extraction outputs, e.g. from per-customer configs, into a single output.
+Comments are deduplicated by provider, repository and comment ID, keeping the copy from
+the most recent extraction, and statistics are recomputed.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputPath, _ := cmd.Flags().GetString("output")
+
+			// Load outputs individually so duplicates can be resolved by extraction time
+			results := make([]*models.ExtractionResult, 0, len(args))
+			for _, path := range args {
+				result, err := readResult(path)
+				if err != nil {
+					return fmt.Errorf("failed to read outputs: %w", err)
+				}
+				results = append(results, result)
+			}
+
+			merged := core.MergeResults(results...)
+
+			// Write output
+			if err := writeOutput(merged, outputPath); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Merged %d files into %d reviews from %d repositories\n",
+				len(args), merged.TotalComments, merged.RepositoriesProcessed)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output", "merged.json", "Path to output file")
+
+	return cmd
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "customer-a.json")
+	second := filepath.Join(tmpDir, "customer-b.jsonl")
+	outputPath := filepath.Join(tmpDir, "merged.json")
+
+	resultA := testResult()
+	resultB := testResult()
+	resultB.ExtractedAt = time.Now()
+	resultB.Reviews[0].CommentText = "edited"
+	resultB.Reviews = append(resultB.Reviews, models.Review{
+		PRID:       2,
+		Repository: "web",
+		Repo:       models.RepositoryRef{Host: "github.com", Owner: "customer-b", Name: "web"},
+		CommentID:  "9",
+	})
+	assert.NoError(t, writeOutput(resultA, first))
+	assert.NoError(t, writeOutput(resultB, second))
+
+	var out bytes.Buffer
+	cmd := NewMergeCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{first, second, "--output", outputPath})
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Merged 2 files into 3 reviews from 2 repositories")
+
+	merged, err := readResult(outputPath)
+	assert.NoError(t, err)
+	assert.Len(t, merged.Reviews, 3)
+	assert.Equal(t, "edited", merged.Reviews[0].CommentText)
+	assert.Equal(t, 3, merged.Statistics.TotalReviews)
+}
+
+func TestDiffCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldPath := filepath.Join(tmpDir, "old.json")
+	newPath := filepath.Join(tmpDir, "new.json")
+	changesPath := filepath.Join(tmpDir, "changes.json")
+
+	older := testResult()
+	newer := testResult()
+	newer.Reviews[0].CommentText = "edited"
+	newer.Reviews = newer.Reviews[:1]
+	assert.NoError(t, writeOutput(older, oldPath))
+	assert.NoError(t, writeOutput(newer, newPath))
+
+	var out bytes.Buffer
+	cmd := NewDiffCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{oldPath, newPath, "--output", changesPath})
+	assert.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), "0 added, 1 removed, 1 edited, 0 unchanged")
+	assert.Contains(t, out.String(), "- github/github.com/customer-a/api/review/2")
+	assert.Contains(t, out.String(), "~ github/github.com/customer-a/api/comment/1 [comment_text]")
+
+	data, err := os.ReadFile(changesPath)
+	assert.NoError(t, err)
+	var diff core.ResultDiff
+	assert.NoError(t, json.Unmarshal(data, &diff))
+	assert.Equal(t, core.DiffSummary{Removed: 1, Edited: 1}, diff.Summary)
+	assert.Len(t, diff.Changes, 2)
+}
+
+func TestDiffCommand_MissingFile(t *testing.T) {
+	cmd := NewDiffCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"missing-old.json", "missing-new.json"})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read outputs")
+}
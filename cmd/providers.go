@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jesper/review-extractor/pkg/extractor"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// NewProvidersCommand creates and returns the providers command
+func NewProvidersCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "List the supported providers",
+		Long: `List the providers repositories can be extracted from, with an example repository URL,
+the credential methods they accept and the features they support. With --config, the
+providers of the configured plugins are listed as well.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+
+			providers := extractor.Providers()
+			if configPath != "" {
+				config, err := loadConfig(configPath, false)
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				client, err := extractor.New(extractor.Options{Config: config})
+				if err != nil {
+					return fmt.Errorf("failed to create extractor: %w", err)
+				}
+				providers = client.Providers()
+			}
+
+			return printProviders(cmd.OutOrStdout(), providers)
+		},
+	}
+
+	cmd.Flags().String("config", "", "Path to a configuration file whose plugins to list as well")
+
+	return cmd
+}
+
+// printProviders prints a table of providers followed by their provider-specific
+// configuration keys. Plugins, which check URLs and credentials themselves, have no example
+// and accept any credential method.
+func printProviders(w io.Writer, factories []extractor.ProviderFactory) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tDESCRIPTION\tEXAMPLE\tAUTH\tFEATURES")
+	for _, factory := range factories {
+		example, methods := factory.Example, []string{"any"}
+		if example == "" {
+			example = "-"
+		}
+		if len(factory.AuthMethods) > 0 {
+			methods = methods[:0]
+			for _, method := range factory.AuthMethods {
+				methods = append(methods, string(method))
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", factory.Name, factory.Description, example,
+			strings.Join(methods, ", "), features(factory.New(&models.Config{}, nil)))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, factory := range factories {
+		if len(factory.Settings) > 0 {
+			fmt.Fprintf(w, "\n%s settings: %s\n", factory.Name, strings.Join(factory.Settings, ", "))
+		}
+	}
+	return nil
+}
+
+// features lists the optional interfaces an extractor implements besides extraction
+func features(providerExtractor extractor.ProviderExtractor) string {
+	names := []string{"extract"}
+	if _, ok := providerExtractor.(extractor.Checker); ok {
+		names = append(names, "check")
+	}
+	if _, ok := providerExtractor.(extractor.Planner); ok {
+		names = append(names, "plan")
+	}
+	if _, ok := providerExtractor.(extractor.Discoverer); ok {
+		names = append(names, "discover")
+	}
+	return strings.Join(names, ", ")
+}
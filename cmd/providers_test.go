@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvidersCommand(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api_token: secret
+plugins:
+  - provider: critic
+    command: review-extractor-critic
+repositories:
+  - provider: critic
+    url: https://critic.example.com/tools/api
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	var out bytes.Buffer
+	cmd := NewProvidersCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--config", configPath})
+	assert.NoError(t, cmd.Execute())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 10)
+	assert.Regexp(t, `^PROVIDER +DESCRIPTION +EXAMPLE +AUTH +FEATURES$`, lines[0])
+	assert.Regexp(t, `^azure_devops +Azure DevOps .* +bearer, basic, app_password +extract, check, plan$`, lines[1])
+	assert.Regexp(t, `^critic +plugin review-extractor-critic +- +any +extract, plan$`, lines[3], "plugins are listed with the other providers")
+	assert.Regexp(t, `^github +.* +https://github.com/<owner>/<repo> +.*github_app +extract, check, plan, discover$`, lines[7])
+	assert.Equal(t, "github settings: github.api, github.detect_addressed, github.include_empty_reviews, github.token", lines[9])
+}
+
+func TestLoadConfig_InferProvider(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api_token: secret
+repositories:
+  - url: https://github.com/customer-a/api
+  - url: https://codeberg.org/customer-a/api
+  - url: https://dev.azure.com/customer-a/platform/_git/api
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	config, err := loadConfig(configPath, false)
+	assert.NoError(t, err)
+	var providers []models.Provider
+	for _, repo := range config.Repositories {
+		providers = append(providers, repo.Provider)
+	}
+	assert.Equal(t, []models.Provider{models.ProviderGitHub, models.ProviderForgejo, models.ProviderAzureDevOps}, providers)
+
+	data = `api_token: secret
+repositories:
+  - url: https://git.example.com/customer-a/api
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+	_, err = loadConfig(configPath, false)
+	assert.ErrorContains(t, err, `repositories[0]: provider is required; it cannot be inferred from "https://git.example.com/customer-a/api"`)
+}
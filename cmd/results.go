@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// jsonlRecord is a single line of JSONL output: a review with its pull request inlined
+type jsonlRecord struct {
+	models.Review
+	PullRequest *models.PullRequest `json:"pull_request,omitempty"`
+}
+
+// jsonlWriter writes reviews to a JSON Lines file as they are extracted
+type jsonlWriter struct {
+	file    *os.File
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// createJSONL creates a JSON Lines output file, and its directory if needed
+func createJSONL(path string) (*jsonlWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	buf := bufio.NewWriter(file)
+	return &jsonlWriter{file: file, buf: buf, encoder: json.NewEncoder(buf)}, nil
+}
+
+// WriteReview implements the core.Sink interface
+func (w *jsonlWriter) WriteReview(review models.Review) error {
+	return w.encoder.Encode(jsonlRecord{Review: review, PullRequest: review.PullRequest})
+}
+
+// Close writes out buffered reviews and closes the file
+func (w *jsonlWriter) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// isJSONL reports whether the path names a JSON Lines file
+func isJSONL(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".jsonl" || ext == ".ndjson"
+}
+
+// readResults reads previous extraction outputs and combines them into a single result
+func readResults(paths []string) (*models.ExtractionResult, error) {
+	combined := &models.ExtractionResult{SchemaVersion: models.SchemaVersion}
+	seen := make(map[string]bool)
+
+	for _, path := range paths {
+		result, err := readResult(path)
+		if err != nil {
+			return nil, err
+		}
+
+		combined.Reviews = append(combined.Reviews, result.Reviews...)
+		combined.RepositoriesProcessed += result.RepositoriesProcessed
+		if result.ExtractedAt.After(combined.ExtractedAt) {
+			combined.ExtractedAt = result.ExtractedAt
+		}
+
+		for _, pr := range result.PullRequests {
+			key := fmt.Sprintf("%s#%d", pr.Repository.Key(), pr.Number)
+			if !seen[key] {
+				seen[key] = true
+				combined.PullRequests = append(combined.PullRequests, pr)
+			}
+		}
+	}
+
+	combined.TotalComments = len(combined.Reviews)
+	return combined, nil
+}
+
+// readResult reads a single JSON or JSONL extraction output
+func readResult(path string) (*models.ExtractionResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if !isJSONL(path) {
+		var result models.ExtractionResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return &result, nil
+	}
+
+	result := &models.ExtractionResult{}
+	seen := make(map[string]bool)
+	repositories := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		// Review has its own UnmarshalJSON, so the inlined pull request is decoded separately
+		var review models.Review
+		if err := json.Unmarshal(line, &review); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, lineNumber, err)
+		}
+		var record struct {
+			PullRequest *models.PullRequest `json:"pull_request"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", path, lineNumber, err)
+		}
+
+		result.Reviews = append(result.Reviews, review)
+		repositories[review.RepositoryKey()] = true
+
+		if pr := record.PullRequest; pr != nil {
+			key := fmt.Sprintf("%s#%d", pr.Repository.Key(), pr.Number)
+			if !seen[key] {
+				seen[key] = true
+				result.PullRequests = append(result.PullRequests, *pr)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	result.TotalComments = len(result.Reviews)
+	result.RepositoriesProcessed = len(repositories)
+	return result, nil
+}
+
+// marshalJSONL renders one review per line, each with its pull request inlined
+func marshalJSONL(result *models.ExtractionResult) ([]byte, error) {
+	pullRequests := make(map[string]*models.PullRequest, len(result.PullRequests))
+	for i := range result.PullRequests {
+		pr := &result.PullRequests[i]
+		pullRequests[fmt.Sprintf("%s#%d", pr.Repository.Key(), pr.Number)] = pr
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, review := range result.Reviews {
+		pr := review.PullRequest
+		if pr == nil {
+			pr = pullRequests[fmt.Sprintf("%s#%d", review.RepositoryKey(), review.PRID)]
+		}
+		if err := encoder.Encode(jsonlRecord{Review: review, PullRequest: pr}); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/extractor"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// NewStatsCommand creates and returns the stats command
+func NewStatsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats <output-file>...",
+		Short: "Compute statistics from previous extraction outputs",
+		Long: `Compute review statistics from one or more previous JSON or JSONL extraction outputs,
+optionally filtered by repository, comment author and date range, without calling any APIs.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repository, _ := cmd.Flags().GetString("repo")
+			author, _ := cmd.Flags().GetString("author")
+			sinceFlag, _ := cmd.Flags().GetString("since")
+			untilFlag, _ := cmd.Flags().GetString("until")
+			format, _ := cmd.Flags().GetString("format")
+			outputPath, _ := cmd.Flags().GetString("output")
+
+			filter := extractor.ReviewFilter{
+				Repository: repository,
+				Author:     author,
+			}
+
+			var err error
+			if filter.Since, err = parseDate(sinceFlag, false); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if filter.Until, err = parseDate(untilFlag, true); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
+			// Load previous outputs
+			result, err := readResults(args)
+			if err != nil {
+				return fmt.Errorf("failed to read outputs: %w", err)
+			}
+
+			// Recompute statistics for the selected reviews
+			stats := extractor.Filter(result, filter).Statistics
+
+			// Write statistics
+			if outputPath == "" {
+				if err := renderStatistics(cmd.OutOrStdout(), stats, format); err != nil {
+					return fmt.Errorf("failed to write statistics: %w", err)
+				}
+				return nil
+			}
+
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			file, err := os.Create(outputPath)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+
+			// Closing flushes the file, so its error means the statistics were not written
+			err = renderStatistics(file, stats, format)
+			closeErr := file.Close()
+			if err != nil {
+				return fmt.Errorf("failed to write statistics: %w", err)
+			}
+			if closeErr != nil {
+				return fmt.Errorf("failed to write statistics: %w", closeErr)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("repo", "", "Only include reviews from this repository (host/owner/name, owner/name or name)")
+	cmd.Flags().String("author", "", "Only include comments by this author")
+	cmd.Flags().String("since", "", "Only include comments created on or after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().String("until", "", "Only include comments created before this time, or on or before this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().String("format", "table", "Output format: table, json or markdown")
+	cmd.Flags().String("output", "", "Path to output file (defaults to stdout)")
+
+	return cmd
+}
+
+// parseDate parses a YYYY-MM-DD date or an RFC 3339 timestamp. When endOfDay is set,
+// a plain date is moved to the start of the following day so the whole day is included.
+func parseDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// statsSection is a titled table of statistics
+type statsSection struct {
+	title   string
+	headers []string
+	rows    [][]string
+}
+
+// renderStatistics writes the statistics in the given format
+func renderStatistics(w io.Writer, stats models.Statistics, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	case "table":
+		return renderTables(w, statisticsSections(stats))
+	case "markdown", "md":
+		return renderMarkdown(w, statisticsSections(stats))
+	default:
+		return fmt.Errorf("unsupported format %q (expected table, json or markdown)", format)
+	}
+}
+
+// statisticsSections lays out the statistics as tables
+func statisticsSections(stats models.Statistics) []statsSection {
+	sections := []statsSection{
+		{
+			title:   "Summary",
+			headers: []string{"Metric", "Value"},
+			rows: [][]string{
+				{"Comments", fmt.Sprintf("%d", stats.TotalReviews)},
+				{"Pull requests", fmt.Sprintf("%d", stats.TotalPRs)},
+				{"Comments per pull request", fmt.Sprintf("%.2f", stats.ReviewFrequency)},
+				{"Average pull request size (lines)", fmt.Sprintf("%.1f", stats.AveragePRSize)},
+				{"Inline comments per changed line", fmt.Sprintf("%.4f", stats.CommentDensity)},
+			},
+		},
+		countSection("Reviewers", "Reviewer", stats.Reviewers),
+		countSection("Repositories", "Repository", stats.Repositories),
+		pathSection("Files", "File", stats.Files),
+		pathSection("Directories", "Directory", stats.Directories),
+	}
+
+	languages := statsSection{title: "Languages", headers: []string{"Language", "Comments", "Top file"}}
+	for _, l := range stats.Languages {
+		topFile := ""
+		if len(l.Files) > 0 {
+			topFile = fmt.Sprintf("%s (%d)", path.Join(l.Files[0].Repository, l.Files[0].Name), l.Files[0].Count)
+		}
+		languages.rows = append(languages.rows, []string{l.Language, fmt.Sprintf("%d", l.Comments), topFile})
+	}
+	sections = append(sections, languages)
+
+	timings := statsSection{
+		title:   "Timings (hours)",
+		headers: []string{"Metric", "Count", "Mean", "P50", "P75", "P90", "P95", "Max"},
+	}
+	for _, d := range []struct {
+		name         string
+		distribution models.Distribution
+	}{
+		{"Time to first review", stats.TimeToFirstReview},
+		{"Time to merge", stats.TimeToMerge},
+	} {
+		timings.rows = append(timings.rows, []string{
+			d.name,
+			fmt.Sprintf("%d", d.distribution.Count),
+			fmt.Sprintf("%.1f", d.distribution.Mean),
+			fmt.Sprintf("%.1f", d.distribution.P50),
+			fmt.Sprintf("%.1f", d.distribution.P75),
+			fmt.Sprintf("%.1f", d.distribution.P90),
+			fmt.Sprintf("%.1f", d.distribution.P95),
+			fmt.Sprintf("%.1f", d.distribution.Max),
+		})
+	}
+	sections = append(sections, timings)
+
+	interactions := statsSection{title: "Interactions", headers: []string{"Reviewer", "Author", "Comments"}}
+	for _, i := range stats.Interactions {
+		interactions.rows = append(interactions.rows, []string{i.Reviewer, i.Author, fmt.Sprintf("%d", i.Comments)})
+	}
+	sections = append(sections, interactions)
+
+	weekly := statsSection{title: "Weekly activity", headers: []string{"Week", "Comments", "Pull requests", "Reviewers"}}
+	for _, w := range stats.Weekly {
+		weekly.rows = append(weekly.rows, []string{
+			w.WeekStart.Format("2006-01-02"),
+			fmt.Sprintf("%d", w.Comments),
+			fmt.Sprintf("%d", w.PullRequests),
+			fmt.Sprintf("%d", w.Reviewers),
+		})
+	}
+	sections = append(sections, weekly)
+
+	return sections
+}
+
+// countSection lays out a ranked list of counts
+func countSection(title, header string, counts []models.Count) statsSection {
+	section := statsSection{title: title, headers: []string{header, "Comments"}}
+	for _, c := range counts {
+		section.rows = append(section.rows, []string{c.Name, fmt.Sprintf("%d", c.Count)})
+	}
+	return section
+}
+
+// pathSection lays out counts of files or directories with the repository they belong to
+func pathSection(title, header string, counts []models.Count) statsSection {
+	section := statsSection{title: title, headers: []string{"Repository", header, "Comments"}}
+	for _, c := range counts {
+		section.rows = append(section.rows, []string{c.Repository, c.Name, fmt.Sprintf("%d", c.Count)})
+	}
+	return section
+}
+
+// renderTables writes the sections as aligned plain-text tables
+func renderTables(w io.Writer, sections []statsSection) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, section.title)
+		fmt.Fprintln(tw, strings.Join(section.headers, "\t"))
+		if len(section.rows) == 0 {
+			fmt.Fprintln(tw, "(none)")
+		}
+		for _, row := range section.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+	return tw.Flush()
+}
+
+// renderMarkdown writes the sections as Markdown tables
+func renderMarkdown(w io.Writer, sections []statsSection) error {
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s\n\n", section.title)
+		if len(section.rows) == 0 {
+			fmt.Fprintln(w, "_None_")
+			continue
+		}
+
+		separators := make([]string, len(section.headers))
+		for j := range separators {
+			separators[j] = "---"
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(section.headers, " | "))
+		fmt.Fprintf(w, "| %s |\n", strings.Join(separators, " | "))
+		for _, row := range section.rows {
+			cells := make([]string, len(row))
+			for j, cell := range row {
+				cells[j] = strings.ReplaceAll(cell, "|", "\\|")
+			}
+			if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
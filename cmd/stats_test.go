@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResult() *models.ExtractionResult {
+	repo := models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}
+	created := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+
+	return &models.ExtractionResult{
+		SchemaVersion: models.SchemaVersion,
+		Reviews: []models.Review{
+			{PRID: 1, Repository: "api", Repo: repo, Provider: models.ProviderGitHub, CommentID: "1", CommentAuthor: "jane", PRAuthor: "john",
+				CommentCreated: created.Add(time.Hour), FilePath: "main.go"},
+			{PRID: 1, Repository: "api", Repo: repo, Provider: models.ProviderGitHub, CommentID: "2", CommentAuthor: "bob", PRAuthor: "john",
+				CommentCreated: created.AddDate(0, 0, 10)},
+		},
+		PullRequests: []models.PullRequest{
+			{Number: 1, Repository: repo, Author: "john", CreatedAt: created, Additions: 10},
+		},
+		TotalComments:         2,
+		RepositoriesProcessed: 1,
+	}
+}
+
+func TestReadResults_JSONAndJSONL(t *testing.T) {
+	tmpDir := t.TempDir()
+	jsonPath := filepath.Join(tmpDir, "a.json")
+	jsonlPath := filepath.Join(tmpDir, "b.jsonl")
+
+	assert.NoError(t, writeOutput(testResult(), jsonPath))
+	assert.NoError(t, writeOutput(testResult(), jsonlPath))
+
+	// JSONL holds one review per line with its pull request inlined
+	data, err := os.ReadFile(jsonlPath)
+	assert.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Contains(t, string(lines[0]), `"pull_request":{"number":1`)
+
+	fromJSONL, err := readResult(jsonlPath)
+	assert.NoError(t, err)
+	assert.Len(t, fromJSONL.Reviews, 2)
+	assert.Equal(t, testResult().PullRequests, fromJSONL.PullRequests)
+	assert.Equal(t, 1, fromJSONL.RepositoriesProcessed)
+
+	combined, err := readResults([]string{jsonPath, jsonlPath})
+	assert.NoError(t, err)
+	assert.Len(t, combined.Reviews, 4)
+	assert.Len(t, combined.PullRequests, 1)
+	assert.Equal(t, 4, combined.TotalComments)
+}
+
+func TestReadResults_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_, err := readResults([]string{filepath.Join(tmpDir, "missing.json")})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read")
+
+	invalid := filepath.Join(tmpDir, "invalid.jsonl")
+	assert.NoError(t, os.WriteFile(invalid, []byte("{\"pr_id\":1}\nnot json\n"), 0644))
+	_, err = readResults([]string{invalid})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestReadResult_LegacyJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"reviews":[{"pr_id":1,"repository":"api","comment_id":"1"}]}`), 0644))
+
+	result, err := readResult(path)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RepositoryRef{Name: "api"}, result.Reviews[0].Repo)
+}
+
+func TestStatsCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "reviews.json")
+	assert.NoError(t, writeOutput(testResult(), inputPath))
+
+	t.Run("json with filters", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewStatsCommand()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{inputPath, "--format", "json", "--author", "jane", "--until", "2024-06-03"})
+		assert.NoError(t, cmd.Execute())
+
+		var stats models.Statistics
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &stats))
+		assert.Equal(t, 1, stats.TotalReviews)
+		assert.Equal(t, []models.Count{{Name: "jane", Count: 1}}, stats.Reviewers)
+		assert.Equal(t, 1, stats.TimeToFirstReview.Count)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := NewStatsCommand()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{inputPath})
+		assert.NoError(t, cmd.Execute())
+
+		assert.Contains(t, out.String(), "Reviewers")
+		assert.Contains(t, out.String(), "github.com/customer-a/api")
+		assert.Contains(t, out.String(), "Time to first review")
+	})
+
+	t.Run("markdown to file", func(t *testing.T) {
+		outputPath := filepath.Join(tmpDir, "report", "stats.md")
+		cmd := NewStatsCommand()
+		cmd.SetArgs([]string{inputPath, "--format", "markdown", "--output", outputPath, "--repo", "customer-a/api"})
+		assert.NoError(t, cmd.Execute())
+
+		data, err := os.ReadFile(outputPath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "## Summary")
+		assert.Contains(t, string(data), "| Reviewer | Comments |")
+		assert.Contains(t, string(data), "| jane | 1 |")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		cmd := NewStatsCommand()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{inputPath, "--format", "xml"})
+		assert.ErrorContains(t, cmd.Execute(), "unsupported format")
+
+		cmd.SetArgs([]string{inputPath, "--since", "yesterday"})
+		assert.ErrorContains(t, cmd.Execute(), "invalid --since")
+	})
+}
+
+func TestParseDate(t *testing.T) {
+	since, err := parseDate("2024-06-03", false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), since)
+
+	until, err := parseDate("2024-06-03", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC), until)
+
+	exact, err := parseDate("2024-06-03T10:00:00Z", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC), exact)
+
+	zero, err := parseDate("", false)
+	assert.NoError(t, err)
+	assert.True(t, zero.IsZero())
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jesper/review-extractor/pkg/extractor"
+	"github.com/spf13/cobra"
+)
+
+// NewValidateCommand creates and returns the validate command
+func NewValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check a configuration file for problems",
+		Long: `Check a configuration file for unknown keys, missing fields, unsupported providers,
+invalid or duplicate repository URLs and missing tokens, reporting every problem with its line number.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			strictSecrets, _ := cmd.Flags().GetBool("strict-secrets")
+
+			_, problems, err := extractor.LoadConfig(configPath, extractor.ConfigOptions{StrictSecrets: strictSecrets})
+			var validationErr *extractor.ValidationError
+			if err != nil && !errors.As(err, &validationErr) {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, problem := range problems {
+				fmt.Fprintf(out, "%s: %s\n", configPath, problem)
+			}
+
+			if validationErr != nil {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%s is invalid: %d problem(s) found", configPath, len(problems))
+			}
+
+			fmt.Fprintf(out, "%s is valid\n", configPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("config", "config.yaml", "Path to configuration file")
+	cmd.Flags().Bool("strict-secrets", false, strictSecretsUsage)
+
+	return cmd
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommand(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api_token: secret
+repositories:
+  - provider: github
+    url: https://github.com/customer-a/api
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	var out bytes.Buffer
+	cmd := NewValidateCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--config", configPath})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, configPath+" is valid\n", out.String())
+}
+
+func TestValidateCommand_Invalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api_tokn: secret
+repositories:
+  - provider: github
+    url: https://github.com/customer-a
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	var out bytes.Buffer
+	cmd := NewValidateCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--config", configPath})
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "3 problem(s) found")
+	assert.Contains(t, out.String(), configPath+": line 1: error: field api_tokn not found in type models.Config")
+	assert.Contains(t, out.String(), configPath+": line 3: warning: repositories[0]: no credentials configured")
+	assert.Contains(t, out.String(), configPath+`: line 4: error: repositories[0]: invalid github URL "https://github.com/customer-a"`)
+}
+
+func TestLoadConfig_UnknownField(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(configPath, []byte("api_token: secret\nrepos: []\n"), 0644))
+
+	_, err := loadConfig(configPath, false)
+	assert.ErrorContains(t, err, "line 2: error: field repos not found in type models.Config")
+	assert.ErrorContains(t, err, "error: no repositories configured")
+}
+
+func TestValidateCommand_StrictSecrets(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api_token: ghp_literal
+repositories:
+  - provider: github
+    url: https://github.com/customer-a/api
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	var out bytes.Buffer
+	cmd := NewValidateCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--config", configPath, "--strict-secrets"})
+	assert.ErrorContains(t, cmd.Execute(), "1 problem(s) found")
+	assert.Contains(t, out.String(), configPath+": line 1: error: api_token contains a literal token")
+}
@@ -0,0 +1,108 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// callsPerPullRequest is the number of requests needed to extract one pull request: its
+// threads, its iterations and the changes of the last iteration, plus the diff of commented
+// files and their contents at both commits, assuming one file
+const callsPerPullRequest = 6
+
+// Check implements the core.Checker interface. It verifies that the credential can read the
+// repository, list its pull requests and fetch an iteration diff. Azure DevOps reports no
+// request budget up front, so it is not checked.
+func (e *Extractor) Check(ctx context.Context, repoURL string) (*models.RepositoryCheck, error) {
+	probe, err := e.probe(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return core.CheckRepository(ctx, repoURL, models.ProviderAzureDevOps, probe), nil
+}
+
+// Plan implements the core.Planner interface. Azure DevOps does not report the number of
+// pull requests, so they are counted by listing them.
+func (e *Extractor) Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error) {
+	probe, err := e.probe(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return core.PlanRepository(ctx, repoURL, models.ProviderAzureDevOps, probe)
+}
+
+// probe returns the core.RepositoryProbe of a repository
+func (e *Extractor) probe(repoURL string) (*repositoryProbe, error) {
+	_, loc, err := parseURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure DevOps URL: %w", err)
+	}
+	return &repositoryProbe{extractor: e, repoURL: repoURL, loc: loc}, nil
+}
+
+// repositoryProbe implements core.RepositoryProbe for an Azure DevOps repository
+type repositoryProbe struct {
+	extractor *Extractor
+	repoURL   string
+	loc       location
+	client    ClientInterface
+}
+
+func (p *repositoryProbe) Connect() (bool, error) {
+	client, authenticated, err := p.extractor.clientFor(p.repoURL, p.loc.collection)
+	p.client = client
+	return authenticated, err
+}
+
+func (p *repositoryProbe) Authenticate(ctx context.Context) (string, error) {
+	data, err := p.client.GetConnectionData(ctx)
+	if err != nil {
+		return "", err
+	}
+	return data.AuthenticatedUser.ProviderDisplayName, nil
+}
+
+func (p *repositoryProbe) Repository(ctx context.Context) (string, error) {
+	repository, err := p.client.GetRepository(ctx, p.loc.project, p.loc.repo)
+	if err != nil {
+		return "", err
+	}
+	visibility := repository.Project.Visibility
+	if visibility == "" {
+		visibility = "private"
+	}
+	return fmt.Sprintf("%s/%s (%s)", repository.Project.Name, repository.Name, visibility), nil
+}
+
+func (p *repositoryProbe) CountPullRequests(ctx context.Context) (int, int, error) {
+	count, latest, err := p.client.CountPullRequests(ctx, p.loc.project, p.loc.repo)
+	if err != nil || latest == nil {
+		return count, 0, err
+	}
+	return count, latest.PullRequestID, nil
+}
+
+// Diff fetches the changes of the last iteration, since Azure DevOps has no diff endpoint
+func (p *repositoryProbe) Diff(ctx context.Context, id int) (string, error) {
+	iterations, err := p.client.GetIterations(ctx, p.loc.project, p.loc.repo, id)
+	if err != nil {
+		return "", err
+	}
+	if len(iterations) == 0 {
+		return "", core.ErrNoDiff
+	}
+	changes, err := p.client.GetIterationChanges(ctx, p.loc.project, p.loc.repo, id, iterations[len(iterations)-1].ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fetched changes of #%d (%d files)", id, len(changes)), nil
+}
+
+// EstimateCalls approximates the requests a full extraction of count pull requests needs
+func (p *repositoryProbe) EstimateCalls(count int) int {
+	listPages := count/pageSize + 1
+	return listPages + count*callsPerPullRequest
+}
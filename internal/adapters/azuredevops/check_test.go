@@ -0,0 +1,46 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	const repo = "/acme/platform/_apis/git/repositories/api"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/_apis/connectionData", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"authenticatedUser": {"providerDisplayName": "Review Bot"}}`)
+	})
+	mux.HandleFunc(repo, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "api", "project": {"name": "platform", "visibility": "private"}}`)
+	})
+	mux.HandleFunc(repo+"/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": [{"pullRequestId": 12}, {"pullRequestId": 11}]}`)
+	})
+	mux.HandleFunc(repo+"/pullRequests/12/iterations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": [{"id": 1}, {"id": 2}]}`)
+	})
+	mux.HandleFunc(repo+"/pullRequests/12/iterations/2/changes", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"changeEntries": [{"item": {"path": "/a.go"}}]}`)
+	})
+	extractor := &Extractor{client: newFakeServer(t, mux, models.Credential{Token: "token"})}
+
+	result, err := extractor.Check(context.Background(), "https://dev.azure.com/acme/platform/_git/api")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryCheck{
+		URL:      "https://dev.azure.com/acme/platform/_git/api",
+		Provider: models.ProviderAzureDevOps,
+		Checks: []models.Check{
+			{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as Review Bot"},
+			{Name: "repository", Status: models.CheckOK, Detail: "platform/api (private)"},
+			{Name: "pull requests", Status: models.CheckOK, Detail: "2 pull requests"},
+			{Name: "diff", Status: models.CheckOK, Detail: "fetched changes of #12 (1 files)"},
+		},
+		EstimatedCalls: 1 + 2*callsPerPullRequest,
+	}, result)
+}
@@ -0,0 +1,242 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+const (
+	// apiVersion is the REST API version requested from Azure DevOps Services and Server
+	apiVersion = "7.1"
+	// pageSize is the number of pull requests or changes requested per page
+	pageSize = 100
+)
+
+// Client calls the Azure DevOps API of one organization or collection
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	credential models.Credential
+}
+
+// NewClient creates a client for the organization or collection at baseURL (e.g.
+// https://dev.azure.com/acme), authenticating with the credential, or unauthenticated if it
+// is empty
+func NewClient(baseURL string, credential models.Credential) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		credential: credential,
+	}
+}
+
+// GetPullRequests fetches pull requests for a repository, newest first
+func (c *Client) GetPullRequests(ctx context.Context, project, repo string) ([]*PullRequest, error) {
+	var allPRs []*PullRequest
+	for skip := 0; ; skip += pageSize {
+		query := url.Values{
+			"searchCriteria.status": {"all"},
+			"$top":                  {strconv.Itoa(pageSize)},
+			"$skip":                 {strconv.Itoa(skip)},
+		}
+		var page struct {
+			Value []*PullRequest `json:"value"`
+		}
+		if err := c.get(ctx, c.repoPath(project, repo, "pullrequests"), query, &page); err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+		allPRs = append(allPRs, page.Value...)
+		if len(page.Value) < pageSize {
+			return allPRs, nil
+		}
+	}
+}
+
+// GetPullRequestThreads fetches the comment threads of a pull request
+func (c *Client) GetPullRequestThreads(ctx context.Context, project, repo string, id int) ([]*Thread, error) {
+	var threads struct {
+		Value []*Thread `json:"value"`
+	}
+	if err := c.get(ctx, c.repoPath(project, repo, "pullRequests", strconv.Itoa(id), "threads"), nil, &threads); err != nil {
+		return nil, fmt.Errorf("failed to list pull request threads: %w", err)
+	}
+
+	return threads.Value, nil
+}
+
+// GetIterations fetches the iterations of a pull request, oldest first
+func (c *Client) GetIterations(ctx context.Context, project, repo string, id int) ([]*Iteration, error) {
+	var iterations struct {
+		Value []*Iteration `json:"value"`
+	}
+	if err := c.get(ctx, c.repoPath(project, repo, "pullRequests", strconv.Itoa(id), "iterations"), nil, &iterations); err != nil {
+		return nil, fmt.Errorf("failed to list pull request iterations: %w", err)
+	}
+
+	return iterations.Value, nil
+}
+
+// GetIterationChanges fetches the files changed by a pull request up to an iteration,
+// compared with the target branch
+func (c *Client) GetIterationChanges(ctx context.Context, project, repo string, id, iteration int) ([]*Change, error) {
+	var allChanges []*Change
+	path := c.repoPath(project, repo, "pullRequests", strconv.Itoa(id), "iterations", strconv.Itoa(iteration), "changes")
+	for skip := 0; ; {
+		query := url.Values{
+			"$compareTo": {"0"},
+			"$top":       {strconv.Itoa(pageSize)},
+			"$skip":      {strconv.Itoa(skip)},
+		}
+		var page struct {
+			ChangeEntries []*Change `json:"changeEntries"`
+			NextSkip      int       `json:"nextSkip"`
+		}
+		if err := c.get(ctx, path, query, &page); err != nil {
+			return nil, fmt.Errorf("failed to list iteration changes: %w", err)
+		}
+		allChanges = append(allChanges, page.ChangeEntries...)
+		if page.NextSkip <= skip {
+			return allChanges, nil
+		}
+		skip = page.NextSkip
+	}
+}
+
+// GetFileDiff fetches the changed line ranges of a file between two commits. originalPath
+// is the path of the file at baseCommit, or empty if it was not renamed.
+func (c *Client) GetFileDiff(ctx context.Context, project, repo, path, originalPath, baseCommit, targetCommit string) (*FileDiff, error) {
+	if originalPath == "" {
+		originalPath = path
+	}
+	body := map[string]any{
+		"baseVersionCommit":   baseCommit,
+		"targetVersionCommit": targetCommit,
+		"fileDiffParams":      []map[string]string{{"path": path, "originalPath": originalPath}},
+	}
+	var diffs []*FileDiff
+	if err := c.post(ctx, "/"+url.PathEscape(project)+"/_apis/git/repositories/"+url.PathEscape(repo)+"/FileDiffs", body, &diffs); err != nil {
+		return nil, fmt.Errorf("failed to get file diff: %w", err)
+	}
+
+	if len(diffs) == 0 {
+		return &FileDiff{Path: path, OriginalPath: originalPath}, nil
+	}
+	return diffs[0], nil
+}
+
+// GetFileContent fetches the content of a file at a commit
+func (c *Client) GetFileContent(ctx context.Context, project, repo, path, commit string) (string, error) {
+	query := url.Values{
+		"path":                          {path},
+		"versionDescriptor.version":     {commit},
+		"versionDescriptor.versionType": {"commit"},
+		"includeContent":                {"true"},
+		"$format":                       {"json"},
+	}
+	var item struct {
+		Content string `json:"content"`
+	}
+	if err := c.get(ctx, c.repoPath(project, repo, "items"), query, &item); err != nil {
+		return "", fmt.Errorf("failed to get file content: %w", err)
+	}
+
+	return item.Content, nil
+}
+
+// CountPullRequests returns the number of pull requests in a repository and the most
+// recently created one. Azure DevOps does not report totals, so every page is listed.
+func (c *Client) CountPullRequests(ctx context.Context, project, repo string) (int, *PullRequest, error) {
+	prs, err := c.GetPullRequests(ctx, project, repo)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(prs) == 0 {
+		return 0, nil, nil
+	}
+	return len(prs), prs[0], nil
+}
+
+// GetConnectionData fetches the account the credential belongs to
+func (c *Client) GetConnectionData(ctx context.Context) (*ConnectionData, error) {
+	var data ConnectionData
+	// connectionData is only available as a preview API
+	query := url.Values{"api-version": {apiVersion + "-preview"}}
+	if err := c.get(ctx, "/_apis/connectionData", query, &data); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	return &data, nil
+}
+
+// GetRepository fetches a repository
+func (c *Client) GetRepository(ctx context.Context, project, repo string) (*Repository, error) {
+	var repository Repository
+	if err := c.get(ctx, c.repoPath(project, repo), nil, &repository); err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	return &repository, nil
+}
+
+// repoPath builds the API path of a repository resource
+func (c *Client) repoPath(project, repo string, elements ...string) string {
+	path := "/" + url.PathEscape(project) + "/_apis/git/repositories/" + url.PathEscape(repo)
+	for _, element := range elements {
+		path += "/" + url.PathEscape(element)
+	}
+	return path
+}
+
+// get fetches an API path and decodes its JSON response into v. The API version is added
+// to the query unless it is set.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	if query.Get("api-version") == "" {
+		query.Set("api-version", apiVersion)
+	}
+	data, _, err := httpclient.Get(ctx, c.httpClient, c.baseURL+path+"?"+query.Encode(), c.prepare, httpclient.JSONMessage)
+	return decode(data, err, v)
+}
+
+// post posts a JSON body to an API path and decodes its JSON response into v
+func (c *Client) post(ctx context.Context, path string, body, v any) error {
+	query := url.Values{"api-version": {apiVersion}}
+	data, _, err := httpclient.Post(ctx, c.httpClient, c.baseURL+path+"?"+query.Encode(), body, c.prepare, httpclient.JSONMessage)
+	return decode(data, err, v)
+}
+
+// decode decodes the JSON response of get or post into v, or explains err
+func decode(data []byte, err error, v any) error {
+	if err != nil {
+		var apiErr *httpclient.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNonAuthoritativeInfo {
+			// Rejected credentials are answered with the sign-in page rather than a 401
+			apiErr.Message = "credentials were rejected (redirected to sign-in)"
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// prepare asks for JSON and adds the credential to a request. Personal access tokens are
+// sent as the password of basic auth with an empty username, logins as regular basic auth.
+func (c *Client) prepare(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	httpclient.Authorize(req, c.credential, "")
+}
@@ -0,0 +1,107 @@
+package azuredevops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeServer starts a local Azure DevOps API stand-in for the acme organization and
+// returns a client talking to it
+func newFakeServer(t *testing.T, mux *http.ServeMux, credential models.Credential) *Client {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/acme", credential)
+}
+
+func TestClient_GetPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/platform/_apis/git/repositories/api/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Empty(t, user, "personal access tokens are sent with an empty username")
+		assert.Equal(t, "secret", password)
+		assert.Equal(t, "7.1", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "all", r.URL.Query().Get("searchCriteria.status"))
+		assert.Equal(t, "100", r.URL.Query().Get("$top"))
+
+		if r.URL.Query().Get("$skip") == "100" {
+			fmt.Fprint(w, `{"count": 1, "value": [{"pullRequestId": 1, "title": "First"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"count": 100, "value": [`)
+		for id := 101; id > 1; id-- {
+			if id < 101 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"pullRequestId": %d}`, id)
+		}
+		fmt.Fprint(w, `]}`)
+	})
+	client := newFakeServer(t, mux, models.Credential{Token: "secret"})
+
+	prs, err := client.GetPullRequests(context.Background(), "platform", "api")
+	assert.NoError(t, err)
+	assert.Len(t, prs, 101)
+	assert.Equal(t, 101, prs[0].PullRequestID)
+	assert.Equal(t, "First", prs[100].Title)
+
+	count, latest, err := client.CountPullRequests(context.Background(), "platform", "api")
+	assert.NoError(t, err)
+	assert.Equal(t, 101, count)
+	assert.Equal(t, 101, latest.PullRequestID)
+}
+
+func TestClient_GetIterationChanges(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/platform/_apis/git/repositories/api/pullRequests/7/iterations/2/changes", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "0", r.URL.Query().Get("$compareTo"))
+		if r.URL.Query().Get("$skip") == "1" {
+			fmt.Fprint(w, `{"changeEntries": [{"changeType": "add", "item": {"path": "/b.go"}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"changeEntries": [{"changeType": "edit", "item": {"path": "/a.go"}}], "nextSkip": 1, "nextTop": 100}`)
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	changes, err := client.GetIterationChanges(context.Background(), "platform", "api", 7, 2)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "/b.go", changes[1].Item.Path)
+}
+
+func TestClient_GetFileContent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/platform/_apis/git/repositories/api/items", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/src/main.go", r.URL.Query().Get("path"))
+		assert.Equal(t, "abc123", r.URL.Query().Get("versionDescriptor.version"))
+		assert.Equal(t, "commit", r.URL.Query().Get("versionDescriptor.versionType"))
+		fmt.Fprint(w, `{"path": "/src/main.go", "content": "package main\n"}`)
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	content, err := client.GetFileContent(context.Background(), "platform", "api", "/src/main.go", "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n", content)
+}
+
+func TestClient_RejectedCredentials(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme/_apis/connectionData", func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "", user)
+		assert.Equal(t, "expired", password)
+		assert.Equal(t, "7.1-preview", r.URL.Query().Get("api-version"))
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+		fmt.Fprint(w, `<html>Sign in</html>`)
+	})
+	client := newFakeServer(t, mux, models.Credential{Token: "expired"})
+
+	_, err := client.GetConnectionData(context.Background())
+	assert.ErrorContains(t, err, "203 credentials were rejected")
+}
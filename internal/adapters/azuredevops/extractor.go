@@ -0,0 +1,509 @@
+package azuredevops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// diffContextRadius is the number of lines around a commented line kept as diff context
+const diffContextRadius = 3
+
+// Extractor implements the core.Extractor interface for Azure DevOps Services and Azure
+// DevOps Server. The organization or collection is derived from each repository URL.
+type Extractor struct {
+	// client, if set, is used for every repository instead of clients built from credentials
+	client  ClientInterface
+	clients *httpclient.Clients[ClientInterface]
+}
+
+// location identifies a repository within Azure DevOps: its organization or collection URL,
+// its project and its name
+type location struct {
+	collection string
+	project    string
+	repo       string
+}
+
+// NewExtractor creates a new Azure DevOps extractor, authenticating with the credentials of
+// each repository
+func NewExtractor(source credentials.Source) *Extractor {
+	return &Extractor{
+		clients: httpclient.NewClients(models.ProviderAzureDevOps, authMethods, source, func(baseURL string, credential models.Credential) ClientInterface {
+			return NewClient(baseURL, credential)
+		}),
+	}
+}
+
+// clientFor returns the client for a repository and whether it is authenticated
+func (e *Extractor) clientFor(repoURL, baseURL string) (ClientInterface, bool, error) {
+	if e.client != nil {
+		return e.client, true, nil
+	}
+	return e.clients.For(repoURL, baseURL)
+}
+
+// ExtractReviews implements the core.Extractor interface
+func (e *Extractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	return core.Collect(e.StreamReviews(ctx, repoURL))
+}
+
+// StreamReviews implements the core.StreamExtractor interface, yielding the reviews of each
+// pull request once they have been fetched
+func (e *Extractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		ref, loc, err := parseURL(repoURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("invalid Azure DevOps URL: %w", err))
+			return
+		}
+		client, _, err := e.clientFor(repoURL, loc.collection)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to authenticate: %w", err))
+			return
+		}
+
+		// Get all pull requests
+		prs, err := client.GetPullRequests(ctx, loc.project, loc.repo)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to get pull requests: %w", err))
+			return
+		}
+
+		// Process each pull request
+		for _, pr := range prs {
+			reviews, err := e.pullRequestReviews(ctx, client, ref, loc, pr)
+			if err != nil {
+				yield(models.Review{}, err)
+				return
+			}
+			for _, review := range reviews {
+				if !yield(review, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pullRequestReviews extracts the comment threads of a pull request
+func (e *Extractor) pullRequestReviews(ctx context.Context, client ClientInterface, ref models.RepositoryRef, loc location, pr *PullRequest) ([]models.Review, error) {
+	threads, err := client.GetPullRequestThreads(ctx, loc.project, loc.repo, pr.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get threads for PR #%d: %w", pr.PullRequestID, err)
+	}
+	iterations, err := client.GetIterations(ctx, loc.project, loc.repo, pr.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iterations for PR #%d: %w", pr.PullRequestID, err)
+	}
+
+	pullRequest := convertPullRequest(pr, ref)
+	diffs := &diffCache{
+		client:   client,
+		loc:      loc,
+		renames:  make(map[string]string),
+		contents: make(map[[2]string][]string),
+		hunks:    make(map[[3]string][]diff.Hunk),
+	}
+	if len(iterations) > 0 {
+		last := iterations[len(iterations)-1]
+		changes, err := client.GetIterationChanges(ctx, loc.project, loc.repo, pr.PullRequestID, last.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changes of iteration %d on PR #%d: %w", last.ID, pr.PullRequestID, err)
+		}
+		pullRequest.ChangedFiles = len(changes)
+		for _, change := range changes {
+			if change.Item != nil && change.OriginalPath != "" {
+				diffs.renames[change.Item.Path] = change.OriginalPath
+			}
+		}
+	}
+
+	base := models.Review{
+		PRID:        pr.PullRequestID,
+		PRTitle:     pr.Title,
+		PRAuthor:    author(pr.CreatedBy),
+		Repository:  ref.Name,
+		Repo:        ref,
+		Provider:    models.ProviderAzureDevOps,
+		PullRequest: pullRequest,
+	}
+
+	var reviews []models.Review
+	states := reviewStates(pr.Reviewers)
+	for _, thread := range threads {
+		if thread.IsDeleted {
+			continue
+		}
+		threadReviews, err := convertThread(ctx, base, thread, iterations, states, diffs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get diff context of thread %d on PR #%d: %w", thread.ID, pr.PullRequestID, err)
+		}
+		reviews = append(reviews, threadReviews...)
+	}
+	return reviews, nil
+}
+
+// convertThread maps the comments of a thread to the shared model. Each comment has the
+// review state of its author's vote. System comments, such as vote and push notifications,
+// and deleted comments are skipped.
+func convertThread(ctx context.Context, base models.Review, thread *Thread, iterations []*Iteration, states map[string]models.ReviewState, diffs *diffCache) ([]models.Review, error) {
+	base.ReviewID = fmt.Sprintf("%d", thread.ID)
+	base.ThreadStatus = threadStatus(thread.Status)
+
+	if tc := thread.ThreadContext; tc != nil && tc.FilePath != "" {
+		start, end, left := tc.RightFileStart, tc.RightFileEnd, false
+		if start == nil {
+			start, end, left = tc.LeftFileStart, tc.LeftFileEnd, true
+		}
+
+		base.FilePath = strings.TrimPrefix(tc.FilePath, "/")
+		if start != nil {
+			base.LineNumber = start.Line
+			if end != nil && end.Line > start.Line {
+				base.StartLine, base.LineNumber = start.Line, end.Line
+			}
+
+			if from, to := compareCommits(thread, iterations); from != "" && to != "" {
+				hunks, err := diffs.get(ctx, tc.FilePath, from, to)
+				if err != nil {
+					return nil, err
+				}
+				base.DiffContext = diff.Context(hunks, base.LineNumber, left, diffContextRadius)
+			}
+		}
+	}
+
+	var reviews []models.Review
+	for _, comment := range thread.Comments {
+		if comment.IsDeleted || comment.CommentType == CommentTypeSystem {
+			continue
+		}
+
+		review := base
+		review.CommentID = fmt.Sprintf("%d/%d", thread.ID, comment.ID)
+		if comment.ParentCommentID > 0 {
+			review.InReplyTo = fmt.Sprintf("%d/%d", thread.ID, comment.ParentCommentID)
+		}
+		review.CommentAuthor = author(comment.Author)
+		review.CommentText = comment.Content
+		review.CommentCreated = comment.PublishedDate
+		review.ReviewState = models.ReviewStateCommented
+		if state, ok := states[review.CommentAuthor]; ok {
+			review.ReviewState = state
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// compareCommits returns the commits of the diff a thread was made on: the source of the
+// earlier compared iteration, or the merge base when the thread compares against the target
+// branch, and the source of the later compared iteration
+func compareCommits(thread *Thread, iterations []*Iteration) (string, string) {
+	if len(iterations) == 0 {
+		return "", ""
+	}
+
+	first, second := 0, iterations[len(iterations)-1].ID
+	if tc := thread.PullRequestThreadContext; tc != nil && tc.IterationContext != nil && tc.IterationContext.SecondComparingIteration > 0 {
+		first, second = tc.IterationContext.FirstComparingIteration, tc.IterationContext.SecondComparingIteration
+	}
+
+	iteration := findIteration(iterations, second)
+	if iteration == nil {
+		return "", ""
+	}
+	to := commitID(iteration.SourceRefCommit)
+	switch {
+	case first > 0:
+		if earlier := findIteration(iterations, first); earlier != nil {
+			return commitID(earlier.SourceRefCommit), to
+		}
+		return "", to
+	case iteration.CommonRefCommit != nil:
+		return commitID(iteration.CommonRefCommit), to
+	default:
+		return commitID(iteration.TargetRefCommit), to
+	}
+}
+
+// findIteration returns the iteration with the given ID, or nil
+func findIteration(iterations []*Iteration, id int) *Iteration {
+	for _, iteration := range iterations {
+		if iteration.ID == id {
+			return iteration
+		}
+	}
+	return nil
+}
+
+// diffCache fetches the diff of each file once per pair of compared commits, as threads on
+// the same file share it
+type diffCache struct {
+	client ClientInterface
+	loc    location
+	// renames holds the original paths of the files the pull request renamed
+	renames  map[string]string
+	contents map[[2]string][]string
+	hunks    map[[3]string][]diff.Hunk
+}
+
+// get returns the diff of a file between two commits. Azure DevOps only reports the ranges
+// of changed lines, so the hunk is rebuilt from the contents of the file at both commits.
+func (d *diffCache) get(ctx context.Context, path, from, to string) ([]diff.Hunk, error) {
+	key := [3]string{from, to, path}
+	if hunks, ok := d.hunks[key]; ok {
+		return hunks, nil
+	}
+
+	originalPath := path
+	if renamed, ok := d.renames[path]; ok {
+		originalPath = renamed
+	}
+	fileDiff, err := d.client.GetFileDiff(ctx, d.loc.project, d.loc.repo, path, originalPath, from, to)
+	if err != nil {
+		return nil, err
+	}
+	oldLines, err := d.lines(ctx, originalPath, from)
+	if err != nil {
+		return nil, err
+	}
+	newLines, err := d.lines(ctx, path, to)
+	if err != nil {
+		return nil, err
+	}
+
+	hunks := []diff.Hunk{fileHunk(fileDiff.LineDiffBlocks, oldLines, newLines)}
+	d.hunks[key] = hunks
+	return hunks, nil
+}
+
+// lines returns the lines of a file at a commit, or none if it does not exist
+func (d *diffCache) lines(ctx context.Context, path, commit string) ([]string, error) {
+	key := [2]string{commit, path}
+	if lines, ok := d.contents[key]; ok {
+		return lines, nil
+	}
+
+	content, err := d.client.GetFileContent(ctx, d.loc.project, d.loc.repo, path, commit)
+	var apiErr *httpclient.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		content, err = "", nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	d.contents[key] = lines
+	return lines, nil
+}
+
+// fileHunk rebuilds the diff of a file as one hunk spanning the whole file, from the changed
+// line blocks of its diff and its lines at both commits
+func fileHunk(blocks []*LineDiffBlock, oldLines, newLines []string) diff.Hunk {
+	hunk := diff.Hunk{OldStart: 1, OldLines: len(oldLines), NewStart: 1, NewLines: len(newLines)}
+	oldNumber, newNumber := 1, 1
+	// unchanged adds the lines both sides share up to the given lines
+	unchanged := func(oldEnd, newEnd int) {
+		for oldNumber < oldEnd && newNumber < newEnd && oldNumber <= len(oldLines) && newNumber <= len(newLines) {
+			hunk.Lines = append(hunk.Lines, diff.Line{Kind: diff.LineContext, Content: newLines[newNumber-1], OldNumber: oldNumber, NewNumber: newNumber})
+			oldNumber++
+			newNumber++
+		}
+	}
+
+	for _, block := range blocks {
+		if block.ChangeType == LineChangeNone {
+			continue
+		}
+		// A block without lines on one side does not say where it is on that side
+		oldEnd, newEnd := block.OriginalLineNumberStart, block.ModifiedLineNumberStart
+		if block.OriginalLinesCount == 0 {
+			oldEnd = len(oldLines) + 1
+		}
+		if block.ModifiedLinesCount == 0 {
+			newEnd = len(newLines) + 1
+		}
+		unchanged(oldEnd, newEnd)
+
+		for range block.OriginalLinesCount {
+			if oldNumber > len(oldLines) {
+				break
+			}
+			hunk.Lines = append(hunk.Lines, diff.Line{Kind: diff.LineRemoved, Content: oldLines[oldNumber-1], OldNumber: oldNumber})
+			oldNumber++
+		}
+		for range block.ModifiedLinesCount {
+			if newNumber > len(newLines) {
+				break
+			}
+			hunk.Lines = append(hunk.Lines, diff.Line{Kind: diff.LineAdded, Content: newLines[newNumber-1], NewNumber: newNumber})
+			newNumber++
+		}
+	}
+	unchanged(len(oldLines)+1, len(newLines)+1)
+	return hunk
+}
+
+// reviewStates maps the reviewers of a pull request to the review state of their vote
+func reviewStates(reviewers []Reviewer) map[string]models.ReviewState {
+	states := make(map[string]models.ReviewState, len(reviewers))
+	for _, reviewer := range reviewers {
+		states[author(&reviewer.Identity)] = voteState(reviewer.Vote)
+	}
+	return states
+}
+
+// voteState maps a reviewer vote to a review state. Approvals with suggestions approve;
+// waiting for the author and rejections request changes.
+func voteState(vote int) models.ReviewState {
+	switch {
+	case vote >= VoteApprovedWithSuggestions:
+		return models.ReviewStateApproved
+	case vote <= VoteWaitingForAuthor:
+		return models.ReviewStateChangesRequested
+	default:
+		return models.ReviewStateCommented
+	}
+}
+
+// threadStatus maps an Azure DevOps thread status to the shared model, or returns an empty
+// status for unknown ones
+func threadStatus(status string) models.ThreadStatus {
+	switch status {
+	case ThreadStatusActive:
+		return models.ThreadStatusActive
+	case ThreadStatusPending:
+		return models.ThreadStatusPending
+	case ThreadStatusFixed:
+		return models.ThreadStatusFixed
+	case ThreadStatusWontFix:
+		return models.ThreadStatusWontFix
+	case ThreadStatusByDesign:
+		return models.ThreadStatusByDesign
+	case ThreadStatusClosed:
+		return models.ThreadStatusClosed
+	default:
+		return ""
+	}
+}
+
+// convertPullRequest maps an Azure DevOps pull request to the shared model. Completed pull
+// requests are merged; abandoned ones are closed without merging.
+func convertPullRequest(pr *PullRequest, repo models.RepositoryRef) *models.PullRequest {
+	pullRequest := &models.PullRequest{
+		Number:     pr.PullRequestID,
+		Repository: repo,
+		Provider:   models.ProviderAzureDevOps,
+		Title:      pr.Title,
+		Author:     author(pr.CreatedBy),
+		URL:        fmt.Sprintf("%s/pullrequest/%d", repo.URL, pr.PullRequestID),
+		State:      "open",
+		Draft:      pr.IsDraft,
+		CreatedAt:  pr.CreationDate,
+		ClosedAt:   pr.ClosedDate,
+		BaseBranch: strings.TrimPrefix(pr.TargetRefName, "refs/heads/"),
+		HeadBranch: strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+		HeadSHA:    commitID(pr.LastMergeSourceCommit),
+	}
+	switch pr.Status {
+	case PullRequestStatusCompleted:
+		pullRequest.State = "closed"
+		pullRequest.Merged = true
+		pullRequest.MergedAt = pr.ClosedDate
+	case PullRequestStatusAbandoned:
+		pullRequest.State = "closed"
+	}
+	for _, label := range pr.Labels {
+		pullRequest.Labels = append(pullRequest.Labels, label.Name)
+	}
+	return pullRequest
+}
+
+// author returns the sign-in name of an identity, its display name if it has none, or an
+// empty string for missing identities
+func author(identity *Identity) string {
+	switch {
+	case identity == nil:
+		return ""
+	case identity.UniqueName != "":
+		return identity.UniqueName
+	default:
+		return identity.DisplayName
+	}
+}
+
+// commitID returns the ID of a commit reference, or an empty string
+func commitID(commit *Commit) string {
+	if commit == nil {
+		return ""
+	}
+	return commit.CommitID
+}
+
+// parseURL parses a repository URL of the form <collection>/<project>/_git/<repo> into its
+// identity and location. The collection is https://dev.azure.com/<org> on Azure DevOps
+// Services, https://<org>.visualstudio.com on legacy URLs and https://<host>/tfs/<collection>
+// on Azure DevOps Server.
+func parseURL(repoURL string) (models.RepositoryRef, location, error) {
+	invalid := fmt.Errorf("invalid URL format: expected https://dev.azure.com/<org>/<project>/_git/<repo>")
+
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(repoURL), "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return models.RepositoryRef{}, location{}, invalid
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	index := -1
+	for i, segment := range segments {
+		if segment == "_git" {
+			index = i
+			break
+		}
+	}
+	// The project and repository are required, and a collection unless the host is the
+	// organization itself
+	legacy := strings.HasSuffix(strings.ToLower(u.Host), ".visualstudio.com")
+	if index < 1 || index != len(segments)-2 || segments[index+1] == "" || (index < 2 && !legacy) {
+		return models.RepositoryRef{}, location{}, invalid
+	}
+
+	collection := u.Scheme + "://" + u.Host
+	if prefix := strings.Join(segments[:index-1], "/"); prefix != "" {
+		collection += "/" + prefix
+	}
+	loc := location{
+		collection: collection,
+		project:    segments[index-1],
+		repo:       strings.TrimSuffix(segments[index+1], ".git"),
+	}
+
+	ref := models.RepositoryRef{
+		Host:  strings.ToLower(u.Host),
+		Owner: strings.Join(segments[:index], "/"),
+		Name:  loc.repo,
+		URL:   collection + "/" + loc.project + "/_git/" + loc.repo,
+	}
+	return ref, loc, nil
+}
+
+// ValidateURL checks that url identifies an Azure DevOps Git repository
+func ValidateURL(url string) error {
+	_, _, err := parseURL(url)
+	return err
+}
@@ -0,0 +1,226 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractReviews(t *testing.T) {
+	const repo = "/acme/platform/_apis/git/repositories/api"
+	mux := http.NewServeMux()
+	mux.HandleFunc(repo+"/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": [{
+			"pullRequestId": 7, "title": "Add retries", "status": "completed",
+			"createdBy": {"displayName": "Alice", "uniqueName": "alice@example.com"},
+			"creationDate": "2024-05-01T10:00:00Z", "closedDate": "2024-05-02T10:00:00Z",
+			"sourceRefName": "refs/heads/retries", "targetRefName": "refs/heads/main",
+			"lastMergeSourceCommit": {"commitId": "def456"}, "labels": [{"name": "enhancement"}],
+			"reviewers": [
+				{"displayName": "Bob", "uniqueName": "bob@example.com", "vote": -5},
+				{"displayName": "Carol", "uniqueName": "carol@example.com", "vote": 10}
+			]
+		}]}`)
+	})
+	mux.HandleFunc(repo+"/pullRequests/7/threads", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": [
+			{"id": 1, "status": "fixed",
+			 "threadContext": {"filePath": "/client.go", "rightFileStart": {"line": 4, "offset": 1}, "rightFileEnd": {"line": 5, "offset": 10}},
+			 "pullRequestThreadContext": {"iterationContext": {"firstComparingIteration": 0, "secondComparingIteration": 1}},
+			 "comments": [
+				{"id": 1, "author": {"displayName": "Bob", "uniqueName": "bob@example.com"}, "content": "Retry forever?", "commentType": "text", "publishedDate": "2024-05-01T11:00:00Z"},
+				{"id": 2, "parentCommentId": 1, "author": {"displayName": "Alice", "uniqueName": "alice@example.com"}, "content": "Bounded now", "commentType": "text", "publishedDate": "2024-05-01T12:00:00Z"},
+				{"id": 3, "author": {"displayName": "Bob"}, "content": "", "commentType": "text", "isDeleted": true}]},
+			{"id": 2, "status": "wontFix",
+			 "threadContext": {"filePath": "/client.go", "leftFileStart": {"line": 4, "offset": 1}, "leftFileEnd": {"line": 4, "offset": 5}},
+			 "comments": [{"id": 1, "author": {"displayName": "Carol"}, "content": "Why remove this?", "commentType": "text", "publishedDate": "2024-05-01T11:30:00Z"}]},
+			{"id": 3, "comments": [{"id": 1, "author": {"displayName": "Microsoft.VisualStudio.Services.TFS"}, "content": "Bob voted 10", "commentType": "system"}]},
+			{"id": 4, "status": "active",
+			 "comments": [{"id": 1, "author": {"uniqueName": "carol@example.com"}, "content": "Nice work overall", "commentType": "text", "publishedDate": "2024-05-01T13:00:00Z"}]},
+			{"id": 5, "isDeleted": true, "comments": [{"id": 1, "content": "Gone", "commentType": "text"}]}
+		]}`)
+	})
+	mux.HandleFunc(repo+"/pullRequests/7/iterations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": [
+			{"id": 1, "sourceRefCommit": {"commitId": "abc123"}, "targetRefCommit": {"commitId": "main2"}, "commonRefCommit": {"commitId": "base1"}},
+			{"id": 2, "sourceRefCommit": {"commitId": "def456"}, "targetRefCommit": {"commitId": "main2"}, "commonRefCommit": {"commitId": "base1"}}
+		]}`)
+	})
+	mux.HandleFunc(repo+"/pullRequests/7/iterations/2/changes", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"changeEntries": [{"changeType": "edit", "item": {"path": "/client.go"}}, {"changeType": "add", "item": {"path": "/retry.go"}}]}`)
+	})
+	diffs := map[string]int{}
+	mux.HandleFunc("POST "+repo+"/FileDiffs", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			BaseVersionCommit   string `json:"baseVersionCommit"`
+			TargetVersionCommit string `json:"targetVersionCommit"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		diffs[body.BaseVersionCommit+".."+body.TargetVersionCommit]++
+		fmt.Fprint(w, `[{"path": "/client.go", "originalPath": "/client.go", "lineDiffBlocks": [
+			{"changeType": "none", "originalLineNumberStart": 1, "originalLinesCount": 3, "modifiedLineNumberStart": 1, "modifiedLinesCount": 3},
+			{"changeType": "edit", "originalLineNumberStart": 4, "originalLinesCount": 1, "modifiedLineNumberStart": 4, "modifiedLinesCount": 1},
+			{"changeType": "none", "originalLineNumberStart": 5, "originalLinesCount": 3, "modifiedLineNumberStart": 5, "modifiedLinesCount": 3}
+		]}]`)
+	})
+	fetched := map[string]int{}
+	mux.HandleFunc(repo+"/items", func(w http.ResponseWriter, r *http.Request) {
+		commit := r.URL.Query().Get("versionDescriptor.version")
+		fetched[commit]++
+		switch commit {
+		case "abc123", "def456":
+			fmt.Fprint(w, `{"content": "package client\n\nfunc call() {\n\tfor i := 0; i < 3; i++ {\n\t\treturn do()\n\t}\n}\n"}`)
+		case "base1":
+			fmt.Fprint(w, `{"content": "package client\n\nfunc call() {\n\tfor {\n\t\treturn do()\n\t}\n}\n"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	extractor := &Extractor{client: newFakeServer(t, mux, models.Credential{})}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://dev.azure.com/acme/platform/_git/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 4)
+
+	first := reviews[0]
+	assert.Equal(t, "1/1", first.CommentID)
+	assert.Equal(t, "bob@example.com", first.CommentAuthor)
+	assert.Equal(t, "client.go", first.FilePath)
+	assert.Equal(t, 4, first.StartLine)
+	assert.Equal(t, 5, first.LineNumber)
+	assert.Equal(t, "\nfunc call() {\nfor i := 0; i < 3; i++ {\nreturn do()\n}\n}", first.DiffContext)
+	assert.Equal(t, "1", first.ReviewID)
+	assert.Equal(t, models.ReviewStateChangesRequested, first.ReviewState, "bob is waiting for the author")
+	assert.Equal(t, models.ThreadStatusFixed, first.ThreadStatus)
+	assert.Equal(t, models.ProviderAzureDevOps, first.Provider)
+	assert.Equal(t, models.RepositoryRef{
+		Host:  "dev.azure.com",
+		Owner: "acme/platform",
+		Name:  "api",
+		URL:   "https://dev.azure.com/acme/platform/_git/api",
+	}, first.Repo)
+
+	assert.Empty(t, first.InReplyTo)
+	assert.Equal(t, "1/2", reviews[1].CommentID, "replies share the thread's anchor")
+	assert.Equal(t, "1/1", reviews[1].InReplyTo)
+	assert.Equal(t, 5, reviews[1].LineNumber)
+	assert.Equal(t, models.ReviewStateCommented, reviews[1].ReviewState, "the author did not vote")
+
+	left := reviews[2]
+	assert.Equal(t, "Carol", left.CommentAuthor)
+	assert.Equal(t, 4, left.LineNumber)
+	assert.Zero(t, left.StartLine)
+	assert.Equal(t, "package client\n\nfunc call() {\nfor {\nreturn do()\n}\n}", left.DiffContext, "left side comments use the merge base")
+	assert.Equal(t, models.ThreadStatusWontFix, left.ThreadStatus)
+
+	general := reviews[3]
+	assert.Equal(t, "Nice work overall", general.CommentText)
+	assert.Empty(t, general.FilePath)
+	assert.Empty(t, general.DiffContext)
+	assert.Equal(t, models.ThreadStatusActive, general.ThreadStatus)
+	assert.Equal(t, models.ReviewStateApproved, general.ReviewState)
+
+	assert.Equal(t, map[string]int{"base1..abc123": 1, "base1..def456": 1}, diffs, "diffs are fetched once per compared commits")
+	assert.Equal(t, map[string]int{"abc123": 1, "base1": 1, "def456": 1}, fetched, "file contents are fetched once per commit")
+
+	pr := first.PullRequest
+	assert.Equal(t, "closed", pr.State)
+	assert.True(t, pr.Merged)
+	assert.Equal(t, "alice@example.com", pr.Author)
+	assert.Equal(t, "https://dev.azure.com/acme/platform/_git/api/pullrequest/7", pr.URL)
+	assert.Equal(t, "main", pr.BaseBranch)
+	assert.Equal(t, "retries", pr.HeadBranch)
+	assert.Equal(t, "def456", pr.HeadSHA)
+	assert.Equal(t, []string{"enhancement"}, pr.Labels)
+	assert.Equal(t, 2, pr.ChangedFiles)
+	assert.Equal(t, pr.ClosedAt, pr.MergedAt)
+}
+
+func TestFileHunk(t *testing.T) {
+	oldLines := []string{"one", "two", "three", "four"}
+	newLines := []string{"one", "2", "three", "3.5", "four"}
+	hunk := fileHunk([]*LineDiffBlock{
+		{ChangeType: LineChangeEdit, OriginalLineNumberStart: 2, OriginalLinesCount: 1, ModifiedLineNumberStart: 2, ModifiedLinesCount: 1},
+		{ChangeType: LineChangeAdd, OriginalLineNumberStart: 3, ModifiedLineNumberStart: 4, ModifiedLinesCount: 1},
+	}, oldLines, newLines)
+	hunks := []diff.Hunk{hunk}
+
+	assert.Equal(t, "one\n2\nthree\n3.5\nfour", diff.Context(hunks, 3, false, 2))
+	assert.Equal(t, "one\ntwo\nthree\nfour", diff.Context(hunks, 2, true, 2))
+	assert.Equal(t, "three\n3.5\nfour", diff.Context(hunks, 4, false, 1), "added lines are placed by their new line number")
+
+	added := []diff.Hunk{fileHunk([]*LineDiffBlock{
+		{ChangeType: LineChangeAdd, OriginalLineNumberStart: 1, ModifiedLineNumberStart: 1, ModifiedLinesCount: 5},
+	}, nil, newLines)}
+	assert.Equal(t, "one\n2", diff.Context(added, 1, false, 1))
+	assert.Equal(t, "", diff.Context(added, 1, true, 1), "added files have no old side")
+}
+
+func TestVoteState(t *testing.T) {
+	assert.Equal(t, models.ReviewStateApproved, voteState(VoteApproved))
+	assert.Equal(t, models.ReviewStateApproved, voteState(VoteApprovedWithSuggestions))
+	assert.Equal(t, models.ReviewStateCommented, voteState(VoteNone))
+	assert.Equal(t, models.ReviewStateChangesRequested, voteState(VoteWaitingForAuthor))
+	assert.Equal(t, models.ReviewStateChangesRequested, voteState(VoteRejected))
+}
+
+func TestThreadStatus(t *testing.T) {
+	assert.Equal(t, models.ThreadStatusByDesign, threadStatus("byDesign"))
+	assert.Equal(t, models.ThreadStatusClosed, threadStatus("closed"))
+	assert.Equal(t, models.ThreadStatusPending, threadStatus("pending"))
+	assert.Equal(t, models.ThreadStatus(""), threadStatus("unknown"))
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		ref        models.RepositoryRef
+		collection string
+		project    string
+		wantErr    bool
+	}{
+		{
+			url:        "https://dev.azure.com/acme/platform/_git/api",
+			ref:        models.RepositoryRef{Host: "dev.azure.com", Owner: "acme/platform", Name: "api", URL: "https://dev.azure.com/acme/platform/_git/api"},
+			collection: "https://dev.azure.com/acme",
+			project:    "platform",
+		},
+		{
+			url:        "https://acme.visualstudio.com/platform/_git/api.git/",
+			ref:        models.RepositoryRef{Host: "acme.visualstudio.com", Owner: "platform", Name: "api", URL: "https://acme.visualstudio.com/platform/_git/api"},
+			collection: "https://acme.visualstudio.com",
+			project:    "platform",
+		},
+		{
+			url:        "https://tfs.example.com/tfs/DefaultCollection/platform/_git/api",
+			ref:        models.RepositoryRef{Host: "tfs.example.com", Owner: "tfs/DefaultCollection/platform", Name: "api", URL: "https://tfs.example.com/tfs/DefaultCollection/platform/_git/api"},
+			collection: "https://tfs.example.com/tfs/DefaultCollection",
+			project:    "platform",
+		},
+		{url: "https://dev.azure.com/acme/_git/api", wantErr: true},
+		{url: "https://dev.azure.com/acme/platform/api", wantErr: true},
+		{url: "https://dev.azure.com/acme/platform/_git/api/pullrequest/7", wantErr: true},
+		{url: "dev.azure.com/acme/platform/_git/api", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ref, loc, err := parseURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, ValidateURL(tt.url))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ref, ref)
+			assert.Equal(t, tt.collection, loc.collection)
+			assert.Equal(t, tt.project, loc.project)
+			assert.Equal(t, "api", loc.repo)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package azuredevops
+
+import "context"
+
+// ClientInterface defines the interface for Azure DevOps API operations
+type ClientInterface interface {
+	GetPullRequests(ctx context.Context, project, repo string) ([]*PullRequest, error)
+	GetPullRequestThreads(ctx context.Context, project, repo string, id int) ([]*Thread, error)
+	GetIterations(ctx context.Context, project, repo string, id int) ([]*Iteration, error)
+	GetIterationChanges(ctx context.Context, project, repo string, id, iteration int) ([]*Change, error)
+	GetFileDiff(ctx context.Context, project, repo, path, originalPath, baseCommit, targetCommit string) (*FileDiff, error)
+	GetFileContent(ctx context.Context, project, repo, path, commit string) (string, error)
+	CountPullRequests(ctx context.Context, project, repo string) (int, *PullRequest, error)
+	GetConnectionData(ctx context.Context) (*ConnectionData, error)
+	GetRepository(ctx context.Context, project, repo string) (*Repository, error)
+}
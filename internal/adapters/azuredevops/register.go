@@ -0,0 +1,27 @@
+package azuredevops
+
+import (
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// authMethods lists the credential methods Azure DevOps accepts
+var authMethods = []models.AuthMethod{models.AuthBearer, models.AuthBasic, models.AuthAppPassword}
+
+func init() {
+	core.Register(core.ProviderFactory{
+		Name:        models.ProviderAzureDevOps,
+		Description: "Azure DevOps Services and Server",
+		Example:     "https://dev.azure.com/<org>/<project>/_git/<repo>",
+		AuthMethods: authMethods,
+		// The _git path segment is specific to Azure DevOps, also on self-hosted servers
+		MatchURL: func(url string) bool {
+			return ValidateURL(url) == nil
+		},
+		ValidateURL: ValidateURL,
+		New: func(config *models.Config, source credentials.Source) core.Extractor {
+			return NewExtractor(source)
+		},
+	})
+}
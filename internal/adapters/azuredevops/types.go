@@ -0,0 +1,188 @@
+package azuredevops
+
+import "time"
+
+// Identity is an Azure DevOps user. UniqueName is the sign-in name, usually an email address.
+type Identity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+}
+
+// Label is a pull request tag
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Commit references a commit by its ID
+type Commit struct {
+	CommitID string `json:"commitId"`
+}
+
+// Pull request statuses reported by Azure DevOps
+const (
+	PullRequestStatusActive    = "active"
+	PullRequestStatusCompleted = "completed"
+	PullRequestStatusAbandoned = "abandoned"
+)
+
+// PullRequest is a pull request as returned by the pullrequests endpoint
+type PullRequest struct {
+	PullRequestID         int        `json:"pullRequestId"`
+	Title                 string     `json:"title"`
+	Status                string     `json:"status"`
+	CreatedBy             *Identity  `json:"createdBy"`
+	CreationDate          time.Time  `json:"creationDate"`
+	ClosedDate            *time.Time `json:"closedDate"`
+	SourceRefName         string     `json:"sourceRefName"`
+	TargetRefName         string     `json:"targetRefName"`
+	IsDraft               bool       `json:"isDraft"`
+	LastMergeSourceCommit *Commit    `json:"lastMergeSourceCommit"`
+	Labels                []Label    `json:"labels"`
+	Reviewers             []Reviewer `json:"reviewers"`
+}
+
+// Reviewer votes reported by Azure DevOps
+const (
+	VoteApproved                = 10
+	VoteApprovedWithSuggestions = 5
+	VoteNone                    = 0
+	VoteWaitingForAuthor        = -5
+	VoteRejected                = -10
+)
+
+// Reviewer is a reviewer of a pull request and their current vote
+type Reviewer struct {
+	Identity
+	Vote int `json:"vote"`
+}
+
+// Thread statuses reported by Azure DevOps
+const (
+	ThreadStatusActive   = "active"
+	ThreadStatusPending  = "pending"
+	ThreadStatusFixed    = "fixed"
+	ThreadStatusWontFix  = "wontFix"
+	ThreadStatusByDesign = "byDesign"
+	ThreadStatusClosed   = "closed"
+)
+
+// Position is a line and character offset in a file, both starting at 1
+type Position struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+}
+
+// ThreadContext anchors a thread to a file. The right side is the file in the pull request's
+// source branch, the left side the file it is compared with; only one side is usually set.
+type ThreadContext struct {
+	FilePath       string    `json:"filePath"`
+	RightFileStart *Position `json:"rightFileStart"`
+	RightFileEnd   *Position `json:"rightFileEnd"`
+	LeftFileStart  *Position `json:"leftFileStart"`
+	LeftFileEnd    *Position `json:"leftFileEnd"`
+}
+
+// IterationContext identifies the iterations compared when a thread was created. A first
+// iteration of 0 means the comparison was against the target branch.
+type IterationContext struct {
+	FirstComparingIteration  int `json:"firstComparingIteration"`
+	SecondComparingIteration int `json:"secondComparingIteration"`
+}
+
+// PullRequestThreadContext holds the pull request specific anchoring of a thread
+type PullRequestThreadContext struct {
+	IterationContext *IterationContext `json:"iterationContext"`
+}
+
+// Comment types reported by Azure DevOps
+const (
+	CommentTypeText   = "text"
+	CommentTypeSystem = "system"
+)
+
+// Comment is a comment of a thread
+type Comment struct {
+	ID              int       `json:"id"`
+	ParentCommentID int       `json:"parentCommentId"`
+	Author          *Identity `json:"author"`
+	Content         string    `json:"content"`
+	CommentType     string    `json:"commentType"`
+	IsDeleted       bool      `json:"isDeleted"`
+	PublishedDate   time.Time `json:"publishedDate"`
+}
+
+// Thread is a comment thread of a pull request. Threads without a ThreadContext are
+// comments on the pull request as a whole.
+type Thread struct {
+	ID                       int                       `json:"id"`
+	Status                   string                    `json:"status"`
+	IsDeleted                bool                      `json:"isDeleted"`
+	ThreadContext            *ThreadContext            `json:"threadContext"`
+	PullRequestThreadContext *PullRequestThreadContext `json:"pullRequestThreadContext"`
+	Comments                 []*Comment                `json:"comments"`
+}
+
+// Iteration is a push to a pull request's source branch. CommonRefCommit is the merge base
+// of the source and target branches at that point.
+type Iteration struct {
+	ID              int     `json:"id"`
+	SourceRefCommit *Commit `json:"sourceRefCommit"`
+	TargetRefCommit *Commit `json:"targetRefCommit"`
+	CommonRefCommit *Commit `json:"commonRefCommit"`
+}
+
+// Item is a file or folder of a change
+type Item struct {
+	Path string `json:"path"`
+}
+
+// Change is a file changed by a pull request iteration. OriginalPath is set for renames.
+type Change struct {
+	ChangeType   string `json:"changeType"`
+	Item         *Item  `json:"item"`
+	OriginalPath string `json:"originalPath"`
+}
+
+// Line diff block change types reported by Azure DevOps
+const (
+	LineChangeNone   = "none"
+	LineChangeAdd    = "add"
+	LineChangeDelete = "delete"
+	LineChangeEdit   = "edit"
+)
+
+// LineDiffBlock is a block of lines of a file diff. Line numbers start at 1.
+type LineDiffBlock struct {
+	ChangeType              string `json:"changeType"`
+	OriginalLineNumberStart int    `json:"originalLineNumberStart"`
+	OriginalLinesCount      int    `json:"originalLinesCount"`
+	ModifiedLineNumberStart int    `json:"modifiedLineNumberStart"`
+	ModifiedLinesCount      int    `json:"modifiedLinesCount"`
+}
+
+// FileDiff is the diff of a file between two commits, as line ranges without content
+type FileDiff struct {
+	Path           string           `json:"path"`
+	OriginalPath   string           `json:"originalPath"`
+	LineDiffBlocks []*LineDiffBlock `json:"lineDiffBlocks"`
+}
+
+// ConnectionData describes the account a request is authenticated as
+type ConnectionData struct {
+	AuthenticatedUser struct {
+		ProviderDisplayName string `json:"providerDisplayName"`
+	} `json:"authenticatedUser"`
+}
+
+// Project is an Azure DevOps project
+type Project struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+// Repository is a Git repository of a project
+type Repository struct {
+	Name    string  `json:"name"`
+	Project Project `json:"project"`
+	WebURL  string  `json:"webUrl"`
+}
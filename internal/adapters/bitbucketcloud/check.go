@@ -0,0 +1,99 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// callsPerPullRequest is the number of requests needed to extract one pull request: its
+// comments, its diffstat and its diff
+const callsPerPullRequest = 3
+
+// Check implements the core.Checker interface. It verifies that the credential can read the
+// repository, list its pull requests and fetch a diff. Bitbucket Cloud reports no request
+// budget up front, so it is not checked.
+func (e *Extractor) Check(ctx context.Context, repoURL string) (*models.RepositoryCheck, error) {
+	probe, err := e.probe(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return core.CheckRepository(ctx, repoURL, models.ProviderBitbucketCloud, probe), nil
+}
+
+// Plan implements the core.Planner interface. It counts the pull requests of a repository
+// with a single list call and estimates the requests a full extraction needs.
+func (e *Extractor) Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error) {
+	probe, err := e.probe(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return core.PlanRepository(ctx, repoURL, models.ProviderBitbucketCloud, probe)
+}
+
+// probe returns the core.RepositoryProbe of a repository
+func (e *Extractor) probe(repoURL string) (*repositoryProbe, error) {
+	ref, err := parseURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Bitbucket Cloud URL: %w", err)
+	}
+	return &repositoryProbe{extractor: e, repoURL: repoURL, ref: ref}, nil
+}
+
+// repositoryProbe implements core.RepositoryProbe for a Bitbucket Cloud repository
+type repositoryProbe struct {
+	extractor *Extractor
+	repoURL   string
+	ref       models.RepositoryRef
+	client    ClientInterface
+}
+
+func (p *repositoryProbe) Connect() (bool, error) {
+	client, authenticated, err := p.extractor.clientFor(p.repoURL)
+	p.client = client
+	return authenticated, err
+}
+
+func (p *repositoryProbe) Authenticate(ctx context.Context) (string, error) {
+	user, err := p.client.GetAuthenticatedUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	return nickname(user), nil
+}
+
+func (p *repositoryProbe) Repository(ctx context.Context) (string, error) {
+	repository, err := p.client.GetRepository(ctx, p.ref.Owner, p.ref.Name)
+	if err != nil {
+		return "", err
+	}
+	visibility := "public"
+	if repository.IsPrivate {
+		visibility = "private"
+	}
+	return fmt.Sprintf("%s (%s)", repository.FullName, visibility), nil
+}
+
+func (p *repositoryProbe) CountPullRequests(ctx context.Context) (int, int, error) {
+	count, latest, err := p.client.CountPullRequests(ctx, p.ref.Owner, p.ref.Name)
+	if err != nil || latest == nil {
+		return count, 0, err
+	}
+	return count, latest.ID, nil
+}
+
+func (p *repositoryProbe) Diff(ctx context.Context, id int) (string, error) {
+	rawDiff, err := p.client.GetPullRequestDiff(ctx, p.ref.Owner, p.ref.Name, id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fetched diff of #%d (%d bytes)", id, len(rawDiff)), nil
+}
+
+// EstimateCalls approximates the requests a full extraction of count pull requests needs
+func (p *repositoryProbe) EstimateCalls(count int) int {
+	listPages := (count + pageSize - 1) / pageSize
+	return listPages + count*callsPerPullRequest
+}
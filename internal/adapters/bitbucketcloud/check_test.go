@@ -0,0 +1,42 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"display_name": "Review Bot", "nickname": "review-bot"}`)
+	})
+	mux.HandleFunc("/2.0/repositories/acme/api", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"full_name": "acme/api", "is_private": true}`)
+	})
+	mux.HandleFunc("/2.0/repositories/acme/api/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"size": 120, "values": [{"id": 120}]}`)
+	})
+	mux.HandleFunc("/2.0/repositories/acme/api/pullrequests/120/diff", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "diff --git a/a.go b/a.go\n")
+	})
+	extractor := &Extractor{client: newFakeServer(t, mux, models.Credential{Token: "token"})}
+
+	result, err := extractor.Check(context.Background(), "https://bitbucket.org/acme/api")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryCheck{
+		URL:      "https://bitbucket.org/acme/api",
+		Provider: models.ProviderBitbucketCloud,
+		Checks: []models.Check{
+			{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+			{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+			{Name: "pull requests", Status: models.CheckOK, Detail: "120 pull requests"},
+			{Name: "diff", Status: models.CheckOK, Detail: "fetched diff of #120 (25 bytes)"},
+		},
+		EstimatedCalls: 3 + 120*callsPerPullRequest,
+	}, result)
+}
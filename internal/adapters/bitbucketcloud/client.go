@@ -0,0 +1,215 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+const (
+	// APIURL is the root of the Bitbucket Cloud 2.0 API
+	APIURL = "https://api.bitbucket.org/2.0"
+	// pageSize is the number of items requested per page; Bitbucket caps most endpoints at 50
+	pageSize = 50
+)
+
+// pullRequestStates lists every state, as the pullrequests endpoint only returns open pull
+// requests by default
+var pullRequestStates = []string{PullRequestStateOpen, PullRequestStateMerged, PullRequestStateDeclined, PullRequestStateSuperseded}
+
+// Client calls the Bitbucket Cloud API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	credential models.Credential
+}
+
+// NewClient creates a client for the API at baseURL (usually APIURL), authenticating with
+// the credential, or unauthenticated if it is empty
+func NewClient(baseURL string, credential models.Credential) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		credential: credential,
+	}
+}
+
+// GetPullRequests fetches pull requests for a repository in every state
+func (c *Client) GetPullRequests(ctx context.Context, workspace, repo string) ([]*PullRequest, error) {
+	var allPRs []*PullRequest
+	query := url.Values{"state": pullRequestStates}
+	if err := c.getPages(ctx, c.repoPath(workspace, repo, "pullrequests"), query, func(data json.RawMessage) error {
+		var prs []*PullRequest
+		if err := json.Unmarshal(data, &prs); err != nil {
+			return err
+		}
+		allPRs = append(allPRs, prs...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	return allPRs, nil
+}
+
+// GetPullRequestComments fetches the comments of a pull request, general and inline
+func (c *Client) GetPullRequestComments(ctx context.Context, workspace, repo string, id int) ([]*Comment, error) {
+	var allComments []*Comment
+	if err := c.getPages(ctx, c.repoPath(workspace, repo, "pullrequests", strconv.Itoa(id), "comments"), nil, func(data json.RawMessage) error {
+		var comments []*Comment
+		if err := json.Unmarshal(data, &comments); err != nil {
+			return err
+		}
+		allComments = append(allComments, comments...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+
+	return allComments, nil
+}
+
+// GetDiffStat fetches the number of lines a pull request changes per file
+func (c *Client) GetDiffStat(ctx context.Context, workspace, repo string, id int) ([]*DiffStat, error) {
+	var allStats []*DiffStat
+	if err := c.getPages(ctx, c.repoPath(workspace, repo, "pullrequests", strconv.Itoa(id), "diffstat"), nil, func(data json.RawMessage) error {
+		var stats []*DiffStat
+		if err := json.Unmarshal(data, &stats); err != nil {
+			return err
+		}
+		allStats = append(allStats, stats...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get pull request diffstat: %w", err)
+	}
+
+	return allStats, nil
+}
+
+// GetPullRequestDiff fetches the diff for a pull request. Bitbucket redirects to the diff of
+// the source and destination commits, which the HTTP client follows.
+func (c *Client) GetPullRequestDiff(ctx context.Context, workspace, repo string, id int) (string, error) {
+	data, err := c.fetch(ctx, c.baseURL+c.repoPath(workspace, repo, "pullrequests", strconv.Itoa(id), "diff"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get pull request diff: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// CountPullRequests returns the number of pull requests in a repository and the most
+// recently created one, using a single request
+func (c *Client) CountPullRequests(ctx context.Context, workspace, repo string) (int, *PullRequest, error) {
+	query := url.Values{"state": pullRequestStates, "pagelen": {"1"}, "sort": {"-created_on"}}
+	var page struct {
+		Size   int            `json:"size"`
+		Values []*PullRequest `json:"values"`
+	}
+	if err := c.get(ctx, c.baseURL+c.repoPath(workspace, repo, "pullrequests")+"?"+query.Encode(), &page); err != nil {
+		return 0, nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	if len(page.Values) == 0 {
+		return 0, nil, nil
+	}
+	return max(page.Size, len(page.Values)), page.Values[0], nil
+}
+
+// GetAuthenticatedUser fetches the user the credential belongs to
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.get(ctx, c.baseURL+"/user", &user); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetRepository fetches a repository
+func (c *Client) GetRepository(ctx context.Context, workspace, repo string) (*Repository, error) {
+	var repository Repository
+	if err := c.get(ctx, c.baseURL+c.repoPath(workspace, repo), &repository); err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	return &repository, nil
+}
+
+// repoPath builds the API path of a repository resource
+func (c *Client) repoPath(workspace, repo string, elements ...string) string {
+	path := "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(repo)
+	for _, element := range elements {
+		path += "/" + url.PathEscape(element)
+	}
+	return path
+}
+
+// getPages fetches every page of a list endpoint, following the next links of the
+// responses, and passes each page's values to handle
+func (c *Client) getPages(ctx context.Context, path string, query url.Values, handle func(json.RawMessage) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("pagelen", strconv.Itoa(pageSize))
+	next := c.baseURL + path + "?" + query.Encode()
+
+	for next != "" {
+		var page struct {
+			Values json.RawMessage `json:"values"`
+			Next   string          `json:"next"`
+		}
+		if err := c.get(ctx, next, &page); err != nil {
+			return err
+		}
+		if err := handle(page.Values); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		next = page.Next
+	}
+
+	return nil
+}
+
+// get fetches a URL and decodes its JSON response into v
+func (c *Client) get(ctx context.Context, url string, v any) error {
+	data, err := c.fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// fetch performs an authenticated GET request and returns the response body
+func (c *Client) fetch(ctx context.Context, url string) ([]byte, error) {
+	data, _, err := httpclient.Get(ctx, c.httpClient, url, c.authorize, errorMessage)
+	return data, err
+}
+
+// errorMessage returns the message of a Bitbucket error body, which nests it in an error object
+func errorMessage(data []byte) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return ""
+	}
+	return body.Error.Message
+}
+
+// authorize adds the credential to a request: app passwords use basic auth with the
+// account's username, access tokens the bearer scheme
+func (c *Client) authorize(req *http.Request) {
+	httpclient.Authorize(req, c.credential, "Bearer")
+}
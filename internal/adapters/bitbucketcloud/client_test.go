@@ -0,0 +1,91 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeServer starts a local Bitbucket Cloud API stand-in and returns a client talking to it
+func newFakeServer(t *testing.T, mux *http.ServeMux, credential models.Credential) *Client {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/2.0", credential)
+}
+
+func TestClient_GetPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2.0/repositories/acme/api/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "bot", user)
+		assert.Equal(t, "app-password", password)
+		assert.Equal(t, pullRequestStates, r.URL.Query()["state"])
+
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"values": [{"id": 1, "title": "First"}], "page": 2}`)
+			return
+		}
+		assert.Equal(t, "50", r.URL.Query().Get("pagelen"))
+		fmt.Fprintf(w, `{"values": [{"id": 2, "title": "Second"}], "page": 1,
+			"next": "http://%s/2.0/repositories/acme/api/pullrequests?pagelen=50&state=OPEN&state=MERGED&state=DECLINED&state=SUPERSEDED&page=2"}`, r.Host)
+	})
+	client := newFakeServer(t, mux, models.Credential{Method: models.AuthAppPassword, Username: "bot", Password: "app-password"})
+
+	prs, err := client.GetPullRequests(context.Background(), "acme", "api")
+	assert.NoError(t, err)
+	assert.Len(t, prs, 2)
+	assert.Equal(t, 2, prs[0].ID)
+	assert.Equal(t, "First", prs[1].Title)
+}
+
+func TestClient_CountPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2.0/repositories/acme/api/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "1", r.URL.Query().Get("pagelen"))
+		assert.Equal(t, "-created_on", r.URL.Query().Get("sort"))
+		fmt.Fprint(w, `{"size": 212, "values": [{"id": 212}]}`)
+	})
+	client := newFakeServer(t, mux, models.Credential{Token: "token"})
+
+	count, latest, err := client.CountPullRequests(context.Background(), "acme", "api")
+	assert.NoError(t, err)
+	assert.Equal(t, 212, count)
+	assert.Equal(t, 212, latest.ID)
+}
+
+func TestClient_GetPullRequestDiff(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2.0/repositories/acme/api/pullrequests/7/diff", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/2.0/repositories/acme/api/diff/acme/api:abc123%0Ddef456", http.StatusFound)
+	})
+	mux.HandleFunc("/2.0/repositories/acme/api/diff/", func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+		assert.Equal(t, "app-password", password, "credentials are kept across the redirect")
+		fmt.Fprint(w, "diff --git a/main.go b/main.go\n")
+	})
+	client := newFakeServer(t, mux, models.Credential{Method: models.AuthAppPassword, Username: "bot", Password: "app-password"})
+
+	rawDiff, err := client.GetPullRequestDiff(context.Background(), "acme", "api", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "diff --git a/main.go b/main.go\n", rawDiff)
+}
+
+func TestClient_ErrorMessage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2.0/repositories/acme/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"type": "error", "error": {"message": "Repository acme/missing not found"}}`)
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	_, err := client.GetRepository(context.Background(), "acme", "missing")
+	assert.ErrorContains(t, err, "failed to get repository: GET ")
+	assert.ErrorContains(t, err, "404 Repository acme/missing not found")
+}
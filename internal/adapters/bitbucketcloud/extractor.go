@@ -0,0 +1,243 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// diffContextRadius is the number of lines around a commented line kept as diff context
+const diffContextRadius = 3
+
+// Extractor implements the core.Extractor interface for Bitbucket Cloud (bitbucket.org)
+type Extractor struct {
+	// client, if set, is used for every repository instead of clients built from credentials
+	client  ClientInterface
+	clients *httpclient.Clients[ClientInterface]
+}
+
+// NewExtractor creates a new Bitbucket Cloud extractor, authenticating with the credentials
+// of each repository
+func NewExtractor(source credentials.Source) *Extractor {
+	return &Extractor{
+		clients: httpclient.NewClients(models.ProviderBitbucketCloud, authMethods, source, func(baseURL string, credential models.Credential) ClientInterface {
+			return NewClient(baseURL, credential)
+		}),
+	}
+}
+
+// clientFor returns the client for a repository and whether it is authenticated
+func (e *Extractor) clientFor(repoURL string) (ClientInterface, bool, error) {
+	if e.client != nil {
+		return e.client, true, nil
+	}
+	return e.clients.For(repoURL, APIURL)
+}
+
+// ExtractReviews implements the core.Extractor interface
+func (e *Extractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	return core.Collect(e.StreamReviews(ctx, repoURL))
+}
+
+// StreamReviews implements the core.StreamExtractor interface, yielding the reviews of each
+// pull request once they have been fetched
+func (e *Extractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		ref, err := parseURL(repoURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("invalid Bitbucket Cloud URL: %w", err))
+			return
+		}
+		client, _, err := e.clientFor(repoURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to authenticate: %w", err))
+			return
+		}
+
+		// Get all pull requests
+		prs, err := client.GetPullRequests(ctx, ref.Owner, ref.Name)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to get pull requests: %w", err))
+			return
+		}
+
+		// Process each pull request
+		for _, pr := range prs {
+			reviews, err := e.pullRequestReviews(ctx, client, ref, pr)
+			if err != nil {
+				yield(models.Review{}, err)
+				return
+			}
+			for _, review := range reviews {
+				if !yield(review, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pullRequestReviews extracts the published comments of a pull request
+func (e *Extractor) pullRequestReviews(ctx context.Context, client ClientInterface, ref models.RepositoryRef, pr *PullRequest) ([]models.Review, error) {
+	comments, err := client.GetPullRequestComments(ctx, ref.Owner, ref.Name, pr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments for PR #%d: %w", pr.ID, err)
+	}
+	stats, err := client.GetDiffStat(ctx, ref.Owner, ref.Name, pr.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diffstat for PR #%d: %w", pr.ID, err)
+	}
+
+	// The diff is only needed for inline comments
+	var files []diff.File
+	for _, comment := range comments {
+		if comment.Inline != nil && !comment.Deleted && !comment.Pending {
+			rawDiff, err := client.GetPullRequestDiff(ctx, ref.Owner, ref.Name, pr.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get diff for PR #%d: %w", pr.ID, err)
+			}
+			files = diff.Parse(rawDiff)
+			break
+		}
+	}
+
+	base := models.Review{
+		PRID:        pr.ID,
+		PRTitle:     pr.Title,
+		PRAuthor:    nickname(pr.Author),
+		Repository:  ref.Name,
+		Repo:        ref,
+		Provider:    models.ProviderBitbucketCloud,
+		PullRequest: convertPullRequest(pr, ref, stats),
+	}
+
+	var reviews []models.Review
+	for _, comment := range comments {
+		// Deleted comments keep their place in threads; pending ones are unpublished drafts
+		if comment.Deleted || comment.Pending {
+			continue
+		}
+		reviews = append(reviews, convertComment(base, comment, files))
+	}
+	return reviews, nil
+}
+
+// convertComment maps a pull request comment to the shared model. Inline comments are
+// anchored to the new side of the diff, or to the old side for removed lines.
+func convertComment(base models.Review, comment *Comment, files []diff.File) models.Review {
+	review := base
+	review.CommentID = fmt.Sprintf("%d", comment.ID)
+	review.CommentAuthor = nickname(comment.User)
+	review.CommentText = comment.Content.Raw
+	review.CommentCreated = comment.CreatedOn
+	review.ReviewState = models.ReviewStateCommented
+	if comment.Parent != nil {
+		review.InReplyTo = fmt.Sprintf("%d", comment.Parent.ID)
+	}
+
+	if inline := comment.Inline; inline != nil {
+		review.FilePath = inline.Path
+		line, old := 0, false
+		switch {
+		case inline.To != nil:
+			line = *inline.To
+		case inline.From != nil:
+			line, old = *inline.From, true
+		}
+		review.LineNumber = line
+		if file := diff.FindFile(files, inline.Path); file != nil && line > 0 {
+			review.DiffContext = diff.Context(file.Hunks, line, old, diffContextRadius)
+		}
+	}
+	return review
+}
+
+// convertPullRequest maps a Bitbucket Cloud pull request to the shared model. The API has no
+// merge or close date, so closed pull requests use their last update.
+func convertPullRequest(pr *PullRequest, repo models.RepositoryRef, stats []*DiffStat) *models.PullRequest {
+	pullRequest := &models.PullRequest{
+		Number:       pr.ID,
+		Repository:   repo,
+		Provider:     models.ProviderBitbucketCloud,
+		Title:        pr.Title,
+		Author:       nickname(pr.Author),
+		URL:          pr.Links.HTML.Href,
+		State:        "open",
+		Draft:        pr.Draft,
+		CreatedAt:    pr.CreatedOn,
+		BaseBranch:   pr.Destination.Branch.Name,
+		HeadBranch:   pr.Source.Branch.Name,
+		ChangedFiles: len(stats),
+	}
+	if pr.Source.Commit != nil {
+		pullRequest.HeadSHA = pr.Source.Commit.Hash
+	}
+	if pr.State != PullRequestStateOpen {
+		updated := pr.UpdatedOn
+		pullRequest.State = "closed"
+		pullRequest.ClosedAt = &updated
+		if pr.State == PullRequestStateMerged {
+			pullRequest.Merged = true
+			pullRequest.MergedAt = &updated
+		}
+	}
+	for _, stat := range stats {
+		pullRequest.Additions += stat.LinesAdded
+		pullRequest.Deletions += stat.LinesRemoved
+	}
+	return pullRequest
+}
+
+// nickname returns the nickname of a user, its display name if it has none, or an empty
+// string for deleted users
+func nickname(user *User) string {
+	switch {
+	case user == nil:
+		return ""
+	case user.Nickname != "":
+		return user.Nickname
+	default:
+		return user.DisplayName
+	}
+}
+
+// parseURL parses a repository URL of the form https://bitbucket.org/<workspace>/<repo>
+func parseURL(repoURL string) (models.RepositoryRef, error) {
+	invalid := fmt.Errorf("invalid URL format: expected https://bitbucket.org/<workspace>/<repo>")
+
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(repoURL), "/"))
+	if err != nil || u.Scheme != "https" {
+		return models.RepositoryRef{}, invalid
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	if host != "bitbucket.org" {
+		return models.RepositoryRef{}, invalid
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return models.RepositoryRef{}, invalid
+	}
+
+	workspace, name := segments[0], strings.TrimSuffix(segments[1], ".git")
+	return models.RepositoryRef{
+		Host:  host,
+		Owner: workspace,
+		Name:  name,
+		URL:   "https://bitbucket.org/" + workspace + "/" + name,
+	}, nil
+}
+
+// ValidateURL checks that url identifies a Bitbucket Cloud repository
+func ValidateURL(url string) error {
+	_, err := parseURL(url)
+	return err
+}
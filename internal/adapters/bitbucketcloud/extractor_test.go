@@ -0,0 +1,140 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractReviews(t *testing.T) {
+	const repo = "/2.0/repositories/acme/api"
+	mux := http.NewServeMux()
+	mux.HandleFunc(repo+"/pullrequests", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"values": [
+			{"id": 7, "title": "Add retries", "state": "MERGED", "author": {"display_name": "Alice", "nickname": "alice"},
+			 "created_on": "2024-05-01T10:00:00.000000+00:00", "updated_on": "2024-05-02T10:00:00.000000+00:00",
+			 "source": {"branch": {"name": "retries"}, "commit": {"hash": "abc123def456"}},
+			 "destination": {"branch": {"name": "main"}, "commit": {"hash": "0123456789ab"}},
+			 "links": {"html": {"href": "https://bitbucket.org/acme/api/pull-requests/7"}}},
+			{"id": 8, "title": "Docs", "state": "OPEN", "author": {"display_name": "Bob"}}
+		]}`)
+	})
+	mux.HandleFunc(repo+"/pullrequests/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"values": [
+			{"id": 10, "content": {"raw": "Retry forever?"}, "user": {"nickname": "bob"}, "created_on": "2024-05-01T11:00:00+00:00",
+			 "inline": {"path": "client.go", "from": null, "to": 12}},
+			{"id": 11, "content": {"raw": "Bounded now"}, "user": {"nickname": "alice"}, "created_on": "2024-05-01T12:00:00+00:00",
+			 "inline": {"path": "client.go", "from": null, "to": 12}, "parent": {"id": 10}},
+			{"id": 12, "content": {"raw": "Why remove this?"}, "user": {"display_name": "Carol"}, "created_on": "2024-05-01T11:30:00+00:00",
+			 "inline": {"path": "client.go", "from": 11, "to": null}},
+			{"id": 13, "content": {"raw": "Looks good overall"}, "user": {"nickname": "bob"}, "created_on": "2024-05-01T13:00:00+00:00"},
+			{"id": 14, "content": {"raw": ""}, "deleted": true},
+			{"id": 15, "content": {"raw": "Draft"}, "pending": true}
+		]}`)
+	})
+	mux.HandleFunc(repo+"/pullrequests/7/diffstat", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"values": [
+			{"status": "modified", "lines_added": 3, "lines_removed": 1, "old": {"path": "client.go"}, "new": {"path": "client.go"}},
+			{"status": "added", "lines_added": 20, "lines_removed": 0, "old": null, "new": {"path": "retry.go"}}
+		]}`)
+	})
+	diffs := 0
+	mux.HandleFunc(repo+"/pullrequests/7/diff", func(w http.ResponseWriter, r *http.Request) {
+		diffs++
+		fmt.Fprint(w, "diff --git a/client.go b/client.go\n--- a/client.go\n+++ b/client.go\n@@ -10,3 +10,4 @@ func call() {\n \tfor {\n-\t\treturn do()\n+\t\tif err := do(); err == nil {\n+\t\t\treturn nil\n \t\t}\n")
+	})
+	fetched := 0
+	mux.HandleFunc(repo+"/pullrequests/8/comments", func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+		fmt.Fprint(w, `{"values": []}`)
+	})
+	mux.HandleFunc(repo+"/pullrequests/8/diffstat", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"values": []}`)
+	})
+	mux.HandleFunc(repo+"/pullrequests/8/diff", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the diff of a pull request without inline comments is not needed")
+	})
+	extractor := &Extractor{client: newFakeServer(t, mux, models.Credential{})}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://bitbucket.org/acme/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 4)
+	assert.Equal(t, 1, diffs)
+
+	inline := reviews[0]
+	assert.Equal(t, "10", inline.CommentID)
+	assert.Equal(t, "bob", inline.CommentAuthor)
+	assert.Equal(t, "client.go", inline.FilePath)
+	assert.Equal(t, 12, inline.LineNumber)
+	assert.Equal(t, "for {\nif err := do(); err == nil {\nreturn nil\n}", inline.DiffContext)
+	assert.Equal(t, models.ReviewStateCommented, inline.ReviewState)
+	assert.Equal(t, models.ProviderBitbucketCloud, inline.Provider)
+	assert.Equal(t, models.RepositoryRef{Host: "bitbucket.org", Owner: "acme", Name: "api", URL: "https://bitbucket.org/acme/api"}, inline.Repo)
+
+	assert.Equal(t, "10", reviews[1].InReplyTo)
+
+	removed := reviews[2]
+	assert.Equal(t, 11, removed.LineNumber, "comments on removed lines use the from anchor")
+	assert.Equal(t, "for {\nreturn do()\n}", removed.DiffContext)
+	assert.Equal(t, "Carol", removed.CommentAuthor)
+
+	assert.Equal(t, "Looks good overall", reviews[3].CommentText)
+	assert.Empty(t, reviews[3].FilePath)
+
+	pr := inline.PullRequest
+	assert.Equal(t, "closed", pr.State)
+	assert.True(t, pr.Merged)
+	assert.Equal(t, "alice", pr.Author)
+	assert.Equal(t, "https://bitbucket.org/acme/api/pull-requests/7", pr.URL)
+	assert.Equal(t, "main", pr.BaseBranch)
+	assert.Equal(t, "retries", pr.HeadBranch)
+	assert.Equal(t, "abc123def456", pr.HeadSHA)
+	assert.Equal(t, 23, pr.Additions)
+	assert.Equal(t, 1, pr.Deletions)
+	assert.Equal(t, 2, pr.ChangedFiles)
+	assert.Equal(t, pr.ClosedAt, pr.MergedAt)
+
+	fetched = 0
+	for review, err := range extractor.StreamReviews(context.Background(), "https://bitbucket.org/acme/api") {
+		assert.NoError(t, err)
+		assert.Equal(t, "10", review.CommentID)
+		break
+	}
+	assert.Zero(t, fetched, "pull requests after the consumer stopped are not fetched")
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		ref     models.RepositoryRef
+		wantErr bool
+	}{
+		{
+			url: "https://bitbucket.org/acme/api.git",
+			ref: models.RepositoryRef{Host: "bitbucket.org", Owner: "acme", Name: "api", URL: "https://bitbucket.org/acme/api"},
+		},
+		{
+			url: "https://www.Bitbucket.org/acme/api/",
+			ref: models.RepositoryRef{Host: "bitbucket.org", Owner: "acme", Name: "api", URL: "https://bitbucket.org/acme/api"},
+		},
+		{url: "https://bitbucket.org/acme", wantErr: true},
+		{url: "https://bitbucket.org/acme/api/pull-requests/7", wantErr: true},
+		{url: "https://bitbucket.example.com/projects/PROJ/repos/api", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ref, err := parseURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, ValidateURL(tt.url))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ref, ref)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package bitbucketcloud
+
+import "context"
+
+// ClientInterface defines the interface for Bitbucket Cloud API operations
+type ClientInterface interface {
+	GetPullRequests(ctx context.Context, workspace, repo string) ([]*PullRequest, error)
+	GetPullRequestComments(ctx context.Context, workspace, repo string, id int) ([]*Comment, error)
+	GetDiffStat(ctx context.Context, workspace, repo string, id int) ([]*DiffStat, error)
+	GetPullRequestDiff(ctx context.Context, workspace, repo string, id int) (string, error)
+	CountPullRequests(ctx context.Context, workspace, repo string) (int, *PullRequest, error)
+	GetAuthenticatedUser(ctx context.Context) (*User, error)
+	GetRepository(ctx context.Context, workspace, repo string) (*Repository, error)
+}
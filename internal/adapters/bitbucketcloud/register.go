@@ -0,0 +1,26 @@
+package bitbucketcloud
+
+import (
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// authMethods lists the credential methods Bitbucket Cloud accepts
+var authMethods = []models.AuthMethod{models.AuthBearer, models.AuthBasic, models.AuthAppPassword}
+
+func init() {
+	core.Register(core.ProviderFactory{
+		Name:        models.ProviderBitbucketCloud,
+		Description: "Bitbucket Cloud (bitbucket.org)",
+		Example:     "https://bitbucket.org/<workspace>/<repo>",
+		AuthMethods: authMethods,
+		MatchURL: func(url string) bool {
+			return ValidateURL(url) == nil
+		},
+		ValidateURL: ValidateURL,
+		New: func(config *models.Config, source credentials.Source) core.Extractor {
+			return NewExtractor(source)
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package bitbucketcloud
+
+import "time"
+
+// User is a Bitbucket account. Nickname is the public handle; usernames are no longer
+// returned by the API.
+type User struct {
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+	AccountID   string `json:"account_id"`
+}
+
+// Link is a hypermedia link
+type Link struct {
+	Href string `json:"href"`
+}
+
+// Endpoint is the source or destination of a pull request
+type Endpoint struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit *struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+// Pull request states reported by Bitbucket Cloud
+const (
+	PullRequestStateOpen       = "OPEN"
+	PullRequestStateMerged     = "MERGED"
+	PullRequestStateDeclined   = "DECLINED"
+	PullRequestStateSuperseded = "SUPERSEDED"
+)
+
+// PullRequest is a pull request as returned by the pullrequests endpoint
+type PullRequest struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	State       string    `json:"state"`
+	Draft       bool      `json:"draft"`
+	Author      *User     `json:"author"`
+	CreatedOn   time.Time `json:"created_on"`
+	UpdatedOn   time.Time `json:"updated_on"`
+	Source      Endpoint  `json:"source"`
+	Destination Endpoint  `json:"destination"`
+	Links       struct {
+		HTML Link `json:"html"`
+	} `json:"links"`
+}
+
+// Inline anchors a comment to a file. To is the line on the new side of the diff and From
+// the line on the old side; only one is set.
+type Inline struct {
+	Path string `json:"path"`
+	From *int   `json:"from"`
+	To   *int   `json:"to"`
+}
+
+// Comment is a pull request comment. Comments without Inline are on the pull request as a
+// whole.
+type Comment struct {
+	ID      int `json:"id"`
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	User      *User     `json:"user"`
+	CreatedOn time.Time `json:"created_on"`
+	Inline    *Inline   `json:"inline"`
+	Parent    *struct {
+		ID int `json:"id"`
+	} `json:"parent"`
+	Deleted bool `json:"deleted"`
+	Pending bool `json:"pending"`
+}
+
+// DiffStatFile is a file of a diffstat entry
+type DiffStatFile struct {
+	Path string `json:"path"`
+}
+
+// DiffStat counts the lines a pull request changes in one file
+type DiffStat struct {
+	Status       string        `json:"status"`
+	LinesAdded   int           `json:"lines_added"`
+	LinesRemoved int           `json:"lines_removed"`
+	Old          *DiffStatFile `json:"old"`
+	New          *DiffStatFile `json:"new"`
+}
+
+// Repository is a Bitbucket repository
+type Repository struct {
+	FullName  string `json:"full_name"`
+	IsPrivate bool   `json:"is_private"`
+}
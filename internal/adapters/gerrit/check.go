@@ -0,0 +1,101 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// callsPerChange is the number of requests needed to extract one change: its comments, plus
+// the patches of the patch sets that have comments, assumed to be one
+const callsPerChange = 2
+
+// Check implements the core.Checker interface. It verifies that the credential can read the
+// project, list its changes and fetch a patch. Gerrit has no rate limit API, so the budget is
+// not checked.
+func (e *Extractor) Check(ctx context.Context, repoURL string) (*models.RepositoryCheck, error) {
+	probe, err := e.probe(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return core.CheckRepository(ctx, repoURL, models.ProviderGerrit, probe), nil
+}
+
+// Plan implements the core.Planner interface. Gerrit does not report the number of changes,
+// so they are counted by listing them.
+func (e *Extractor) Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error) {
+	probe, err := e.probe(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return core.PlanRepository(ctx, repoURL, models.ProviderGerrit, probe)
+}
+
+// probe returns the core.RepositoryProbe of a project
+func (e *Extractor) probe(repoURL string) (*projectProbe, error) {
+	_, baseURL, project, err := parseURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Gerrit URL: %w", err)
+	}
+	return &projectProbe{extractor: e, repoURL: repoURL, baseURL: baseURL, project: project}, nil
+}
+
+// projectProbe implements core.RepositoryProbe for a Gerrit project; its pull requests are
+// changes, identified by their number
+type projectProbe struct {
+	extractor *Extractor
+	repoURL   string
+	baseURL   string
+	project   string
+	client    ClientInterface
+}
+
+func (p *projectProbe) Connect() (bool, error) {
+	client, authenticated, err := p.extractor.clientFor(p.repoURL, p.baseURL)
+	p.client = client
+	return authenticated, err
+}
+
+func (p *projectProbe) Authenticate(ctx context.Context) (string, error) {
+	account, err := p.client.GetSelf(ctx)
+	if err != nil {
+		return "", err
+	}
+	return username(account), nil
+}
+
+func (p *projectProbe) Repository(ctx context.Context) (string, error) {
+	info, err := p.client.GetProject(ctx, p.project)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%s)", info.Name, info.State), nil
+}
+
+func (p *projectProbe) CountPullRequests(ctx context.Context) (int, int, error) {
+	count, latest, err := p.client.CountChanges(ctx, p.project)
+	if err != nil || latest == nil {
+		return count, 0, err
+	}
+	return count, latest.Number, nil
+}
+
+// Diff fetches the patch of the current patch set of a change
+func (p *projectProbe) Diff(ctx context.Context, number int) (string, error) {
+	id := url.PathEscape(p.project) + "~" + strconv.Itoa(number)
+	patch, err := p.client.GetPatch(ctx, id, "current")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fetched patch of change %d (%d bytes)", number, len(patch)), nil
+}
+
+// EstimateCalls approximates the requests a full extraction of count changes needs
+func (p *projectProbe) EstimateCalls(count int) int {
+	listPages := count/pageSize + 1
+	return listPages + count*callsPerChange
+}
@@ -0,0 +1,46 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a/accounts/self", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ")]}'\n"+`{"_account_id": 1000, "username": "review-bot"}`)
+	})
+	mux.HandleFunc("/a/projects/{project}", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "platform/kernel", r.PathValue("project"))
+		fmt.Fprint(w, ")]}'\n"+`{"id": "platform%2Fkernel", "name": "platform/kernel", "state": "ACTIVE"}`)
+	})
+	mux.HandleFunc("/a/changes/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.Query()["o"], "counting does not request details")
+		fmt.Fprint(w, ")]}'\n"+`[{"id": "platform%2Fkernel~12", "_number": 12}, {"id": "platform%2Fkernel~11", "_number": 11}]`)
+	})
+	mux.HandleFunc("/a/changes/{id}/revisions/current/patch", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "platform/kernel~12", r.PathValue("id"))
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte("diff --git a/a.c b/a.c\n")))
+	})
+	extractor := &Extractor{client: newFakeServer(t, mux, models.Credential{Token: "token"})}
+
+	result, err := extractor.Check(context.Background(), "https://review.example.com/platform/kernel")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryCheck{
+		URL:      "https://review.example.com/platform/kernel",
+		Provider: models.ProviderGerrit,
+		Checks: []models.Check{
+			{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+			{Name: "repository", Status: models.CheckOK, Detail: "platform/kernel (ACTIVE)"},
+			{Name: "pull requests", Status: models.CheckOK, Detail: "2 pull requests"},
+			{Name: "diff", Status: models.CheckOK, Detail: "fetched patch of change 12 (23 bytes)"},
+		},
+		EstimatedCalls: 1 + 2*callsPerChange,
+	}, result)
+}
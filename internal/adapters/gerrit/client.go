@@ -0,0 +1,194 @@
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// pageSize is the number of changes requested per query page
+const pageSize = 100
+
+// xssiPrefix is prepended by Gerrit to every JSON response to prevent cross-site script
+// inclusion
+var xssiPrefix = []byte(")]}'")
+
+// changeOptions are the query options needed to extract a change: its patch sets, the
+// files of the current one, its messages and the details of the accounts involved
+var changeOptions = []string{"ALL_REVISIONS", "CURRENT_FILES", "MESSAGES", "DETAILED_ACCOUNTS"}
+
+// Client calls the Gerrit API of one server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	credential models.Credential
+}
+
+// NewClient creates a client for the server at baseURL (e.g. https://review.example.com),
+// authenticating with the credential, or unauthenticated if it is empty
+func NewClient(baseURL string, credential models.Credential) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		credential: credential,
+	}
+}
+
+// GetChanges fetches the changes of a project, most recently updated first
+func (c *Client) GetChanges(ctx context.Context, project string) ([]*Change, error) {
+	changes, err := c.queryChanges(ctx, project, changeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetComments fetches the published inline comments of a change across all patch sets,
+// ordered by time. changeID is the ID Gerrit reports, in which the project is URL encoded.
+func (c *Client) GetComments(ctx context.Context, changeID string) ([]*Comment, error) {
+	var byPath map[string][]*Comment
+	if err := c.get(ctx, "/changes/"+changeID+"/comments", nil, &byPath); err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	var comments []*Comment
+	for path, pathComments := range byPath {
+		for _, comment := range pathComments {
+			comment.Path = path
+			comments = append(comments, comment)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		if !comments[i].Updated.Equal(comments[j].Updated.Time) {
+			return comments[i].Updated.Before(comments[j].Updated.Time)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+
+	return comments, nil
+}
+
+// GetPatch fetches the diff of a patch set against its parent, in git format-patch form
+func (c *Client) GetPatch(ctx context.Context, changeID, revision string) (string, error) {
+	path := "/changes/" + changeID + "/revisions/" + url.PathEscape(revision) + "/patch"
+	data, err := c.fetch(ctx, path, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get patch: %w", err)
+	}
+
+	// Patches are served base64 encoded
+	patch, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode patch: %w", err)
+	}
+	return string(patch), nil
+}
+
+// CountChanges returns the number of changes in a project and the most recently updated
+// one. Gerrit does not report totals, so every page is listed, without details.
+func (c *Client) CountChanges(ctx context.Context, project string) (int, *Change, error) {
+	changes, err := c.queryChanges(ctx, project, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	if len(changes) == 0 {
+		return 0, nil, nil
+	}
+	return len(changes), changes[0], nil
+}
+
+// GetSelf fetches the account the credential belongs to
+func (c *Client) GetSelf(ctx context.Context) (*Account, error) {
+	var account Account
+	if err := c.get(ctx, "/accounts/self", nil, &account); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetProject fetches a project
+func (c *Client) GetProject(ctx context.Context, project string) (*Project, error) {
+	var info Project
+	if err := c.get(ctx, "/projects/"+url.PathEscape(project), nil, &info); err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return &info, nil
+}
+
+// queryChanges lists every change of a project, requesting the given options
+func (c *Client) queryChanges(ctx context.Context, project string, options []string) ([]*Change, error) {
+	var allChanges []*Change
+	for start := 0; ; start += pageSize {
+		query := url.Values{
+			"q": {fmt.Sprintf("project:%q", project)},
+			"n": {strconv.Itoa(pageSize)},
+			"S": {strconv.Itoa(start)},
+			"o": options,
+		}
+		var changes []*Change
+		if err := c.get(ctx, "/changes/", query, &changes); err != nil {
+			return nil, err
+		}
+		allChanges = append(allChanges, changes...)
+		// Only the last change of a page says whether more follow
+		if len(changes) == 0 || !changes[len(changes)-1].MoreChanges {
+			return allChanges, nil
+		}
+	}
+}
+
+// get fetches an API path and decodes its JSON response into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	data, err := c.fetch(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bytes.TrimPrefix(data, xssiPrefix), v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// fetch performs a GET request and returns the response body. Authenticated requests go
+// through Gerrit's /a/ prefix.
+func (c *Client) fetch(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	if !c.credential.IsZero() {
+		path = "/a" + path
+	}
+	url := c.baseURL + path
+	if len(query) > 0 {
+		url += "?" + query.Encode()
+	}
+
+	data, _, err := httpclient.Get(ctx, c.httpClient, url, c.authorize, plainMessage)
+	return data, err
+}
+
+// plainMessage returns the plain text Gerrit explains errors in, unless it is too long to
+// be a message
+func plainMessage(data []byte) string {
+	if message := strings.TrimSpace(string(data)); len(message) < 200 {
+		return message
+	}
+	return ""
+}
+
+// authorize adds the credential to a request: logins with the HTTP password Gerrit generates
+// use basic auth, tokens of servers set up for OAuth the bearer scheme
+func (c *Client) authorize(req *http.Request) {
+	httpclient.Authorize(req, c.credential, "Bearer")
+}
@@ -0,0 +1,97 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeServer starts a local Gerrit API stand-in and returns a client talking to it
+func newFakeServer(t *testing.T, mux *http.ServeMux, credential models.Credential) *Client {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, credential)
+}
+
+func TestClient_GetChanges(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a/changes/", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "bot", user)
+		assert.Equal(t, "http-password", password)
+		assert.Equal(t, `project:"platform/kernel"`, r.URL.Query().Get("q"))
+		assert.Equal(t, changeOptions, r.URL.Query()["o"])
+
+		fmt.Fprint(w, ")]}'\n")
+		if r.URL.Query().Get("S") == "100" {
+			fmt.Fprint(w, `[{"_number": 1, "subject": "First", "created": "2024-05-01 10:00:00.000000000"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"_number": 2, "subject": "Second"}, {"_number": 3, "_more_changes": true}]`)
+	})
+	client := newFakeServer(t, mux, models.Credential{Method: models.AuthBasic, Username: "bot", Password: "http-password"})
+
+	changes, err := client.GetChanges(context.Background(), "platform/kernel")
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "Second", changes[0].Subject)
+	assert.Equal(t, 1, changes[2].Number)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), changes[2].Created.Time)
+}
+
+func TestClient_GetComments(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/changes/{id}/comments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "platform/kernel~42", r.PathValue("id"))
+		assert.Empty(t, r.Header.Get("Authorization"), "anonymous requests are not authenticated")
+		fmt.Fprint(w, `)]}'
+		{
+			"drivers/gpio.c": [{"id": "c2", "patch_set": 2, "line": 10, "message": "Later", "updated": "2024-05-01 12:00:00.000000000"}],
+			"/PATCHSET_LEVEL": [{"id": "c1", "patch_set": 1, "message": "Earlier", "updated": "2024-05-01 11:00:00.000000000"}]
+		}`)
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	comments, err := client.GetComments(context.Background(), "platform%2Fkernel~42")
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+	assert.Equal(t, "c1", comments[0].ID, "comments are ordered by time")
+	assert.Equal(t, "/PATCHSET_LEVEL", comments[0].Path)
+	assert.Equal(t, "drivers/gpio.c", comments[1].Path)
+}
+
+func TestClient_GetPatch(t *testing.T) {
+	patch := "From abc123 Mon Sep 17 00:00:00 2001\nSubject: Fix\n\ndiff --git a/main.c b/main.c\n"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a/changes/{id}/revisions/{revision}/patch", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "abc123", r.PathValue("revision"))
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte(patch)))
+	})
+	client := newFakeServer(t, mux, models.Credential{Token: "token"})
+
+	got, err := client.GetPatch(context.Background(), "kernel~42", "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, patch, got)
+}
+
+func TestClient_ErrorMessage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/projects/{project}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Not found: missing\n")
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	_, err := client.GetProject(context.Background(), "missing")
+	assert.ErrorContains(t, err, "failed to get project: GET ")
+	assert.ErrorContains(t, err, "404 Not found: missing")
+}
@@ -0,0 +1,395 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// diffContextRadius is the number of lines around a commented line kept as diff context
+const diffContextRadius = 3
+
+// Paths Gerrit uses for comments that are not on a file
+const (
+	patchSetLevelPath = "/PATCHSET_LEVEL"
+	commitMessagePath = "/COMMIT_MSG"
+)
+
+var (
+	// messageHeaderPattern matches the first line of a review message, which lists its votes
+	messageHeaderPattern = regexp.MustCompile(`^Patch Set \d+:(.*)$`)
+	// codeReviewPattern matches a Code-Review vote in a review message header
+	codeReviewPattern = regexp.MustCompile(`\bCode-Review([+-]\d+)`)
+	// commentCountPattern matches the line of a review message counting its inline comments
+	commentCountPattern = regexp.MustCompile(`^\(\d+ (inline )?comments?\)$`)
+)
+
+// Extractor implements the core.Extractor interface for Gerrit. Changes are mapped to pull
+// requests and review messages to reviews; the server is derived from each repository URL.
+type Extractor struct {
+	// client, if set, is used for every repository instead of clients built from credentials
+	client  ClientInterface
+	clients *httpclient.Clients[ClientInterface]
+}
+
+// NewExtractor creates a new Gerrit extractor, authenticating with the credentials of each
+// repository
+func NewExtractor(source credentials.Source) *Extractor {
+	return &Extractor{
+		clients: httpclient.NewClients(models.ProviderGerrit, authMethods, source, func(baseURL string, credential models.Credential) ClientInterface {
+			return NewClient(baseURL, credential)
+		}),
+	}
+}
+
+// clientFor returns the client for a repository and whether it is authenticated
+func (e *Extractor) clientFor(repoURL, baseURL string) (ClientInterface, bool, error) {
+	if e.client != nil {
+		return e.client, true, nil
+	}
+	return e.clients.For(repoURL, baseURL)
+}
+
+// ExtractReviews implements the core.Extractor interface
+func (e *Extractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	return core.Collect(e.StreamReviews(ctx, repoURL))
+}
+
+// StreamReviews implements the core.StreamExtractor interface, yielding the reviews of each
+// change once they have been fetched
+func (e *Extractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		ref, baseURL, project, err := parseURL(repoURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("invalid Gerrit URL: %w", err))
+			return
+		}
+		client, _, err := e.clientFor(repoURL, baseURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to authenticate: %w", err))
+			return
+		}
+
+		// Get all changes
+		changes, err := client.GetChanges(ctx, project)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to get changes: %w", err))
+			return
+		}
+
+		// Process each change
+		for _, change := range changes {
+			reviews, err := e.changeReviews(ctx, client, ref, baseURL, change)
+			if err != nil {
+				yield(models.Review{}, err)
+				return
+			}
+			for _, review := range reviews {
+				if !yield(review, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// changeReviews extracts the review messages and inline comments of a change
+func (e *Extractor) changeReviews(ctx context.Context, client ClientInterface, ref models.RepositoryRef, baseURL string, change *Change) ([]models.Review, error) {
+	comments, err := client.GetComments(ctx, change.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments for change %d: %w", change.Number, err)
+	}
+
+	base := models.Review{
+		PRID:        change.Number,
+		PRTitle:     change.Subject,
+		PRAuthor:    username(change.Owner),
+		Repository:  ref.Name,
+		Repo:        ref,
+		Provider:    models.ProviderGerrit,
+		PullRequest: convertChange(change, ref, baseURL),
+	}
+
+	// Review messages carry the votes; inline comments link to the message they were
+	// published with
+	states := make(map[string]models.ReviewState)
+	var bodies []models.Review
+	for _, message := range change.Messages {
+		if strings.HasPrefix(message.Tag, "autogenerated:") {
+			continue
+		}
+		text, vote := parseMessage(message.Message)
+		states[message.ID] = reviewState(vote)
+		if text == "" {
+			continue
+		}
+
+		review := base
+		review.CommentID = message.ID
+		review.CommentAuthor = username(message.Author)
+		review.CommentText = text
+		review.CommentCreated = message.Date.Time
+		review.ReviewID = message.ID
+		review.ReviewState = states[message.ID]
+		bodies = append(bodies, review)
+	}
+
+	patches := newPatchCache(client, change)
+	statuses := threadStatuses(comments)
+	var inline []models.Review
+	for _, comment := range comments {
+		review := base
+		review.CommentID = comment.ID
+		review.InReplyTo = comment.InReplyTo
+		review.CommentAuthor = username(comment.Author)
+		review.CommentText = comment.Message
+		review.CommentCreated = comment.Updated.Time
+		review.ReviewID = comment.ChangeMessageID
+		review.ReviewState = models.ReviewStateCommented
+		if state, ok := states[comment.ChangeMessageID]; ok {
+			review.ReviewState = state
+		}
+		review.ThreadStatus = statuses[comment.ID]
+
+		if comment.Path != patchSetLevelPath {
+			review.FilePath = comment.Path
+			review.LineNumber = comment.Line
+			if r := comment.Range; r != nil && r.EndLine > r.StartLine {
+				review.StartLine, review.LineNumber = r.StartLine, r.EndLine
+			}
+		}
+		if review.LineNumber > 0 && review.FilePath != commitMessagePath {
+			// The diff context comes from the patch set the comment was made on
+			files, err := patches.get(ctx, comment.PatchSet)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get patch set %d of change %d: %w", comment.PatchSet, change.Number, err)
+			}
+			if file := diff.FindFile(files, comment.Path); file != nil {
+				review.DiffContext = diff.Context(file.Hunks, review.LineNumber, comment.Side == SideParent, diffContextRadius)
+			}
+		}
+		inline = append(inline, review)
+	}
+
+	// Like the GitHub adapter, inline comments come before review bodies
+	return append(inline, bodies...), nil
+}
+
+// parseMessage splits a review message into the text written by the reviewer and the
+// Code-Review vote recorded in its header, e.g. "Patch Set 2: Code-Review+2"
+func parseMessage(message string) (string, int) {
+	lines := strings.Split(message, "\n")
+	vote := 0
+	if match := messageHeaderPattern.FindStringSubmatch(lines[0]); match != nil {
+		if votes := codeReviewPattern.FindStringSubmatch(match[1]); votes != nil {
+			vote, _ = strconv.Atoi(votes[1])
+		}
+		lines = lines[1:]
+	}
+
+	var text []string
+	for _, line := range lines {
+		if !commentCountPattern.MatchString(strings.TrimSpace(line)) {
+			text = append(text, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(text, "\n")), vote
+}
+
+// reviewState maps a Code-Review vote to the shared model
+func reviewState(vote int) models.ReviewState {
+	switch {
+	case vote > 0:
+		return models.ReviewStateApproved
+	case vote < 0:
+		return models.ReviewStateChangesRequested
+	default:
+		return models.ReviewStateCommented
+	}
+}
+
+// threadStatuses returns the status of the thread each comment belongs to, keyed by comment
+// ID. Threads are chains of replies; the latest comment of a thread decides whether it is
+// resolved. Comments must be ordered by time.
+func threadStatuses(comments []*Comment) map[string]models.ThreadStatus {
+	parents := make(map[string]string, len(comments))
+	for _, comment := range comments {
+		parents[comment.ID] = comment.InReplyTo
+	}
+	root := func(id string) string {
+		// Guard against cycles in malformed data
+		for range len(parents) {
+			parent, ok := parents[id]
+			if !ok || parent == "" {
+				break
+			}
+			id = parent
+		}
+		return id
+	}
+
+	unresolved := make(map[string]bool)
+	for _, comment := range comments {
+		unresolved[root(comment.ID)] = comment.Unresolved
+	}
+
+	statuses := make(map[string]models.ThreadStatus, len(comments))
+	for _, comment := range comments {
+		statuses[comment.ID] = models.ThreadStatusResolved
+		if unresolved[root(comment.ID)] {
+			statuses[comment.ID] = models.ThreadStatusActive
+		}
+	}
+	return statuses
+}
+
+// patchCache fetches the diff of each patch set of a change once, as comments on the same
+// patch set share it
+type patchCache struct {
+	client    ClientInterface
+	changeID  string
+	revisions map[int]string
+	files     map[int][]diff.File
+}
+
+// newPatchCache creates a cache for the patch sets of a change
+func newPatchCache(client ClientInterface, change *Change) *patchCache {
+	revisions := make(map[int]string, len(change.Revisions))
+	for sha, revision := range change.Revisions {
+		revisions[revision.Number] = sha
+	}
+	return &patchCache{
+		client:    client,
+		changeID:  change.ID,
+		revisions: revisions,
+		files:     make(map[int][]diff.File),
+	}
+}
+
+// get returns the files changed by a patch set, or nil if the patch set is unknown
+func (p *patchCache) get(ctx context.Context, patchSet int) ([]diff.File, error) {
+	if files, ok := p.files[patchSet]; ok {
+		return files, nil
+	}
+
+	var files []diff.File
+	if revision, ok := p.revisions[patchSet]; ok {
+		patch, err := p.client.GetPatch(ctx, p.changeID, revision)
+		if err != nil {
+			return nil, err
+		}
+		files = diff.Parse(patch)
+	}
+
+	p.files[patchSet] = files
+	return files, nil
+}
+
+// convertChange maps a Gerrit change to the shared model. Changes have no source branch, so
+// the head branch is the ref of the current patch set.
+func convertChange(change *Change, repo models.RepositoryRef, baseURL string) *models.PullRequest {
+	pullRequest := &models.PullRequest{
+		Number:     change.Number,
+		Repository: repo,
+		Provider:   models.ProviderGerrit,
+		Title:      change.Subject,
+		Author:     username(change.Owner),
+		URL:        fmt.Sprintf("%s/c/%s/+/%d", baseURL, change.Project, change.Number),
+		State:      "open",
+		Draft:      change.WorkInProgress,
+		CreatedAt:  change.Created.Time,
+		BaseBranch: change.Branch,
+		HeadSHA:    change.CurrentRevision,
+		Labels:     change.Hashtags,
+		Additions:  change.Insertions,
+		Deletions:  change.Deletions,
+	}
+	switch change.Status {
+	case ChangeStatusMerged:
+		pullRequest.State = "closed"
+		pullRequest.Merged = true
+		if change.Submitted != nil {
+			pullRequest.MergedAt = &change.Submitted.Time
+			pullRequest.ClosedAt = &change.Submitted.Time
+		}
+	case ChangeStatusAbandoned:
+		pullRequest.State = "closed"
+		pullRequest.ClosedAt = &change.Updated.Time
+	}
+	if revision, ok := change.Revisions[change.CurrentRevision]; ok {
+		pullRequest.HeadBranch = revision.Ref
+		for path := range revision.Files {
+			// Skip the commit message and merge list Gerrit lists as files
+			if !strings.HasPrefix(path, "/") {
+				pullRequest.ChangedFiles++
+			}
+		}
+	}
+	return pullRequest
+}
+
+// username returns the username of an account, falling back to its email and name, or an
+// empty string for missing accounts
+func username(account *Account) string {
+	switch {
+	case account == nil:
+		return ""
+	case account.Username != "":
+		return account.Username
+	case account.Email != "":
+		return account.Email
+	default:
+		return account.Name
+	}
+}
+
+// parseURL parses a repository URL into its identity, the URL of its server and the project
+// name. Project names may contain slashes, so the whole path is the project, unless the URL
+// is a project page (<server>/admin/repos/<project>), which also works for servers served
+// under a path prefix.
+func parseURL(repoURL string) (models.RepositoryRef, string, string, error) {
+	invalid := fmt.Errorf("invalid URL format: expected https://<host>/<project> or https://<host>/admin/repos/<project>")
+
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(repoURL), "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return models.RepositoryRef{}, "", "", invalid
+	}
+
+	baseURL := u.Scheme + "://" + u.Host
+	project := strings.Trim(u.Path, "/")
+	if prefix, name, ok := strings.Cut(u.Path, "/admin/repos/"); ok {
+		baseURL += strings.TrimSuffix(prefix, "/")
+		// Project pages may carry a section, e.g. ",branches"
+		project, _, _ = strings.Cut(name, ",")
+	}
+	project = strings.TrimSuffix(project, ".git")
+	if project == "" {
+		return models.RepositoryRef{}, "", "", invalid
+	}
+
+	ref := models.RepositoryRef{
+		Host: strings.ToLower(u.Host),
+		Name: project,
+		URL:  baseURL + "/" + project,
+	}
+	if idx := strings.LastIndex(project, "/"); idx >= 0 {
+		ref.Owner, ref.Name = project[:idx], project[idx+1:]
+	}
+	return ref, baseURL, project, nil
+}
+
+// ValidateURL checks that url identifies a Gerrit project
+func ValidateURL(url string) error {
+	_, _, _, err := parseURL(url)
+	return err
+}
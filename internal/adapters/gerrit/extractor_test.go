@@ -0,0 +1,184 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractReviews(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/changes/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'
+		[{
+			"id": "platform%2Fkernel~42", "project": "platform/kernel", "branch": "main", "hashtags": ["gpio"],
+			"subject": "Fix GPIO init", "status": "MERGED", "_number": 42,
+			"created": "2024-05-01 10:00:00.000000000", "updated": "2024-05-03 10:00:00.000000000",
+			"submitted": "2024-05-02 10:00:00.000000000", "insertions": 2, "deletions": 1,
+			"owner": {"_account_id": 1, "name": "Alice", "username": "alice"},
+			"current_revision": "sha2",
+			"revisions": {
+				"sha1": {"_number": 1, "ref": "refs/changes/42/42/1"},
+				"sha2": {"_number": 2, "ref": "refs/changes/42/42/2", "files": {"/COMMIT_MSG": {}, "drivers/gpio.c": {}}}
+			},
+			"messages": [
+				{"id": "m0", "author": {"username": "alice"}, "date": "2024-05-01 10:00:00.000000000", "message": "Uploaded patch set 1.", "tag": "autogenerated:gerrit:newPatchSet", "_revision_number": 1},
+				{"id": "m1", "author": {"username": "bob"}, "date": "2024-05-01 11:00:00.000000000", "message": "Patch Set 1: Code-Review-1\n\n(1 comment)", "_revision_number": 1},
+				{"id": "m2", "author": {"username": "alice"}, "date": "2024-05-01 12:00:00.000000000", "message": "Patch Set 1:\n\n(1 comment)\n\nFixed in the next patch set", "_revision_number": 1},
+				{"id": "m3", "author": {"name": "Carol"}, "date": "2024-05-01 13:00:00.000000000", "message": "Patch Set 2: Code-Review+2 Verified+1\n\n(2 comments)\n\nLooks good", "_revision_number": 2}
+			]
+		}]`)
+	})
+	mux.HandleFunc("/changes/{id}/comments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "platform/kernel~42", r.PathValue("id"))
+		fmt.Fprint(w, `)]}'
+		{
+			"drivers/gpio.c": [
+				{"id": "c1", "patch_set": 1, "line": 2, "message": "Why 2?", "updated": "2024-05-01 11:00:00.000000000",
+				 "author": {"username": "bob"}, "unresolved": true, "change_message_id": "m1"},
+				{"id": "c2", "patch_set": 1, "line": 2, "in_reply_to": "c1", "message": "Done", "updated": "2024-05-01 12:00:00.000000000",
+				 "author": {"username": "alice"}, "unresolved": false, "change_message_id": "m2"},
+				{"id": "c3", "patch_set": 2, "side": "PARENT", "line": 2, "range": {"start_line": 1, "end_line": 2},
+				 "message": "Was this wrong before?", "updated": "2024-05-01 13:00:00.000000000",
+				 "author": {"name": "Carol"}, "unresolved": true, "change_message_id": "m3"}
+			],
+			"/PATCHSET_LEVEL": [
+				{"id": "c4", "patch_set": 2, "message": "Ship it", "updated": "2024-05-01 13:00:00.000000000",
+				 "author": {"name": "Carol"}, "unresolved": false, "change_message_id": "m3"}
+			]
+		}`)
+	})
+	patches := map[string]string{
+		"sha1": "diff --git a/drivers/gpio.c b/drivers/gpio.c\n--- a/drivers/gpio.c\n+++ b/drivers/gpio.c\n@@ -1,3 +1,3 @@\n int a;\n-int b = 1;\n+int b = 2;\n int c;\n",
+		"sha2": "diff --git a/drivers/gpio.c b/drivers/gpio.c\n--- a/drivers/gpio.c\n+++ b/drivers/gpio.c\n@@ -1,3 +1,4 @@\n int a;\n-int b = 1;\n+int b = 3;\n+int d;\n int c;\n",
+	}
+	fetched := map[string]int{}
+	mux.HandleFunc("/changes/{id}/revisions/{revision}/patch", func(w http.ResponseWriter, r *http.Request) {
+		revision := r.PathValue("revision")
+		fetched[revision]++
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte(patches[revision])))
+	})
+	extractor := &Extractor{client: newFakeServer(t, mux, models.Credential{})}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://review.example.com/platform/kernel")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 6)
+
+	first := reviews[0]
+	assert.Equal(t, "c1", first.CommentID)
+	assert.Equal(t, "bob", first.CommentAuthor)
+	assert.Equal(t, "drivers/gpio.c", first.FilePath)
+	assert.Equal(t, 2, first.LineNumber)
+	assert.Equal(t, "int a;\nint b = 2;\nint c;", first.DiffContext, "patch set 1 is used for its comments")
+	assert.Equal(t, "m1", first.ReviewID)
+	assert.Equal(t, models.ReviewStateChangesRequested, first.ReviewState)
+	assert.Equal(t, models.ThreadStatusResolved, first.ThreadStatus, "the reply resolved the thread")
+	assert.Equal(t, models.ProviderGerrit, first.Provider)
+	assert.Equal(t, models.RepositoryRef{Host: "review.example.com", Owner: "platform", Name: "kernel", URL: "https://review.example.com/platform/kernel"}, first.Repo)
+
+	reply := reviews[1]
+	assert.Equal(t, "c1", reply.InReplyTo)
+	assert.Equal(t, models.ReviewStateCommented, reply.ReviewState)
+	assert.Equal(t, models.ThreadStatusResolved, reply.ThreadStatus)
+
+	parent := reviews[2]
+	assert.Equal(t, 1, parent.StartLine)
+	assert.Equal(t, 2, parent.LineNumber)
+	assert.Equal(t, "int a;\nint b = 1;\nint c;", parent.DiffContext, "comments on the parent use the old side")
+	assert.Equal(t, models.ReviewStateApproved, parent.ReviewState)
+	assert.Equal(t, models.ThreadStatusActive, parent.ThreadStatus)
+
+	patchSetLevel := reviews[3]
+	assert.Equal(t, "Ship it", patchSetLevel.CommentText)
+	assert.Empty(t, patchSetLevel.FilePath)
+	assert.Empty(t, patchSetLevel.DiffContext)
+
+	assert.Equal(t, "Fixed in the next patch set", reviews[4].CommentText)
+	assert.Equal(t, "m2", reviews[4].ReviewID)
+	assert.Equal(t, "Looks good", reviews[5].CommentText)
+	assert.Equal(t, "Carol", reviews[5].CommentAuthor)
+	assert.Equal(t, models.ReviewStateApproved, reviews[5].ReviewState)
+
+	assert.Equal(t, map[string]int{"sha1": 1, "sha2": 1}, fetched, "patches are fetched once per patch set")
+
+	pr := first.PullRequest
+	assert.Equal(t, "closed", pr.State)
+	assert.True(t, pr.Merged)
+	assert.Equal(t, "alice", pr.Author)
+	assert.Equal(t, "https://review.example.com/c/platform/kernel/+/42", pr.URL)
+	assert.Equal(t, "main", pr.BaseBranch)
+	assert.Equal(t, "refs/changes/42/42/2", pr.HeadBranch)
+	assert.Equal(t, "sha2", pr.HeadSHA)
+	assert.Equal(t, []string{"gpio"}, pr.Labels)
+	assert.Equal(t, 1, pr.ChangedFiles)
+	assert.Equal(t, pr.MergedAt, pr.ClosedAt)
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		text    string
+		vote    int
+	}{
+		{message: "Patch Set 3: Code-Review+2", vote: 2},
+		{message: "Patch Set 3: Verified-1 Code-Review-2\n\n(3 comments)\n\nPlease split this", text: "Please split this", vote: -2},
+		{message: "Patch Set 1:\n\n(1 comment)", text: ""},
+		{message: "Patch Set 1:\n\nQuick question", text: "Quick question"},
+		{message: "Change has been successfully merged", text: "Change has been successfully merged"},
+	}
+	for _, tt := range tests {
+		text, vote := parseMessage(tt.message)
+		assert.Equal(t, tt.text, text, tt.message)
+		assert.Equal(t, tt.vote, vote, tt.message)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		ref     models.RepositoryRef
+		baseURL string
+		project string
+		wantErr bool
+	}{
+		{
+			url:     "https://review.example.com/platform/kernel.git",
+			ref:     models.RepositoryRef{Host: "review.example.com", Owner: "platform", Name: "kernel", URL: "https://review.example.com/platform/kernel"},
+			baseURL: "https://review.example.com",
+			project: "platform/kernel",
+		},
+		{
+			url:     "https://Review.Example.com/tools",
+			ref:     models.RepositoryRef{Host: "review.example.com", Name: "tools", URL: "https://Review.Example.com/tools"},
+			baseURL: "https://Review.Example.com",
+			project: "tools",
+		},
+		{
+			url:     "https://example.com/gerrit/admin/repos/platform/kernel,branches",
+			ref:     models.RepositoryRef{Host: "example.com", Owner: "platform", Name: "kernel", URL: "https://example.com/gerrit/platform/kernel"},
+			baseURL: "https://example.com/gerrit",
+			project: "platform/kernel",
+		},
+		{url: "https://review.example.com", wantErr: true},
+		{url: "review.example.com/kernel", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ref, baseURL, project, err := parseURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, ValidateURL(tt.url))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ref, ref)
+			assert.Equal(t, tt.baseURL, baseURL)
+			assert.Equal(t, tt.project, project)
+		})
+	}
+}
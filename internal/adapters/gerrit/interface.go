@@ -0,0 +1,13 @@
+package gerrit
+
+import "context"
+
+// ClientInterface defines the interface for Gerrit API operations
+type ClientInterface interface {
+	GetChanges(ctx context.Context, project string) ([]*Change, error)
+	GetComments(ctx context.Context, changeID string) ([]*Comment, error)
+	GetPatch(ctx context.Context, changeID, revision string) (string, error)
+	CountChanges(ctx context.Context, project string) (int, *Change, error)
+	GetSelf(ctx context.Context) (*Account, error)
+	GetProject(ctx context.Context, project string) (*Project, error)
+}
@@ -0,0 +1,36 @@
+package gerrit
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// authMethods lists the credential methods Gerrit accepts
+var authMethods = []models.AuthMethod{models.AuthBearer, models.AuthBasic, models.AuthAppPassword}
+
+func init() {
+	core.Register(core.ProviderFactory{
+		Name:        models.ProviderGerrit,
+		Description: "Gerrit Code Review",
+		Example:     "https://<host>/<project>",
+		AuthMethods: authMethods,
+		MatchURL:    matchURL,
+		ValidateURL: ValidateURL,
+		New: func(config *models.Config, source credentials.Source) core.Extractor {
+			return NewExtractor(source)
+		},
+	})
+}
+
+// matchURL recognizes Google's hosted Gerrit instances and project pages of any instance
+func matchURL(repoURL string) bool {
+	if strings.HasSuffix(credentials.Host(repoURL), ".googlesource.com") {
+		return true
+	}
+	u, err := url.Parse(repoURL)
+	return err == nil && strings.Contains(u.Path, "/admin/repos/")
+}
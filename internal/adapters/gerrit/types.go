@@ -0,0 +1,125 @@
+package gerrit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// timestampLayout is the format of Gerrit timestamps, which are always in UTC
+const timestampLayout = "2006-01-02 15:04:05.999999999"
+
+// Timestamp is a Gerrit timestamp such as "2024-05-01 10:00:00.000000000"
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Gerrit timestamp
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(timestampLayout, value)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Account is a Gerrit user. Which fields are set depends on the server's configuration.
+type Account struct {
+	AccountID int    `json:"_account_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+// Change statuses reported by Gerrit
+const (
+	ChangeStatusNew       = "NEW"
+	ChangeStatusMerged    = "MERGED"
+	ChangeStatusAbandoned = "ABANDONED"
+)
+
+// Revision is a patch set of a change
+type Revision struct {
+	Number   int                 `json:"_number"`
+	Ref      string              `json:"ref"`
+	Created  Timestamp           `json:"created"`
+	Uploader *Account            `json:"uploader"`
+	Files    map[string]struct{} `json:"files"`
+}
+
+// ChangeMessage is a message posted on a change, such as a review with its votes
+type ChangeMessage struct {
+	ID             string    `json:"id"`
+	Author         *Account  `json:"author"`
+	Date           Timestamp `json:"date"`
+	Message        string    `json:"message"`
+	Tag            string    `json:"tag"`
+	RevisionNumber int       `json:"_revision_number"`
+}
+
+// Change is a change as returned by the changes query endpoint. Revisions are keyed by
+// commit SHA.
+type Change struct {
+	ID              string               `json:"id"`
+	Project         string               `json:"project"`
+	Branch          string               `json:"branch"`
+	Topic           string               `json:"topic"`
+	Hashtags        []string             `json:"hashtags"`
+	Subject         string               `json:"subject"`
+	Status          string               `json:"status"`
+	Created         Timestamp            `json:"created"`
+	Updated         Timestamp            `json:"updated"`
+	Submitted       *Timestamp           `json:"submitted"`
+	Insertions      int                  `json:"insertions"`
+	Deletions       int                  `json:"deletions"`
+	Number          int                  `json:"_number"`
+	Owner           *Account             `json:"owner"`
+	WorkInProgress  bool                 `json:"work_in_progress"`
+	CurrentRevision string               `json:"current_revision"`
+	Revisions       map[string]*Revision `json:"revisions"`
+	Messages        []*ChangeMessage     `json:"messages"`
+	MoreChanges     bool                 `json:"_more_changes"`
+}
+
+// Comment sides reported by Gerrit; comments without a side are on the revision
+const (
+	SideParent   = "PARENT"
+	SideRevision = "REVISION"
+)
+
+// CommentRange is the characters a comment is anchored to
+type CommentRange struct {
+	StartLine      int `json:"start_line"`
+	StartCharacter int `json:"start_character"`
+	EndLine        int `json:"end_line"`
+	EndCharacter   int `json:"end_character"`
+}
+
+// Comment is a published inline comment. Comments are keyed by path when listed, so Path is
+// filled in by the client. Unresolved is set on every comment; the last comment of a thread
+// decides whether the thread is resolved.
+type Comment struct {
+	ID              string        `json:"id"`
+	Path            string        `json:"path"`
+	PatchSet        int           `json:"patch_set"`
+	Side            string        `json:"side"`
+	Line            int           `json:"line"`
+	Range           *CommentRange `json:"range"`
+	InReplyTo       string        `json:"in_reply_to"`
+	Message         string        `json:"message"`
+	Updated         Timestamp     `json:"updated"`
+	Author          *Account      `json:"author"`
+	Unresolved      bool          `json:"unresolved"`
+	ChangeMessageID string        `json:"change_message_id"`
+}
+
+// Project is a Gerrit project
+type Project struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
@@ -0,0 +1,43 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "review-bot"}`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"full_name": "acme/api", "private": true}`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Count", "120")
+		fmt.Fprint(w, `[{"number": 120}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls/120.diff", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "diff --git a/a.go b/a.go\n")
+	})
+	extractor := &Extractor{provider: models.ProviderGitea, client: newFakeServer(t, mux, models.Credential{Token: "token"})}
+
+	result, err := extractor.Check(context.Background(), "https://git.example.com/acme/api")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryCheck{
+		URL:      "https://git.example.com/acme/api",
+		Provider: models.ProviderGitea,
+		Checks: []models.Check{
+			{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+			{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+			{Name: "pull requests", Status: models.CheckOK, Detail: "120 pull requests"},
+			{Name: "diff", Status: models.CheckOK, Detail: "fetched diff of #120 (25 bytes)"},
+		},
+		EstimatedCalls: 3 + 120*callsPerPullRequest,
+	}, result)
+}
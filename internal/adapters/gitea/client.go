@@ -0,0 +1,193 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// pageSize is the number of items requested per page; Gitea caps it at 50 by default
+const pageSize = 50
+
+// nextLinkPattern matches the next page in a Link header
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Client calls the Gitea API of one instance
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	credential models.Credential
+}
+
+// NewClient creates a client for the API at baseURL (e.g. https://gitea.example.com/api/v1),
+// authenticating with the credential, or unauthenticated if it is empty
+func NewClient(baseURL string, credential models.Credential) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		credential: credential,
+	}
+}
+
+// GetPullRequests fetches pull requests for a repository
+func (c *Client) GetPullRequests(ctx context.Context, owner, repo string) ([]*PullRequest, error) {
+	var allPRs []*PullRequest
+	query := url.Values{"state": {"all"}, "sort": {"newest"}}
+	if err := c.getPages(ctx, c.repoPath(owner, repo, "pulls"), query, func(data []byte) error {
+		var prs []*PullRequest
+		if err := json.Unmarshal(data, &prs); err != nil {
+			return err
+		}
+		allPRs = append(allPRs, prs...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	return allPRs, nil
+}
+
+// GetPullRequestReviews fetches reviews for a pull request
+func (c *Client) GetPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]*Review, error) {
+	var allReviews []*Review
+	if err := c.getPages(ctx, c.repoPath(owner, repo, "pulls", strconv.Itoa(number), "reviews"), nil, func(data []byte) error {
+		var reviews []*Review
+		if err := json.Unmarshal(data, &reviews); err != nil {
+			return err
+		}
+		allReviews = append(allReviews, reviews...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list pull request reviews: %w", err)
+	}
+
+	return allReviews, nil
+}
+
+// GetReviewComments fetches the inline comments of a review
+func (c *Client) GetReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]*ReviewComment, error) {
+	var comments []*ReviewComment
+	path := c.repoPath(owner, repo, "pulls", strconv.Itoa(number), "reviews", strconv.FormatInt(reviewID, 10), "comments")
+	if _, err := c.get(ctx, c.baseURL+path, &comments); err != nil {
+		return nil, fmt.Errorf("failed to list review comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// CountPullRequests returns the number of pull requests in a repository and the most
+// recently created one, using a single request
+func (c *Client) CountPullRequests(ctx context.Context, owner, repo string) (int, *PullRequest, error) {
+	query := url.Values{"state": {"all"}, "sort": {"newest"}, "limit": {"1"}}
+	var prs []*PullRequest
+	header, err := c.get(ctx, c.baseURL+c.repoPath(owner, repo, "pulls")+"?"+query.Encode(), &prs)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	if len(prs) == 0 {
+		return 0, nil, nil
+	}
+
+	count := len(prs)
+	if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil && total > count {
+		count = total
+	}
+	return count, prs[0], nil
+}
+
+// GetPullRequestDiff fetches the diff for a pull request
+func (c *Client) GetPullRequestDiff(ctx context.Context, owner, repo string, number int) (string, error) {
+	data, _, err := c.fetch(ctx, c.baseURL+c.repoPath(owner, repo, "pulls", strconv.Itoa(number)+".diff"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get pull request diff: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// GetAuthenticatedUser fetches the user the token belongs to
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*User, error) {
+	var user User
+	if _, err := c.get(ctx, c.baseURL+"/user", &user); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetRepository fetches a repository
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*Repository, error) {
+	var repository Repository
+	if _, err := c.get(ctx, c.baseURL+c.repoPath(owner, repo), &repository); err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	return &repository, nil
+}
+
+// repoPath builds the API path of a repository resource
+func (c *Client) repoPath(owner, repo string, elements ...string) string {
+	path := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+	for _, element := range elements {
+		path += "/" + url.PathEscape(element)
+	}
+	return path
+}
+
+// getPages fetches every page of a list endpoint, following the Link headers, and passes
+// each page's body to handle
+func (c *Client) getPages(ctx context.Context, path string, query url.Values, handle func([]byte) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("limit", strconv.Itoa(pageSize))
+	next := c.baseURL + path + "?" + query.Encode()
+
+	for next != "" {
+		data, header, err := c.fetch(ctx, next)
+		if err != nil {
+			return err
+		}
+		if err := handle(data); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		next = ""
+		if match := nextLinkPattern.FindStringSubmatch(header.Get("Link")); match != nil {
+			next = match[1]
+		}
+	}
+
+	return nil
+}
+
+// get fetches a URL and decodes its JSON response into v
+func (c *Client) get(ctx context.Context, url string, v any) (http.Header, error) {
+	data, header, err := c.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return header, nil
+}
+
+// fetch performs an authenticated GET request and returns the response body
+func (c *Client) fetch(ctx context.Context, url string) ([]byte, http.Header, error) {
+	return httpclient.Get(ctx, c.httpClient, url, c.authorize, httpclient.JSONMessage)
+}
+
+// authorize adds the credential to a request: tokens use Gitea's token scheme, logins basic auth
+func (c *Client) authorize(req *http.Request) {
+	httpclient.Authorize(req, c.credential, "token")
+}
@@ -0,0 +1,68 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeServer starts a local Gitea API stand-in and returns a client talking to it
+func newFakeServer(t *testing.T, mux *http.ServeMux, credential models.Credential) *Client {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/api/v1", credential)
+}
+
+func TestClient_GetPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "all", r.URL.Query().Get("state"))
+		assert.Equal(t, "50", r.URL.Query().Get("limit"))
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"number": 1, "title": "First"}]`)
+			return
+		}
+		w.Header().Set("Link", `<http://`+r.Host+`/api/v1/repos/acme/api/pulls?limit=50&page=2&state=all>; rel="next",<http://`+r.Host+`/api/v1/repos/acme/api/pulls?limit=50&page=2&state=all>; rel="last"`)
+		fmt.Fprint(w, `[{"number": 2, "title": "Second"}]`)
+	})
+	client := newFakeServer(t, mux, models.Credential{Token: "secret"})
+
+	prs, err := client.GetPullRequests(context.Background(), "acme", "api")
+	assert.NoError(t, err)
+	assert.Len(t, prs, 2)
+	assert.Equal(t, 2, prs[0].Number)
+	assert.Equal(t, "First", prs[1].Title)
+}
+
+func TestClient_CountPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("limit"))
+		w.Header().Set("X-Total-Count", "412")
+		fmt.Fprint(w, `[{"number": 412}]`)
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	count, latest, err := client.CountPullRequests(context.Background(), "acme", "api")
+	assert.NoError(t, err)
+	assert.Equal(t, 412, count)
+	assert.Equal(t, 412, latest.Number)
+}
+
+func TestClient_GetPullRequestDiff(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls/7.diff", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "diff --git a/main.go b/main.go\n")
+	})
+	client := newFakeServer(t, mux, models.Credential{})
+
+	rawDiff, err := client.GetPullRequestDiff(context.Background(), "acme", "api", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "diff --git a/main.go b/main.go\n", rawDiff)
+}
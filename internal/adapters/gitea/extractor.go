@@ -0,0 +1,257 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/internal/httpclient"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// diffContextRadius is the number of lines around a commented line kept as diff context
+const diffContextRadius = 3
+
+// Extractor implements the core.Extractor interface for Gitea and Forgejo, whose APIs are
+// compatible. Instances are self-hosted, so the API is derived from each repository URL.
+type Extractor struct {
+	provider models.Provider
+	// client, if set, is used for every repository instead of clients built from credentials
+	client  ClientInterface
+	clients *httpclient.Clients[ClientInterface]
+}
+
+// NewExtractor creates a new extractor for provider (gitea or forgejo), authenticating
+// with the credentials of each repository
+func NewExtractor(provider models.Provider, source credentials.Source) *Extractor {
+	return &Extractor{
+		provider: provider,
+		clients: httpclient.NewClients(provider, authMethods, source, func(baseURL string, credential models.Credential) ClientInterface {
+			return NewClient(baseURL, credential)
+		}),
+	}
+}
+
+// clientFor returns the client for a repository and whether it is authenticated
+func (e *Extractor) clientFor(repoURL, baseURL string) (ClientInterface, bool, error) {
+	if e.client != nil {
+		return e.client, true, nil
+	}
+	return e.clients.For(repoURL, baseURL)
+}
+
+// ExtractReviews implements the core.Extractor interface
+func (e *Extractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	return core.Collect(e.StreamReviews(ctx, repoURL))
+}
+
+// StreamReviews implements the core.StreamExtractor interface, yielding the reviews of each
+// pull request once they have been fetched
+func (e *Extractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		ref, baseURL, err := parseURL(repoURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("invalid %s URL: %w", e.provider, err))
+			return
+		}
+		client, _, err := e.clientFor(repoURL, baseURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to authenticate: %w", err))
+			return
+		}
+
+		// Get all pull requests
+		prs, err := client.GetPullRequests(ctx, ref.Owner, ref.Name)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to get pull requests: %w", err))
+			return
+		}
+
+		// Process each pull request
+		for _, pr := range prs {
+			reviews, err := e.pullRequestReviews(ctx, client, ref, pr)
+			if err != nil {
+				yield(models.Review{}, err)
+				return
+			}
+			for _, review := range reviews {
+				if !yield(review, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pullRequestReviews extracts the reviews of a pull request and their inline comments
+func (e *Extractor) pullRequestReviews(ctx context.Context, client ClientInterface, ref models.RepositoryRef, pr *PullRequest) ([]models.Review, error) {
+	reviews, err := client.GetPullRequestReviews(ctx, ref.Owner, ref.Name, pr.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews for PR #%d: %w", pr.Number, err)
+	}
+	pullRequest := e.convertPullRequest(pr, ref)
+
+	base := models.Review{
+		PRID:        pr.Number,
+		PRTitle:     pr.Title,
+		PRAuthor:    login(pr.User),
+		Repository:  ref.Name,
+		Repo:        ref,
+		Provider:    e.provider,
+		PullRequest: pullRequest,
+	}
+
+	var comments, bodies []models.Review
+	for _, review := range reviews {
+		state := reviewState(review)
+		if state == "" {
+			continue
+		}
+
+		// Inline comments are only listed per review
+		if review.CommentsCount > 0 {
+			reviewComments, err := client.GetReviewComments(ctx, ref.Owner, ref.Name, pr.Number, review.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get comments of review %d on PR #%d: %w", review.ID, pr.Number, err)
+			}
+			for _, comment := range reviewComments {
+				comments = append(comments, convertComment(base, comment, review.ID, state))
+			}
+		}
+
+		if review.Body != "" {
+			reviewModel := base
+			// Reviews and comments are numbered independently, so review IDs get a prefix
+			reviewModel.CommentID = fmt.Sprintf("review-%d", review.ID)
+			reviewModel.CommentAuthor = login(review.User)
+			reviewModel.CommentText = review.Body
+			reviewModel.CommentCreated = review.SubmittedAt
+			reviewModel.ReviewID = fmt.Sprintf("%d", review.ID)
+			reviewModel.ReviewState = state
+			bodies = append(bodies, reviewModel)
+		}
+	}
+
+	// Like the GitHub adapter, inline comments come before review bodies
+	return append(comments, bodies...), nil
+}
+
+// convertComment maps an inline review comment to the shared model
+func convertComment(base models.Review, comment *ReviewComment, reviewID int64, state models.ReviewState) models.Review {
+	line, old := comment.Position, false
+	if line == 0 {
+		line, old = comment.OriginalPosition, true
+	}
+
+	review := base
+	review.CommentID = fmt.Sprintf("%d", comment.ID)
+	review.CommentAuthor = login(comment.User)
+	review.CommentText = comment.Body
+	review.CommentCreated = comment.CreatedAt
+	review.ReviewID = fmt.Sprintf("%d", reviewID)
+	review.ReviewState = state
+	review.FilePath = comment.Path
+	review.LineNumber = line
+	review.DiffContext = diff.Context(diff.ParsePatch(comment.DiffHunk), line, old, diffContextRadius)
+	return review
+}
+
+// reviewState maps a Gitea review state to the shared model, or returns an empty state for
+// entries that are not submitted reviews: pending drafts and review requests
+func reviewState(review *Review) models.ReviewState {
+	if review.Dismissed {
+		return models.ReviewStateDismissed
+	}
+
+	switch review.State {
+	case ReviewStateApproved:
+		return models.ReviewStateApproved
+	case ReviewStateRequestChanges:
+		return models.ReviewStateChangesRequested
+	case ReviewStateComment:
+		return models.ReviewStateCommented
+	default:
+		return ""
+	}
+}
+
+// convertPullRequest maps a Gitea pull request to the shared model
+func (e *Extractor) convertPullRequest(pr *PullRequest, repo models.RepositoryRef) *models.PullRequest {
+	pullRequest := &models.PullRequest{
+		Number:       pr.Number,
+		Repository:   repo,
+		Provider:     e.provider,
+		Title:        pr.Title,
+		Author:       login(pr.User),
+		URL:          pr.HTMLURL,
+		State:        pr.State,
+		Merged:       pr.Merged || pr.MergedAt != nil,
+		Draft:        pr.Draft,
+		CreatedAt:    pr.CreatedAt,
+		MergedAt:     pr.MergedAt,
+		ClosedAt:     pr.ClosedAt,
+		Additions:    pr.Additions,
+		Deletions:    pr.Deletions,
+		ChangedFiles: pr.ChangedFiles,
+	}
+	if pr.Base != nil {
+		pullRequest.BaseBranch = pr.Base.Ref
+	}
+	if pr.Head != nil {
+		pullRequest.HeadBranch = pr.Head.Ref
+		pullRequest.HeadSHA = pr.Head.SHA
+	}
+	for _, label := range pr.Labels {
+		pullRequest.Labels = append(pullRequest.Labels, label.Name)
+	}
+	return pullRequest
+}
+
+// login returns the login of a user, or an empty string for deleted users
+func login(user *User) string {
+	if user == nil {
+		return ""
+	}
+	return user.Login
+}
+
+// parseURL parses a repository URL into its identity and the API base URL of its instance.
+// Instances may be served under a path prefix, so the last two path segments are the
+// owner and repository.
+func parseURL(repoURL string) (models.RepositoryRef, string, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(repoURL), "/"))
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return models.RepositoryRef{}, "", fmt.Errorf("invalid URL format: expected https://<host>/<owner>/<repo>")
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || segments[len(segments)-2] == "" || segments[len(segments)-1] == "" {
+		return models.RepositoryRef{}, "", fmt.Errorf("invalid URL format: expected https://<host>/<owner>/<repo>")
+	}
+
+	owner := segments[len(segments)-2]
+	name := strings.TrimSuffix(segments[len(segments)-1], ".git")
+	root := u.Scheme + "://" + u.Host
+	if prefix := strings.Join(segments[:len(segments)-2], "/"); prefix != "" {
+		root += "/" + prefix
+	}
+
+	ref := models.RepositoryRef{
+		Host:  strings.ToLower(u.Host),
+		Owner: owner,
+		Name:  name,
+		URL:   root + "/" + owner + "/" + name,
+	}
+	return ref, root + "/api/v1", nil
+}
+
+// ValidateURL checks that url identifies a repository on a Gitea or Forgejo instance
+func ValidateURL(url string) error {
+	_, _, err := parseURL(url)
+	return err
+}
@@ -0,0 +1,126 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractReviews(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{
+			"number": 7, "title": "Add retries", "user": {"login": "alice"},
+			"html_url": "https://git.example.com/acme/api/pulls/7", "state": "closed", "merged": true,
+			"created_at": "2024-05-01T10:00:00Z", "merged_at": "2024-05-02T10:00:00Z",
+			"base": {"ref": "main"}, "head": {"ref": "retries", "sha": "abc123"},
+			"labels": [{"name": "enhancement"}], "additions": 10, "deletions": 2, "changed_files": 1
+		}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls/7/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": 1, "user": {"login": "bob"}, "state": "REQUEST_CHANGES", "body": "A few issues", "comments_count": 2, "submitted_at": "2024-05-01T11:00:00Z"},
+			{"id": 2, "user": {"login": "carol"}, "state": "REQUEST_REVIEW", "body": ""},
+			{"id": 3, "user": {"login": "bob"}, "state": "APPROVED", "body": "", "comments_count": 0, "submitted_at": "2024-05-01T12:00:00Z"}
+		]`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls/7/reviews/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": 10, "pull_request_review_id": 1, "user": {"login": "bob"}, "body": "Retry forever?", "path": "client.go",
+			 "position": 12, "diff_hunk": "@@ -10,3 +10,4 @@ func call() {\n \tfor {\n-\t\treturn do()\n+\t\tif err := do(); err == nil {\n+\t\t\treturn nil", "created_at": "2024-05-01T11:00:00Z"},
+			{"id": 11, "pull_request_review_id": 1, "user": null, "body": "Why remove this?", "path": "client.go",
+			 "original_position": 11, "diff_hunk": "@@ -10,3 +10,4 @@ func call() {\n \tfor {\n-\t\treturn do()", "created_at": "2024-05-01T11:01:00Z"}
+		]`)
+	})
+	extractor := &Extractor{provider: models.ProviderForgejo, client: newFakeServer(t, mux, models.Credential{})}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://git.example.com/acme/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 3)
+
+	inline := reviews[0]
+	assert.Equal(t, "10", inline.CommentID)
+	assert.Equal(t, "bob", inline.CommentAuthor)
+	assert.Equal(t, "client.go", inline.FilePath)
+	assert.Equal(t, 12, inline.LineNumber)
+	assert.Equal(t, "for {\nif err := do(); err == nil {\nreturn nil", inline.DiffContext)
+	assert.Equal(t, "1", inline.ReviewID)
+	assert.Equal(t, models.ReviewStateChangesRequested, inline.ReviewState)
+	assert.Equal(t, models.ProviderForgejo, inline.Provider)
+	assert.Equal(t, models.RepositoryRef{Host: "git.example.com", Owner: "acme", Name: "api", URL: "https://git.example.com/acme/api"}, inline.Repo)
+
+	assert.Equal(t, 11, reviews[1].LineNumber, "comments on removed lines use the original position")
+	assert.Equal(t, "for {\nreturn do()", reviews[1].DiffContext)
+	assert.Empty(t, reviews[1].CommentAuthor)
+
+	assert.Equal(t, "A few issues", reviews[2].CommentText)
+	assert.Empty(t, reviews[2].FilePath)
+	assert.Equal(t, "review-1", reviews[2].CommentID)
+	assert.Equal(t, "1", reviews[2].ReviewID)
+
+	pr := inline.PullRequest
+	assert.True(t, pr.Merged)
+	assert.Equal(t, "alice", pr.Author)
+	assert.Equal(t, "retries", pr.HeadBranch)
+	assert.Equal(t, []string{"enhancement"}, pr.Labels)
+	assert.Equal(t, 10, pr.Additions)
+}
+
+func TestExtractReviews_ReviewAndCommentShareID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"number": 7, "title": "Add retries"}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls/7/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 5, "state": "COMMENT", "body": "Summary", "comments_count": 1}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/acme/api/pulls/7/reviews/5/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 5, "pull_request_review_id": 5, "body": "Nit", "path": "client.go", "position": 3}]`)
+	})
+	extractor := &Extractor{provider: models.ProviderGitea, client: newFakeServer(t, mux, models.Credential{})}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://git.example.com/acme/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, "5", reviews[0].CommentID)
+	assert.Equal(t, "review-5", reviews[1].CommentID, "review bodies do not collide with comments")
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		ref     models.RepositoryRef
+		baseURL string
+		wantErr bool
+	}{
+		{
+			url:     "https://Git.Example.com/acme/api.git",
+			ref:     models.RepositoryRef{Host: "git.example.com", Owner: "acme", Name: "api", URL: "https://Git.Example.com/acme/api"},
+			baseURL: "https://Git.Example.com/api/v1",
+		},
+		{
+			url:     "http://example.com/gitea/acme/api/",
+			ref:     models.RepositoryRef{Host: "example.com", Owner: "acme", Name: "api", URL: "http://example.com/gitea/acme/api"},
+			baseURL: "http://example.com/gitea/api/v1",
+		},
+		{url: "https://codeberg.org/acme", wantErr: true},
+		{url: "codeberg.org/acme/api", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ref, baseURL, err := parseURL(tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, ValidateURL(tt.url))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ref, ref)
+			assert.Equal(t, tt.baseURL, baseURL)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package gitea
+
+import "context"
+
+// ClientInterface defines the interface for Gitea API operations
+type ClientInterface interface {
+	GetPullRequests(ctx context.Context, owner, repo string) ([]*PullRequest, error)
+	GetPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]*Review, error)
+	GetReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]*ReviewComment, error)
+	CountPullRequests(ctx context.Context, owner, repo string) (int, *PullRequest, error)
+	GetPullRequestDiff(ctx context.Context, owner, repo string, number int) (string, error)
+	GetAuthenticatedUser(ctx context.Context) (*User, error)
+	GetRepository(ctx context.Context, owner, repo string) (*Repository, error)
+}
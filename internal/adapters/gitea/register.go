@@ -0,0 +1,37 @@
+package gitea
+
+import (
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// authMethods lists the credential methods Gitea and Forgejo accept
+var authMethods = []models.AuthMethod{models.AuthBearer, models.AuthBasic, models.AuthAppPassword}
+
+func init() {
+	// The public instances identify the platform; self-hosted ones need an explicit provider
+	for _, platform := range []struct {
+		provider   models.Provider
+		name       string
+		publicHost string
+	}{
+		{models.ProviderGitea, "Gitea", "gitea.com"},
+		{models.ProviderForgejo, "Forgejo", "codeberg.org"},
+	} {
+		provider, publicHost := platform.provider, platform.publicHost
+		core.Register(core.ProviderFactory{
+			Name:        provider,
+			Description: platform.name + " (" + publicHost + " and self-hosted)",
+			Example:     "https://" + publicHost + "/<owner>/<repo>",
+			AuthMethods: authMethods,
+			MatchURL: func(url string) bool {
+				return credentials.Host(url) == publicHost
+			},
+			ValidateURL: ValidateURL,
+			New: func(config *models.Config, source credentials.Source) core.Extractor {
+				return NewExtractor(provider, source)
+			},
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package gitea
+
+import "time"
+
+// User is a Gitea account
+type User struct {
+	Login string `json:"login"`
+}
+
+// Label is a pull request label
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Branch is the base or head of a pull request
+type Branch struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// PullRequest is a pull request as returned by the pulls endpoints
+type PullRequest struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	User         *User      `json:"user"`
+	HTMLURL      string     `json:"html_url"`
+	State        string     `json:"state"`
+	Draft        bool       `json:"draft"`
+	Merged       bool       `json:"merged"`
+	CreatedAt    time.Time  `json:"created_at"`
+	MergedAt     *time.Time `json:"merged_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	Base         *Branch    `json:"base"`
+	Head         *Branch    `json:"head"`
+	Labels       []Label    `json:"labels"`
+	Additions    int        `json:"additions"`
+	Deletions    int        `json:"deletions"`
+	ChangedFiles int        `json:"changed_files"`
+}
+
+// Review states reported by Gitea and Forgejo
+const (
+	ReviewStateApproved       = "APPROVED"
+	ReviewStatePending        = "PENDING"
+	ReviewStateComment        = "COMMENT"
+	ReviewStateRequestChanges = "REQUEST_CHANGES"
+	ReviewStateRequestReview  = "REQUEST_REVIEW"
+)
+
+// Review is a submitted pull request review
+type Review struct {
+	ID            int64     `json:"id"`
+	User          *User     `json:"user"`
+	State         string    `json:"state"`
+	Body          string    `json:"body"`
+	CommentsCount int       `json:"comments_count"`
+	Dismissed     bool      `json:"dismissed"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+}
+
+// ReviewComment is an inline comment of a review. Position is the commented line on the
+// new side of the diff and OriginalPosition the line on the old side; only one is set.
+type ReviewComment struct {
+	ID               int64     `json:"id"`
+	ReviewID         int64     `json:"pull_request_review_id"`
+	User             *User     `json:"user"`
+	Body             string    `json:"body"`
+	Path             string    `json:"path"`
+	Position         int       `json:"position"`
+	OriginalPosition int       `json:"original_position"`
+	CommitID         string    `json:"commit_id"`
+	DiffHunk         string    `json:"diff_hunk"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Repository is a Gitea repository
+type Repository struct {
+	FullName string `json:"full_name"`
+	Private  bool   `json:"private"`
+}
@@ -0,0 +1,105 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// addressDetector determines whether inline comments were followed by commits
+// that changed the commented lines
+type addressDetector struct {
+	client  ClientInterface
+	owner   string
+	repo    string
+	commits []*github.RepositoryCommit
+	// changes caches compared files keyed by "base...head"
+	changes map[string][]*github.CommitFile
+}
+
+// newAddressDetector creates a detector for a single pull request
+func newAddressDetector(ctx context.Context, client ClientInterface, owner, repo string, number int) (*addressDetector, error) {
+	commits, err := client.GetPullRequestCommits(ctx, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return &addressDetector{
+		client:  client,
+		owner:   owner,
+		repo:    repo,
+		commits: commits,
+		changes: make(map[string][]*github.CommitFile),
+	}, nil
+}
+
+// annotate records on the review whether the comment was addressed and by which commit
+func (d *addressDetector) annotate(ctx context.Context, comment *github.PullRequestComment, review *models.Review) error {
+	// Comments on removed lines, or without a line anchor, have nothing to follow up on
+	if comment.GetPath() == "" || comment.GetOriginalCommitID() == "" || comment.GetOriginalLine() <= 0 ||
+		comment.GetSide() == "LEFT" {
+		return nil
+	}
+
+	sha, err := d.fixingCommit(ctx, comment)
+	if err != nil {
+		return err
+	}
+
+	addressed := sha != ""
+	review.Addressed = &addressed
+	review.AddressedBy = sha
+	return nil
+}
+
+// fixingCommit returns the first commit after the comment that changed the commented lines
+func (d *addressDetector) fixingCommit(ctx context.Context, comment *github.PullRequestComment) (string, error) {
+	base := comment.GetOriginalCommitID()
+	end := comment.GetOriginalLine()
+	start := comment.GetOriginalStartLine()
+	if start <= 0 {
+		start = end
+	}
+
+	for _, commit := range d.commits {
+		if commit.GetSHA() == base || !commit.GetCommit().GetCommitter().GetDate().After(comment.GetCreatedAt()) {
+			continue
+		}
+
+		// Comparing against the commented commit keeps the line numbers stable
+		files, err := d.compare(ctx, base, commit.GetSHA())
+		if err != nil {
+			return "", err
+		}
+
+		for _, file := range files {
+			if file.GetFilename() != comment.GetPath() && file.GetPreviousFilename() != comment.GetPath() {
+				continue
+			}
+			if file.GetStatus() == "removed" || diff.Touches(diff.ParsePatch(file.GetPatch()), start, end) {
+				return commit.GetSHA(), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// compare returns the files changed between base and head, caching the result
+func (d *addressDetector) compare(ctx context.Context, base, head string) ([]*github.CommitFile, error) {
+	key := base + "..." + head
+	if files, ok := d.changes[key]; ok {
+		return files, nil
+	}
+
+	comparison, err := d.client.CompareCommits(ctx, d.owner, d.repo, base, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %w", base, head, err)
+	}
+
+	d.changes[key] = comparison.Files
+	return comparison.Files, nil
+}
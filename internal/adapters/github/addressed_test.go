@@ -0,0 +1,189 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func commitAt(sha string, date time.Time) *github.RepositoryCommit {
+	return &github.RepositoryCommit{
+		SHA: github.String(sha),
+		Commit: &github.Commit{
+			Committer: &github.CommitAuthor{Date: &date},
+		},
+	}
+}
+
+func TestAddressDetector_Annotate(t *testing.T) {
+	commented := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	comment := &github.PullRequestComment{
+		ID:               github.Int64(1),
+		Path:             github.String("main.go"),
+		OriginalCommitID: github.String("aaa"),
+		OriginalLine:     github.Int(10),
+		CreatedAt:        &commented,
+	}
+
+	commits := []*github.RepositoryCommit{
+		commitAt("aaa", commented.Add(-time.Hour)),
+		commitAt("bbb", commented.Add(time.Hour)),
+		commitAt("ccc", commented.Add(2*time.Hour)),
+	}
+
+	tests := []struct {
+		name          string
+		comment       *github.PullRequestComment
+		comparisons   map[string]*github.CommitsComparison
+		wantAddressed *bool
+		wantSHA       string
+	}{
+		{
+			name:    "lines changed by a later commit",
+			comment: comment,
+			comparisons: map[string]*github.CommitsComparison{
+				"aaa...bbb": {Files: []*github.CommitFile{
+					{Filename: github.String("other.go"), Patch: github.String("@@ -10 +10 @@\n-a\n+b")},
+				}},
+				"aaa...ccc": {Files: []*github.CommitFile{
+					{Filename: github.String("main.go"), Patch: github.String("@@ -9,3 +9,3 @@\n ctx\n-old\n+new\n ctx")},
+				}},
+			},
+			wantAddressed: github.Bool(true),
+			wantSHA:       "ccc",
+		},
+		{
+			name:    "file changed elsewhere",
+			comment: comment,
+			comparisons: map[string]*github.CommitsComparison{
+				"aaa...ccc": {Files: []*github.CommitFile{
+					{Filename: github.String("main.go"), Patch: github.String("@@ -50 +50 @@\n-old\n+new")},
+				}},
+			},
+			wantAddressed: github.Bool(false),
+		},
+		{
+			name:    "file renamed and changed",
+			comment: comment,
+			comparisons: map[string]*github.CommitsComparison{
+				"aaa...bbb": {Files: []*github.CommitFile{
+					{
+						Filename:         github.String("cmd/main.go"),
+						PreviousFilename: github.String("main.go"),
+						Patch:            github.String("@@ -10 +10 @@\n-old\n+new"),
+					},
+				}},
+			},
+			wantAddressed: github.Bool(true),
+			wantSHA:       "bbb",
+		},
+		{
+			name:    "file removed",
+			comment: comment,
+			comparisons: map[string]*github.CommitsComparison{
+				"aaa...bbb": {Files: []*github.CommitFile{
+					{Filename: github.String("main.go"), Status: github.String("removed")},
+				}},
+			},
+			wantAddressed: github.Bool(true),
+			wantSHA:       "bbb",
+		},
+		{
+			name: "comment on removed line is skipped",
+			comment: &github.PullRequestComment{
+				Path:             github.String("main.go"),
+				OriginalCommitID: github.String("aaa"),
+				OriginalLine:     github.Int(10),
+				Side:             github.String("LEFT"),
+				CreatedAt:        &commented,
+			},
+			wantAddressed: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{commits: commits, comparisons: tt.comparisons}
+			detector, err := newAddressDetector(context.Background(), client, "test", "repo", 1)
+			assert.NoError(t, err)
+
+			var review models.Review
+			err = detector.annotate(context.Background(), tt.comment, &review)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAddressed, review.Addressed)
+			assert.Equal(t, tt.wantSHA, review.AddressedBy)
+		})
+	}
+}
+
+func TestAddressDetector_CompareError(t *testing.T) {
+	commented := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	client := &MockClient{
+		commits:    []*github.RepositoryCommit{commitAt("bbb", commented.Add(time.Hour))},
+		compareErr: assert.AnError,
+	}
+
+	detector, err := newAddressDetector(context.Background(), client, "test", "repo", 1)
+	assert.NoError(t, err)
+
+	err = detector.annotate(context.Background(), &github.PullRequestComment{
+		Path:             github.String("main.go"),
+		OriginalCommitID: github.String("aaa"),
+		OriginalLine:     github.Int(10),
+		CreatedAt:        &commented,
+	}, &models.Review{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compare aaa...bbb")
+}
+
+func TestExtractReviews_DetectAddressed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"number":1,"title":"Test PR","user":{"login":"author"}}]`)
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id":10,"path":"main.go","line":2,"original_line":2,"original_commit_id":"aaa",
+			 "created_at":"2024-06-01T12:00:00Z","body":"Rename this","user":{"login":"reviewer"}},
+			{"id":11,"path":"main.go","line":5,"original_line":5,"original_commit_id":"aaa",
+			 "created_at":"2024-06-01T12:00:00Z","body":"Fine as is","user":{"login":"reviewer"}}
+		]`)
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n")
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"sha":"aaa","commit":{"committer":{"date":"2024-06-01T11:00:00Z"}}},
+			{"sha":"bbb","commit":{"committer":{"date":"2024-06-01T13:00:00Z"}}}
+		]`)
+	})
+	mux.HandleFunc("/repos/test/repo/compare/aaa...bbb", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"files":[{"filename":"main.go","status":"modified","patch":"@@ -1,3 +1,3 @@\n a\n-c\n+d\n e"}]}`)
+	})
+
+	extractor := &Extractor{
+		client:  newFakeServer(t, mux),
+		options: Options{DetectAddressed: true},
+	}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+
+	assert.Equal(t, github.Bool(true), reviews[0].Addressed)
+	assert.Equal(t, "bbb", reviews[0].AddressedBy)
+
+	assert.Equal(t, github.Bool(false), reviews[1].Addressed)
+	assert.Empty(t, reviews[1].AddressedBy)
+}
@@ -0,0 +1,230 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+const (
+	// appJWTLifetime is how long app JWTs are valid; GitHub accepts at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// appClockSkew backdates JWTs to tolerate clocks running ahead of GitHub's
+	appClockSkew = time.Minute
+	// tokenRefreshMargin is how long before expiry installation tokens are replaced
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// installationToken is a cached installation access token
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// appAuth authenticates as a GitHub App: it signs JWTs with the app's private key and
+// exchanges them for installation tokens, which it caches and refreshes before they expire
+type appAuth struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	// client calls the app endpoints, authenticated with a JWT
+	client *github.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]installationToken
+}
+
+// newAppAuth creates the GitHub App authentication for a credential of an app registered on
+// the server whose REST API is at baseURL
+func newAppAuth(baseURL string, credential models.Credential) (*appAuth, error) {
+	if credential.AppID == 0 {
+		return nil, fmt.Errorf("GitHub App credential has no app_id")
+	}
+
+	key, err := parsePrivateKey(credential.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+
+	auth := &appAuth{
+		appID:          credential.AppID,
+		installationID: credential.InstallationID,
+		key:            key,
+		now:            time.Now,
+		installations:  make(map[string]int64),
+		tokens:         make(map[int64]installationToken),
+	}
+	auth.client = newGitHubClient(baseURL, &http.Client{Transport: &jwtTransport{auth: auth}})
+	return auth, nil
+}
+
+// newAppClient creates a client authenticating with the installation covering a repository
+func newAppClient(auth *appAuth, owner, repo string) *Client {
+	httpClient := &http.Client{Transport: &installationTransport{auth: auth, owner: owner, repo: repo}}
+	return &Client{client: &githubClient{client: newGitHubClient(auth.client.BaseURL.String(), httpClient)}}
+}
+
+// parsePrivateKey parses a PEM encoded RSA private key in PKCS #1 or PKCS #8 form
+func parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// jwt returns a JWT identifying the app, signed with RS256
+func (a *appAuth) jwt() (string, error) {
+	now := a.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// token returns an installation token for the installation covering a repository,
+// creating a new one if there is none or the cached one is about to expire
+func (a *appAuth) token(ctx context.Context, owner, repo string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	id, err := a.installation(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	if cached, ok := a.tokens[id]; ok && a.now().Add(tokenRefreshMargin).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	token, _, err := a.client.Apps.CreateInstallationToken(ctx, id, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+
+	cached := installationToken{token: token.GetToken(), expiresAt: token.GetExpiresAt()}
+	a.tokens[id] = cached
+	return cached.token, nil
+}
+
+// installation returns the ID of the installation covering a repository, or the owner's
+// account if repo is empty. Installations belong to an account, so the result is cached per owner.
+func (a *appAuth) installation(ctx context.Context, owner, repo string) (int64, error) {
+	if a.installationID != 0 {
+		return a.installationID, nil
+	}
+	if id, ok := a.installations[owner]; ok {
+		return id, nil
+	}
+
+	var installation *github.Installation
+	var err error
+	switch {
+	case repo != "":
+		installation, _, err = a.client.Apps.FindRepositoryInstallation(ctx, owner, repo)
+	default:
+		// Without a repository, as when discovering repositories, look up the account's installation
+		installation, _, err = a.client.Apps.FindOrganizationInstallation(ctx, owner)
+		if err != nil {
+			installation, _, err = a.client.Apps.FindUserInstallation(ctx, owner)
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find GitHub App installation for %s: %w", path.Join(owner, repo), err)
+	}
+
+	a.installations[owner] = installation.GetID()
+	return installation.GetID(), nil
+}
+
+// jwtTransport authenticates requests to the app endpoints with a JWT
+type jwtTransport struct {
+	auth *appAuth
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.auth.jwt()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	return transport(t.base).RoundTrip(req)
+}
+
+// installationTransport authenticates requests for a repository with an installation token
+type installationTransport struct {
+	auth        *appAuth
+	owner, repo string
+	base        http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.auth.token(req.Context(), t.owner, t.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	return transport(t.base).RoundTrip(req)
+}
+
+// transport returns base, or the default transport if it is nil
+func transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		return http.DefaultTransport
+	}
+	return base
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyJWT checks an app JWT's signature and returns its claims
+func verifyJWT(t *testing.T, key *rsa.PublicKey, jwt string) map[string]any {
+	parts := strings.Split(jwt, ".")
+	if !assert.Len(t, parts, 3) {
+		return nil
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, err)
+	var claims map[string]any
+	assert.NoError(t, json.Unmarshal(payload, &claims))
+	return claims
+}
+
+func TestAppAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	var lookups, exchanges int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/api/installation", func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		claims := verifyJWT(t, &key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		assert.Equal(t, "42", claims["iss"])
+		assert.Equal(t, float64(now.Add(-time.Minute).Unix()), claims["iat"])
+		assert.Equal(t, float64(now.Add(9*time.Minute).Unix()), claims["exp"])
+		fmt.Fprint(w, `{"id": 7}`)
+	})
+	mux.HandleFunc("/app/installations/7/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		verifyJWT(t, &key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		exchanges++
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, exchanges, now.Add(time.Hour).Format(time.RFC3339))
+	})
+	var seen []string
+	mux.HandleFunc("/repos/acme/", func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `[]`)
+	})
+	server := newFakeServer(t, mux)
+
+	auth, err := newAppAuth("https://api.github.com/", models.Credential{Method: models.AuthGitHubApp, AppID: 42, PrivateKey: privateKey})
+	assert.NoError(t, err)
+	auth.now = func() time.Time { return now }
+	auth.client.BaseURL = server.client.BaseURL
+
+	newClient := func(repo string) *Client {
+		client := newAppClient(auth, "acme", repo)
+		client.client.(*githubClient).client.BaseURL, _ = url.Parse(server.client.BaseURL.String())
+		return client
+	}
+	api, web := newClient("api"), newClient("web")
+
+	ctx := context.Background()
+	_, err = api.GetPullRequests(ctx, "acme", "api")
+	assert.NoError(t, err)
+	_, err = web.GetPullRequests(ctx, "acme", "web")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lookups, "installations are cached per owner")
+	assert.Equal(t, 1, exchanges, "tokens are cached until shortly before they expire")
+
+	// Refresh the token once it is about to expire
+	now = now.Add(56 * time.Minute)
+	_, err = api.GetPullRequests(ctx, "acme", "api")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, exchanges)
+	assert.Equal(t, []string{"token ghs_1", "token ghs_1", "token ghs_2"}, seen)
+}
+
+func TestNewAppAuth_Errors(t *testing.T) {
+	_, err := newAppAuth("https://api.github.com/", models.Credential{Method: models.AuthGitHubApp, PrivateKey: "key"})
+	assert.ErrorContains(t, err, "no app_id")
+
+	_, err = newAppAuth("https://api.github.com/", models.Credential{Method: models.AuthGitHubApp, AppID: 42, PrivateKey: "not a key"})
+	assert.ErrorContains(t, err, "invalid GitHub App private key: no PEM block found")
+}
+
+func TestParsePrivateKey_PKCS8(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	parsed, err := parsePrivateKey(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	assert.NoError(t, err)
+	assert.True(t, key.Equal(parsed))
+}
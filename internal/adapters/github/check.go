@@ -0,0 +1,203 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// callsPerPullRequest is the number of requests needed to extract one pull request:
+// its comments, reviews and diff
+const callsPerPullRequest = 3
+
+// estimate is the approximate cost of a full extraction of a repository
+type estimate struct {
+	// calls is the number of REST requests, counted against the core rate limit
+	calls int
+	// queryCost is the cost of the GraphQL queries in points of the GraphQL rate limit
+	queryCost int
+}
+
+// Check implements the core.Checker interface. It verifies that the token can read the
+// repository, list its pull requests and fetch a diff, and compares the requests a full
+// extraction needs with the remaining rate limit budget.
+func (e *Extractor) Check(ctx context.Context, repoURL string) (*models.RepositoryCheck, error) {
+	ref, baseURL, err := parseGitHubURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub URL: %w", err)
+	}
+	owner, repo := ref.Owner, ref.Name
+
+	result := &models.RepositoryCheck{URL: repoURL, Provider: models.ProviderGitHub}
+	add := func(name string, status models.CheckStatus, format string, args ...any) {
+		result.Checks = append(result.Checks, models.Check{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	client, method, err := e.clientFor(repoURL, baseURL, owner, repo)
+	if err != nil {
+		add("authentication", models.CheckFailed, "%v", err)
+		return result, nil
+	}
+
+	// Authentication
+	switch method {
+	case "":
+		add("authentication", models.CheckWarning, "no credentials configured; using unauthenticated access")
+	case models.AuthGitHubApp:
+		// Installation tokens cannot read the authenticated user; any request exchanges a token
+		if _, err := client.GetRateLimit(ctx); err != nil {
+			add("authentication", models.CheckFailed, "%v", err)
+		} else {
+			add("authentication", models.CheckOK, "authenticated as GitHub App installation")
+		}
+	default:
+		if user, err := client.GetAuthenticatedUser(ctx); err != nil {
+			add("authentication", models.CheckFailed, "%v", err)
+		} else {
+			add("authentication", models.CheckOK, "authenticated as %s", user.GetLogin())
+		}
+	}
+
+	// Repository visibility
+	repository, err := client.GetRepository(ctx, owner, repo)
+	if err != nil {
+		add("repository", models.CheckFailed, "%v", err)
+		add("pull requests", models.CheckSkipped, "repository is not accessible")
+		add("diff", models.CheckSkipped, "repository is not accessible")
+	} else {
+		add("repository", models.CheckOK, "%s (%s)", repository.GetFullName(), repository.GetVisibility())
+
+		// Listing pull requests and fetching a diff
+		count, latest, err := client.CountPullRequests(ctx, owner, repo)
+		switch {
+		case err != nil:
+			add("pull requests", models.CheckFailed, "%v", err)
+			add("diff", models.CheckSkipped, "pull requests could not be listed")
+		case latest == nil:
+			add("pull requests", models.CheckOK, "no pull requests")
+			add("diff", models.CheckSkipped, "no pull requests")
+		default:
+			add("pull requests", models.CheckOK, "%d pull requests", count)
+			cost := e.estimate(count)
+			result.EstimatedCalls = cost.calls
+			result.EstimatedQueryCost = cost.queryCost
+
+			if rawDiff, err := client.GetPullRequestDiff(ctx, owner, repo, latest.GetNumber()); err != nil {
+				add("diff", models.CheckFailed, "#%d: %v", latest.GetNumber(), err)
+			} else {
+				add("diff", models.CheckOK, "fetched diff of #%d (%d bytes)", latest.GetNumber(), len(rawDiff))
+			}
+		}
+	}
+
+	// Rate limit budgets
+	rate, err := client.GetRateLimit(ctx)
+	if err != nil {
+		add("rate limit", models.CheckFailed, "%v", err)
+		return result, nil
+	}
+	result.RateLimit = convertRate(rate)
+	if result.EstimatedCalls > rate.Remaining {
+		add("rate limit", models.CheckWarning, "%d of %d requests remaining, about %d needed; the run will exhaust the budget",
+			rate.Remaining, rate.Limit, result.EstimatedCalls)
+	} else {
+		add("rate limit", models.CheckOK, "%d of %d requests remaining, about %d needed", rate.Remaining, rate.Limit, result.EstimatedCalls)
+	}
+
+	if !e.options.GraphQL {
+		return result, nil
+	}
+	// GraphQL queries are counted in points against a budget of their own
+	queryRate, err := client.GetGraphQLRateLimit(ctx)
+	if err != nil {
+		add("graphql rate limit", models.CheckFailed, "%v", err)
+		return result, nil
+	}
+	result.QueryRateLimit = convertRate(queryRate)
+	if result.EstimatedQueryCost > queryRate.Remaining {
+		add("graphql rate limit", models.CheckWarning, "%d of %d points remaining, about %d needed; the run will exhaust the budget",
+			queryRate.Remaining, queryRate.Limit, result.EstimatedQueryCost)
+	} else {
+		add("graphql rate limit", models.CheckOK, "%d of %d points remaining, about %d needed",
+			queryRate.Remaining, queryRate.Limit, result.EstimatedQueryCost)
+	}
+
+	return result, nil
+}
+
+// estimate approximates the cost of a full extraction of count pull requests
+func (e *Extractor) estimate(count int) estimate {
+	perPR := callsPerPullRequest
+	if e.options.GraphQL {
+		// Reviews and comments come with the list; only the diff is fetched per pull request
+		perPR = 1
+	}
+	if e.options.DetectAddressed {
+		// Listing commits, plus at least one comparison
+		perPR += 2
+	}
+
+	if e.options.GraphQL {
+		// Page sizes are tuned so that each list query costs about graphqlTargetCost points
+		queries := (count + graphqlDefaultPageSize - 1) / graphqlDefaultPageSize
+		return estimate{calls: count * perPR, queryCost: queries * graphqlTargetCost}
+	}
+	listPages := (count + 99) / 100
+	return estimate{calls: listPages + count*perPR}
+}
+
+// convertRate maps a GitHub rate limit to the model
+func convertRate(rate *github.Rate) *models.RateLimit {
+	return &models.RateLimit{
+		Limit:     rate.Limit,
+		Remaining: rate.Remaining,
+		Reset:     rate.Reset.Time,
+	}
+}
+
+// Plan implements the core.Planner interface. It counts the pull requests of a repository
+// with a single list call and estimates the requests a full extraction needs.
+func (e *Extractor) Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error) {
+	ref, baseURL, err := parseGitHubURL(repoURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub URL: %w", err)
+	}
+	owner, repo := ref.Owner, ref.Name
+
+	client, _, err := e.clientFor(repoURL, baseURL, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	count, _, err := client.CountPullRequests(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := e.estimate(count)
+	plan := &models.RepositoryPlan{
+		URL:                repoURL,
+		Provider:           models.ProviderGitHub,
+		PullRequests:       count,
+		EstimatedCalls:     cost.calls,
+		EstimatedQueryCost: cost.queryCost,
+	}
+
+	// Reading the rate limits does not count against them
+	rate, err := client.GetRateLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan.RateLimit = convertRate(rate)
+	if e.options.GraphQL {
+		queryRate, err := client.GetGraphQLRateLimit(ctx)
+		if err != nil {
+			return nil, err
+		}
+		plan.QueryRateLimit = convertRate(queryRate)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,156 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	reset := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	client := &MockClient{
+		user:       &github.User{Login: github.String("octocat")},
+		repository: &github.Repository{FullName: github.String("test/repo"), Visibility: github.String("private")},
+		prs:        []*github.PullRequest{{Number: github.Int(42)}, {Number: github.Int(41)}},
+		diff:       "diff --git a/a.go b/a.go\n",
+		rate:       &github.Rate{Limit: 5000, Remaining: 4000, Reset: github.Timestamp{Time: reset}},
+	}
+	extractor := &Extractor{client: client, authenticated: true}
+
+	result, err := extractor.Check(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryCheck{
+		URL:      "https://github.com/test/repo",
+		Provider: models.ProviderGitHub,
+		Checks: []models.Check{
+			{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as octocat"},
+			{Name: "repository", Status: models.CheckOK, Detail: "test/repo (private)"},
+			{Name: "pull requests", Status: models.CheckOK, Detail: "2 pull requests"},
+			{Name: "diff", Status: models.CheckOK, Detail: "fetched diff of #42 (25 bytes)"},
+			{Name: "rate limit", Status: models.CheckOK, Detail: "4000 of 5000 requests remaining, about 7 needed"},
+		},
+		RateLimit:      &models.RateLimit{Limit: 5000, Remaining: 4000, Reset: reset},
+		EstimatedCalls: 7,
+	}, result)
+	assert.False(t, result.Failed())
+
+	// Addressed detection needs more requests than are left
+	extractor.options.DetectAddressed = true
+	client.rate.Remaining = 5
+	result, err = extractor.Check(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, 11, result.EstimatedCalls)
+	assert.Equal(t, models.Check{
+		Name:   "rate limit",
+		Status: models.CheckWarning,
+		Detail: "5 of 5000 requests remaining, about 11 needed; the run will exhaust the budget",
+	}, result.Checks[4])
+	assert.False(t, result.Failed())
+}
+
+func TestCheck_Failures(t *testing.T) {
+	client := &MockClient{
+		repoErr: errors.New("404 Not Found"),
+		rateErr: errors.New("401 Bad credentials"),
+	}
+	extractor := &Extractor{client: client}
+
+	result, err := extractor.Check(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.True(t, result.Failed())
+	assert.Equal(t, []models.Check{
+		{Name: "authentication", Status: models.CheckWarning, Detail: "no credentials configured; using unauthenticated access"},
+		{Name: "repository", Status: models.CheckFailed, Detail: "404 Not Found"},
+		{Name: "pull requests", Status: models.CheckSkipped, Detail: "repository is not accessible"},
+		{Name: "diff", Status: models.CheckSkipped, Detail: "repository is not accessible"},
+		{Name: "rate limit", Status: models.CheckFailed, Detail: "401 Bad credentials"},
+	}, result.Checks)
+
+	_, err = extractor.Check(context.Background(), "https://github.com/test")
+	assert.ErrorContains(t, err, "invalid GitHub URL")
+}
+
+func TestEstimate(t *testing.T) {
+	assert.Equal(t, estimate{calls: 2 + 150*3}, (&Extractor{}).estimate(150))
+	assert.Equal(t, estimate{calls: 2 + 150*5}, (&Extractor{options: Options{DetectAddressed: true}}).estimate(150))
+	assert.Equal(t, estimate{calls: 150, queryCost: 8 * graphqlTargetCost}, (&Extractor{options: Options{GraphQL: true}}).estimate(150),
+		"only diffs are fetched over REST; the list queries cost points")
+}
+
+func TestCheck_GraphQL(t *testing.T) {
+	reset := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	client := &MockClient{
+		user:       &github.User{Login: github.String("octocat")},
+		repository: &github.Repository{FullName: github.String("test/repo"), Visibility: github.String("private")},
+		prs:        []*github.PullRequest{{Number: github.Int(42)}, {Number: github.Int(41)}},
+		diff:       "diff --git a/a.go b/a.go\n",
+		rate:       &github.Rate{Limit: 5000, Remaining: 4000, Reset: github.Timestamp{Time: reset}},
+		queryRate:  &github.Rate{Limit: 5000, Remaining: 5, Reset: github.Timestamp{Time: reset}},
+	}
+	extractor := &Extractor{client: client, authenticated: true, options: Options{GraphQL: true}}
+
+	result, err := extractor.Check(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.EstimatedCalls)
+	assert.Equal(t, graphqlTargetCost, result.EstimatedQueryCost)
+	assert.Equal(t, &models.RateLimit{Limit: 5000, Remaining: 5, Reset: reset}, result.QueryRateLimit)
+	assert.Equal(t, []models.Check{
+		{Name: "rate limit", Status: models.CheckOK, Detail: "4000 of 5000 requests remaining, about 2 needed"},
+		{Name: "graphql rate limit", Status: models.CheckWarning, Detail: "5 of 5000 points remaining, about 10 needed; the run will exhaust the budget"},
+	}, result.Checks[4:])
+
+	plan, err := extractor.Plan(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, plan.EstimatedCalls)
+	assert.Equal(t, graphqlTargetCost, plan.EstimatedQueryCost)
+	assert.Equal(t, &models.RateLimit{Limit: 5000, Remaining: 5, Reset: reset}, plan.QueryRateLimit)
+}
+
+func TestPlan(t *testing.T) {
+	reset := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	client := &MockClient{
+		prs:  []*github.PullRequest{{Number: github.Int(42)}, {Number: github.Int(41)}},
+		rate: &github.Rate{Limit: 5000, Remaining: 4000, Reset: github.Timestamp{Time: reset}},
+	}
+	extractor := &Extractor{client: client}
+
+	plan, err := extractor.Plan(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryPlan{
+		URL:            "https://github.com/test/repo",
+		Provider:       models.ProviderGitHub,
+		PullRequests:   2,
+		EstimatedCalls: 7,
+		RateLimit:      &models.RateLimit{Limit: 5000, Remaining: 4000, Reset: reset},
+	}, plan)
+
+	client.prErr = errors.New("404 Not Found")
+	_, err = extractor.Plan(context.Background(), "https://github.com/test/repo")
+	assert.ErrorContains(t, err, "404 Not Found")
+
+	_, err = extractor.Plan(context.Background(), "https://github.com/test")
+	assert.ErrorContains(t, err, "invalid GitHub URL")
+}
+
+func TestCountPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("per_page"))
+		assert.Equal(t, "all", r.URL.Query().Get("state"))
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=2&per_page=1>; rel="next", <`+r.URL.Path+`?page=57&per_page=1>; rel="last"`)
+		fmt.Fprint(w, `[{"number": 57}]`)
+	})
+	client := newFakeServer(t, mux)
+
+	count, latest, err := client.CountPullRequests(context.Background(), "test", "repo")
+	assert.NoError(t, err)
+	assert.Equal(t, 57, count)
+	assert.Equal(t, 57, latest.GetNumber())
+}
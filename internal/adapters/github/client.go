@@ -3,8 +3,11 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/pkg/models"
 	"golang.org/x/oauth2"
 )
 
@@ -13,19 +16,36 @@ type githubClient struct {
 	client *github.Client
 }
 
-// NewClient creates a new GitHub client
-func NewClient(token string) *Client {
-	var client *github.Client
-	if token != "" {
+// NewClient creates a new GitHub client for the REST API at baseURL (https://api.github.com/
+// or https://<host>/api/v3/ on GitHub Enterprise), authenticating with the credential, or
+// unauthenticated if it is empty
+func NewClient(baseURL string, credential models.Credential) *Client {
+	var httpClient *http.Client
+	switch {
+	case credential.IsZero():
+		// Unauthenticated
+	case credential.AuthMethod() == models.AuthBasic || credential.AuthMethod() == models.AuthAppPassword:
+		transport := &github.BasicAuthTransport{
+			Username: credential.Username,
+			Password: credential.Password,
+		}
+		httpClient = transport.Client()
+	default:
 		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
+			&oauth2.Token{AccessToken: credential.Token},
 		)
-		tc := oauth2.NewClient(context.Background(), ts)
-		client = github.NewClient(tc)
-	} else {
-		client = github.NewClient(nil)
+		httpClient = oauth2.NewClient(context.Background(), ts)
+	}
+	return &Client{client: &githubClient{client: newGitHubClient(baseURL, httpClient)}}
+}
+
+// newGitHubClient creates a go-github client calling the REST API at baseURL
+func newGitHubClient(baseURL string, httpClient *http.Client) *github.Client {
+	client := github.NewClient(httpClient)
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		client.BaseURL = u
 	}
-	return &Client{client: &githubClient{client: client}}
+	return client
 }
 
 // GetPullRequests fetches pull requests for a repository
@@ -117,6 +137,168 @@ func (c *githubClient) GetPullRequestDiff(ctx context.Context, owner, repo strin
 	return diff, nil
 }
 
+// GetPullRequestCommits fetches the commits of a pull request in chronological order
+func (c *githubClient) GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]*github.RepositoryCommit, error) {
+	var allCommits []*github.RepositoryCommit
+	opts := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	for {
+		commits, resp, err := c.client.PullRequests.ListCommits(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull request commits: %w", err)
+		}
+
+		allCommits = append(allCommits, commits...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allCommits, nil
+}
+
+// CompareCommits fetches the changes between two commits
+func (c *githubClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, error) {
+	comparison, _, err := c.client.Repositories.CompareCommits(ctx, owner, repo, base, head, &github.ListOptions{
+		PerPage: 100,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare commits: %w", err)
+	}
+
+	return comparison, nil
+}
+
+// GetAuthenticatedUser fetches the user the token belongs to
+func (c *githubClient) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
+	user, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetRepository fetches a repository
+func (c *githubClient) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	repository, _, err := c.client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	return repository, nil
+}
+
+// CountPullRequests returns the number of pull requests in a repository and the most
+// recently created one, using a single request
+func (c *githubClient) CountPullRequests(ctx context.Context, owner, repo string) (int, *github.PullRequest, error) {
+	prs, resp, err := c.client.PullRequests.List(ctx, owner, repo, &github.PullRequestListOptions{
+		State:       "all",
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	if len(prs) == 0 {
+		return 0, nil, nil
+	}
+
+	// With one pull request per page, the last page number is the total count
+	count := len(prs)
+	if resp.LastPage > count {
+		count = resp.LastPage
+	}
+	return count, prs[0], nil
+}
+
+// GetRateLimit fetches the core API rate limit of the token
+func (c *githubClient) GetRateLimit(ctx context.Context) (*github.Rate, error) {
+	limits, _, err := c.client.RateLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate limit: %w", err)
+	}
+	if limits.Core == nil {
+		return nil, fmt.Errorf("failed to get rate limit: no core rate limit in response")
+	}
+
+	return limits.Core, nil
+}
+
+// GetGraphQLRateLimit fetches the GraphQL API rate limit of the token, counted in points
+// rather than requests
+func (c *githubClient) GetGraphQLRateLimit(ctx context.Context) (*github.Rate, error) {
+	limits, _, err := c.client.RateLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GraphQL rate limit: %w", err)
+	}
+	if limits.GraphQL == nil {
+		return nil, fmt.Errorf("failed to get GraphQL rate limit: no graphql rate limit in response")
+	}
+
+	return limits.GraphQL, nil
+}
+
+// ListRepositories fetches the repositories of an organization, or of a user if there is
+// no organization of that name
+func (c *githubClient) ListRepositories(ctx context.Context, owner string) ([]*github.Repository, error) {
+	var allRepos []*github.Repository
+	orgOpts := &github.RepositoryListByOrgOptions{
+		Type:        "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		repos, resp, err := c.client.Repositories.ListByOrg(ctx, owner, orgOpts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound && len(allRepos) == 0 {
+				return c.listUserRepositories(ctx, owner)
+			}
+			return nil, fmt.Errorf("failed to list repositories: %w", err)
+		}
+
+		allRepos = append(allRepos, repos...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		orgOpts.Page = resp.NextPage
+	}
+
+	return allRepos, nil
+}
+
+// listUserRepositories fetches the repositories owned by a user
+func (c *githubClient) listUserRepositories(ctx context.Context, user string) ([]*github.Repository, error) {
+	var allRepos []*github.Repository
+	opts := &github.RepositoryListOptions{
+		Type:        "owner",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		repos, resp, err := c.client.Repositories.List(ctx, user, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories: %w", err)
+		}
+
+		allRepos = append(allRepos, repos...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allRepos, nil
+}
+
 // Client wraps the GitHub API client
 type Client struct {
 	client ClientInterface
@@ -141,3 +323,43 @@ func (c *Client) GetPullRequestReviews(ctx context.Context, owner, repo string,
 func (c *Client) GetPullRequestDiff(ctx context.Context, owner, repo string, number int) (string, error) {
 	return c.client.GetPullRequestDiff(ctx, owner, repo, number)
 }
+
+// GetPullRequestCommits fetches the commits of a pull request in chronological order
+func (c *Client) GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]*github.RepositoryCommit, error) {
+	return c.client.GetPullRequestCommits(ctx, owner, repo, number)
+}
+
+// CompareCommits fetches the changes between two commits
+func (c *Client) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, error) {
+	return c.client.CompareCommits(ctx, owner, repo, base, head)
+}
+
+// GetAuthenticatedUser fetches the user the token belongs to
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
+	return c.client.GetAuthenticatedUser(ctx)
+}
+
+// GetRepository fetches a repository
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	return c.client.GetRepository(ctx, owner, repo)
+}
+
+// CountPullRequests returns the number of pull requests in a repository and the most recently created one
+func (c *Client) CountPullRequests(ctx context.Context, owner, repo string) (int, *github.PullRequest, error) {
+	return c.client.CountPullRequests(ctx, owner, repo)
+}
+
+// GetRateLimit fetches the core API rate limit of the token
+func (c *Client) GetRateLimit(ctx context.Context) (*github.Rate, error) {
+	return c.client.GetRateLimit(ctx)
+}
+
+// GetGraphQLRateLimit fetches the GraphQL API rate limit of the token
+func (c *Client) GetGraphQLRateLimit(ctx context.Context) (*github.Rate, error) {
+	return c.client.GetGraphQLRateLimit(ctx)
+}
+
+// ListRepositories fetches the repositories of an organization, or of a user if there is no organization of that name
+func (c *Client) ListRepositories(ctx context.Context, owner string) ([]*github.Repository, error) {
+	return c.client.ListRepositories(ctx, owner)
+}
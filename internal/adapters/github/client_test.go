@@ -2,6 +2,10 @@ package github
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -47,6 +51,83 @@ func (m *MockGitHubClient) GetPullRequestDiff(ctx context.Context, owner, repo s
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockGitHubClient) GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]*github.RepositoryCommit, error) {
+	args := m.Called(ctx, owner, repo, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*github.RepositoryCommit), args.Error(1)
+}
+
+func (m *MockGitHubClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, error) {
+	args := m.Called(ctx, owner, repo, base, head)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*github.CommitsComparison), args.Error(1)
+}
+
+func (m *MockGitHubClient) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*github.User), args.Error(1)
+}
+
+func (m *MockGitHubClient) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	args := m.Called(ctx, owner, repo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*github.Repository), args.Error(1)
+}
+
+func (m *MockGitHubClient) CountPullRequests(ctx context.Context, owner, repo string) (int, *github.PullRequest, error) {
+	args := m.Called(ctx, owner, repo)
+	if args.Get(1) == nil {
+		return args.Int(0), nil, args.Error(2)
+	}
+	return args.Int(0), args.Get(1).(*github.PullRequest), args.Error(2)
+}
+
+func (m *MockGitHubClient) GetRateLimit(ctx context.Context) (*github.Rate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*github.Rate), args.Error(1)
+}
+
+func (m *MockGitHubClient) GetGraphQLRateLimit(ctx context.Context) (*github.Rate, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*github.Rate), args.Error(1)
+}
+
+func (m *MockGitHubClient) ListRepositories(ctx context.Context, owner string) ([]*github.Repository, error) {
+	args := m.Called(ctx, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*github.Repository), args.Error(1)
+}
+
+// newFakeServer starts a local GitHub API stand-in and returns a client talking to it
+func newFakeServer(t *testing.T, mux *http.ServeMux) *githubClient {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(server.Client())
+	baseURL, err := url.Parse(server.URL + "/")
+	assert.NoError(t, err)
+	client.BaseURL = baseURL
+
+	return &githubClient{client: client}
+}
+
 func TestGetPullRequests(t *testing.T) {
 	mockClient := new(MockGitHubClient)
 	client := &Client{client: mockClient}
@@ -169,3 +250,75 @@ func TestGetPullRequestDiff(t *testing.T) {
 	_, err = client.GetPullRequestDiff(ctx, "error", "repo", number)
 	assert.Error(t, err)
 }
+
+func TestGitHubClient_GetPullRequestCommits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/testowner/testrepo/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"sha":"def456"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+		fmt.Fprint(w, `[{"sha":"abc123"}]`)
+	})
+	client := newFakeServer(t, mux)
+
+	commits, err := client.GetPullRequestCommits(context.Background(), "testowner", "testrepo", 1)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "abc123", commits[0].GetSHA())
+	assert.Equal(t, "def456", commits[1].GetSHA())
+
+	_, err = client.GetPullRequestCommits(context.Background(), "testowner", "missing", 1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list pull request commits")
+}
+
+func TestGitHubClient_CompareCommits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/testowner/testrepo/compare/abc123...def456", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"files":[{"filename":"main.go","status":"modified","patch":"@@ -1 +1 @@\n-a\n+b"}]}`)
+	})
+	client := newFakeServer(t, mux)
+
+	comparison, err := client.CompareCommits(context.Background(), "testowner", "testrepo", "abc123", "def456")
+	assert.NoError(t, err)
+	assert.Len(t, comparison.Files, 1)
+	assert.Equal(t, "main.go", comparison.Files[0].GetFilename())
+
+	_, err = client.CompareCommits(context.Background(), "testowner", "testrepo", "abc123", "missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compare commits")
+}
+
+func TestGitHubClient_ListRepositories(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/acme/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "all", r.URL.Query().Get("type"))
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"name":"web","html_url":"https://github.com/acme/web"}]`)
+			return
+		}
+		w.Header().Set("Link", `<`+"http://"+r.Host+`/orgs/acme/repos?page=2>; rel="next"`)
+		fmt.Fprint(w, `[{"name":"api","html_url":"https://github.com/acme/api","topics":["go"]}]`)
+	})
+	mux.HandleFunc("/orgs/octocat/repos", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/users/octocat/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "owner", r.URL.Query().Get("type"))
+		fmt.Fprint(w, `[{"name":"hello-world","html_url":"https://github.com/octocat/hello-world","fork":true}]`)
+	})
+	client := newFakeServer(t, mux)
+
+	repos, err := client.ListRepositories(context.Background(), "acme")
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, []string{"go"}, repos[0].Topics)
+	assert.Equal(t, "web", repos[1].GetName())
+
+	repos, err = client.ListRepositories(context.Background(), "octocat")
+	assert.NoError(t, err, "users are listed when there is no organization")
+	assert.Len(t, repos, 1)
+	assert.True(t, repos[0].GetFork())
+}
@@ -3,121 +3,364 @@ package github
 import (
 	"context"
 	"fmt"
+	"iter"
 	"strconv"
 	"strings"
 
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/internal/diff"
 	"github.com/jesper/review-extractor/pkg/models"
 )
 
+// Options configures optional extraction features that cost extra API requests
+type Options struct {
+	// DetectAddressed checks whether later commits changed the lines an inline comment was made on
+	DetectAddressed bool
+	// IncludeEmptyReviews keeps approvals and other verdicts submitted without a body
+	IncludeEmptyReviews bool
+	// GraphQL fetches pull requests with their reviews and comments through the GraphQL API
+	GraphQL bool
+}
+
 // Extractor implements the core.Extractor interface for GitHub
 type Extractor struct {
-	client ClientInterface
+	// client, if set, is used for every repository instead of clients built from credentials
+	client        ClientInterface
+	authenticated bool
+	credentials   credentials.Source
+	clients       map[clientKey]ClientInterface
+	apps          map[clientKey]*appAuth
+	options       Options
+}
+
+// clientKey identifies a client by the API it calls and its credential; GitHub App clients
+// are specific to a repository owner
+type clientKey struct {
+	baseURL    string
+	credential models.Credential
+	owner      string
 }
 
-// NewExtractor creates a new GitHub extractor
-func NewExtractor(token string) *Extractor {
+// NewExtractor creates a new GitHub extractor authenticating with the credentials of each repository
+func NewExtractor(source credentials.Source, options Options) *Extractor {
 	return &Extractor{
-		client: NewClient(token),
+		credentials: source,
+		clients:     make(map[clientKey]ClientInterface),
+		apps:        make(map[clientKey]*appAuth),
+		options:     options,
+	}
+}
+
+// clientFor returns the client for a repository served by the REST API at baseURL and the
+// method it authenticates with, or an empty method if it is unauthenticated. Clients are
+// shared between repositories on the same server with the same credential, and for GitHub
+// Apps the same owner.
+func (e *Extractor) clientFor(repoURL, baseURL, owner, repo string) (ClientInterface, models.AuthMethod, error) {
+	if e.client != nil {
+		if e.authenticated {
+			return e.client, models.AuthBearer, nil
+		}
+		return e.client, "", nil
+	}
+
+	var credential models.Credential
+	authenticated := false
+	if e.credentials != nil {
+		credential, authenticated = e.credentials.Credential(models.ProviderGitHub, repoURL)
+	}
+	if !authenticated || credential.IsZero() {
+		credential = models.Credential{}
 	}
+
+	method := credential.AuthMethod()
+	if credential.IsZero() {
+		method = ""
+	}
+
+	key := clientKey{baseURL: baseURL, credential: credential}
+	if method == models.AuthGitHubApp {
+		key.owner = owner
+	}
+	if client, ok := e.clients[key]; ok {
+		return client, method, nil
+	}
+
+	var client ClientInterface
+	if method == models.AuthGitHubApp {
+		appKey := clientKey{baseURL: baseURL, credential: credential}
+		auth, ok := e.apps[appKey]
+		if !ok {
+			var err error
+			if auth, err = newAppAuth(baseURL, credential); err != nil {
+				return nil, "", err
+			}
+			e.apps[appKey] = auth
+		}
+		client = newAppClient(auth, owner, repo)
+	} else {
+		client = NewClient(baseURL, credential)
+	}
+	if e.options.GraphQL {
+		client = withGraphQL(client)
+	}
+
+	e.clients[key] = client
+	return client, method, nil
 }
 
 // ExtractReviews implements the core.Extractor interface
 func (e *Extractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
-	owner, repo, err := parseGitHubURL(repoURL)
+	return core.Collect(e.StreamReviews(ctx, repoURL))
+}
+
+// StreamReviews implements the core.StreamExtractor interface, yielding the reviews of each
+// pull request once its comments, reviews and diff have been fetched
+func (e *Extractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		repoRef, baseURL, err := parseGitHubURL(repoURL)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("invalid GitHub URL: %w", err))
+			return
+		}
+		owner, repo := repoRef.Owner, repoRef.Name
+		client, _, err := e.clientFor(repoURL, baseURL, owner, repo)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to authenticate: %w", err))
+			return
+		}
+
+		// Get all pull requests
+		prs, err := client.GetPullRequests(ctx, owner, repo)
+		if err != nil {
+			yield(models.Review{}, fmt.Errorf("failed to get pull requests: %w", err))
+			return
+		}
+
+		// Process each pull request
+		for _, pr := range prs {
+			reviews, err := e.pullRequestReviews(ctx, client, owner, repo, repoRef, pr)
+			if err != nil {
+				yield(models.Review{}, err)
+				return
+			}
+			for _, review := range reviews {
+				if !yield(review, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pullRequestReviews extracts the inline comments and review bodies of a pull request
+func (e *Extractor) pullRequestReviews(ctx context.Context, client ClientInterface, owner, repo string, repoRef models.RepositoryRef, pr *github.PullRequest) ([]models.Review, error) {
+	var allReviews []models.Review
+
+	// Get comments
+	comments, err := client.GetPullRequestComments(ctx, owner, repo, pr.GetNumber())
 	if err != nil {
-		return nil, fmt.Errorf("invalid GitHub URL: %w", err)
+		return nil, fmt.Errorf("failed to get comments for PR #%d: %w", pr.GetNumber(), err)
 	}
 
-	// Get all pull requests
-	prs, err := e.client.GetPullRequests(ctx, owner, repo)
+	// Get reviews
+	reviews, err := client.GetPullRequestReviews(ctx, owner, repo, pr.GetNumber())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pull requests: %w", err)
+		return nil, fmt.Errorf("failed to get reviews for PR #%d: %w", pr.GetNumber(), err)
 	}
 
-	var allReviews []models.Review
+	// Get diff for context
+	rawDiff, err := client.GetPullRequestDiff(ctx, owner, repo, pr.GetNumber())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff for PR #%d: %w", pr.GetNumber(), err)
+	}
+	diffFiles := diff.Parse(rawDiff)
+	pullRequest := convertPullRequest(pr, repoRef, diffFiles)
 
-	// Process each pull request
-	for _, pr := range prs {
-		// Get comments
-		comments, err := e.client.GetPullRequestComments(ctx, owner, repo, pr.GetNumber())
-		if err != nil {
-			return nil, fmt.Errorf("failed to get comments for PR #%d: %w", pr.GetNumber(), err)
-		}
+	// Index review verdicts so inline comments can be grouped under them
+	reviewStates := make(map[int64]models.ReviewState, len(reviews))
+	for _, review := range reviews {
+		reviewStates[review.GetID()] = models.ReviewState(review.GetState())
+	}
 
-		// Get reviews
-		reviews, err := e.client.GetPullRequestReviews(ctx, owner, repo, pr.GetNumber())
+	// Get commits to follow up on inline comments
+	var detector *addressDetector
+	if e.options.DetectAddressed && len(comments) > 0 {
+		detector, err = newAddressDetector(ctx, client, owner, repo, pr.GetNumber())
 		if err != nil {
-			return nil, fmt.Errorf("failed to get reviews for PR #%d: %w", pr.GetNumber(), err)
+			return nil, fmt.Errorf("failed to get commits for PR #%d: %w", pr.GetNumber(), err)
 		}
+	}
 
-		// Get diff for context
-		diff, err := e.client.GetPullRequestDiff(ctx, owner, repo, pr.GetNumber())
+	// Process comments
+	finder := newSuggestionFinder(client, owner, repo, pr, diffFiles)
+	for _, comment := range comments {
+		suggestions, err := finder.suggestions(ctx, comment)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get diff for PR #%d: %w", pr.GetNumber(), err)
+			return nil, fmt.Errorf("failed to get suggestions of comment %d: %w", comment.GetID(), err)
 		}
 
-		// Process comments
-		for _, comment := range comments {
-			review := models.Review{
-				PRID:           pr.GetNumber(),
-				PRTitle:        pr.GetTitle(),
-				PRAuthor:       pr.GetUser().GetLogin(),
-				Repository:     repo,
-				Provider:       models.ProviderGitHub,
-				CommentID:      fmt.Sprintf("%d", comment.GetID()),
-				CommentAuthor:  comment.GetUser().GetLogin(),
-				CommentText:    comment.GetBody(),
-				CommentCreated: comment.GetCreatedAt(),
-				FilePath:       comment.GetPath(),
-				LineNumber:     comment.GetLine(),
-				DiffContext:    extractDiffContext(diff, comment.GetPath(), comment.GetLine()),
+		review := models.Review{
+			PRID:           pr.GetNumber(),
+			PRTitle:        pr.GetTitle(),
+			PRAuthor:       pr.GetUser().GetLogin(),
+			Repository:     repo,
+			Repo:           repoRef,
+			Provider:       models.ProviderGitHub,
+			CommentID:      fmt.Sprintf("%d", comment.GetID()),
+			CommentAuthor:  comment.GetUser().GetLogin(),
+			CommentText:    comment.GetBody(),
+			CommentCreated: comment.GetCreatedAt(),
+			FilePath:       comment.GetPath(),
+			StartLine:      comment.GetStartLine(),
+			LineNumber:     comment.GetLine(),
+			DiffContext:    extractDiffContext(rawDiff, comment.GetPath(), comment.GetLine()),
+			Suggestions:    suggestions,
+			PullRequest:    pullRequest,
+		}
+		if reviewID := comment.GetPullRequestReviewID(); reviewID != 0 {
+			review.ReviewID = fmt.Sprintf("%d", reviewID)
+			review.ReviewState = reviewStates[reviewID]
+		}
+		if detector != nil {
+			if err := detector.annotate(ctx, comment, &review); err != nil {
+				return nil, fmt.Errorf("failed to detect follow-up for comment %d: %w", comment.GetID(), err)
 			}
-			allReviews = append(allReviews, review)
 		}
+		allReviews = append(allReviews, review)
+	}
 
-		// Process reviews
-		for _, review := range reviews {
-			if review.GetBody() == "" {
-				continue
-			}
+	// Process reviews
+	for _, review := range reviews {
+		if review.GetBody() == "" && !e.includeEmptyReview(review) {
+			continue
+		}
 
-			reviewModel := models.Review{
-				PRID:           pr.GetNumber(),
-				PRTitle:        pr.GetTitle(),
-				PRAuthor:       pr.GetUser().GetLogin(),
-				Repository:     repo,
-				Provider:       models.ProviderGitHub,
-				CommentID:      fmt.Sprintf("%d", review.GetID()),
-				CommentAuthor:  review.GetUser().GetLogin(),
-				CommentText:    review.GetBody(),
-				CommentCreated: review.GetSubmittedAt(),
-				// Note: Reviews don't have file/line context by default
-				FilePath:    "",
-				LineNumber:  0,
-				DiffContext: "",
-			}
-			allReviews = append(allReviews, reviewModel)
+		reviewModel := models.Review{
+			PRID:           pr.GetNumber(),
+			PRTitle:        pr.GetTitle(),
+			PRAuthor:       pr.GetUser().GetLogin(),
+			Repository:     repo,
+			Repo:           repoRef,
+			Provider:       models.ProviderGitHub,
+			CommentID:      fmt.Sprintf("%d", review.GetID()),
+			CommentAuthor:  review.GetUser().GetLogin(),
+			CommentText:    review.GetBody(),
+			CommentCreated: review.GetSubmittedAt(),
+			ReviewID:       fmt.Sprintf("%d", review.GetID()),
+			ReviewState:    models.ReviewState(review.GetState()),
+			// Note: Reviews don't have file/line context by default
+			FilePath:    "",
+			LineNumber:  0,
+			DiffContext: "",
+			PullRequest: pullRequest,
 		}
+		allReviews = append(allReviews, reviewModel)
 	}
 
 	return allReviews, nil
 }
 
-// parseGitHubURL extracts owner and repo from a GitHub URL
-func parseGitHubURL(url string) (owner, repo string, err error) {
-	// Remove protocol and domain
-	parts := strings.Split(url, "github.com/")
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid GitHub URL format")
+// convertPullRequest maps a GitHub pull request to the shared model. The list endpoint
+// does not report change sizes, so they are derived from the already fetched diff.
+func convertPullRequest(pr *github.PullRequest, repo models.RepositoryRef, files []diff.File) *models.PullRequest {
+	pullRequest := &models.PullRequest{
+		Number:       pr.GetNumber(),
+		Repository:   repo,
+		Provider:     models.ProviderGitHub,
+		Title:        pr.GetTitle(),
+		Author:       pr.GetUser().GetLogin(),
+		URL:          pr.GetHTMLURL(),
+		State:        pr.GetState(),
+		Merged:       pr.GetMerged() || pr.MergedAt != nil,
+		Draft:        pr.GetDraft(),
+		CreatedAt:    pr.GetCreatedAt(),
+		MergedAt:     pr.MergedAt,
+		ClosedAt:     pr.ClosedAt,
+		BaseBranch:   pr.GetBase().GetRef(),
+		HeadBranch:   pr.GetHead().GetRef(),
+		HeadSHA:      pr.GetHead().GetSHA(),
+		Additions:    pr.GetAdditions(),
+		Deletions:    pr.GetDeletions(),
+		ChangedFiles: pr.GetChangedFiles(),
 	}
 
-	// Split owner/repo
-	pathParts := strings.Split(parts[1], "/")
-	if len(pathParts) < 2 {
-		return "", "", fmt.Errorf("invalid GitHub URL format")
+	for _, label := range pr.Labels {
+		pullRequest.Labels = append(pullRequest.Labels, label.GetName())
 	}
 
-	return pathParts[0], pathParts[1], nil
+	if pullRequest.ChangedFiles == 0 && len(files) > 0 {
+		pullRequest.ChangedFiles = len(files)
+		for _, file := range files {
+			for _, hunk := range file.Hunks {
+				for _, line := range hunk.Lines {
+					switch line.Kind {
+					case diff.LineAdded:
+						pullRequest.Additions++
+					case diff.LineRemoved:
+						pullRequest.Deletions++
+					}
+				}
+			}
+		}
+	}
+
+	return pullRequest
+}
+
+// includeEmptyReview reports whether a review without a body should still be extracted.
+// Body-less COMMENTED reviews only wrap inline comments, which are extracted on their own.
+func (e *Extractor) includeEmptyReview(review *github.PullRequestReview) bool {
+	if !e.options.IncludeEmptyReviews {
+		return false
+	}
+
+	switch models.ReviewState(review.GetState()) {
+	case models.ReviewStateApproved, models.ReviewStateChangesRequested, models.ReviewStateDismissed:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseGitHubURL parses the URL of a repository on github.com or a GitHub Enterprise server,
+// such as https://github.com/<owner>/<repo>, into its reference and the base URL of its REST
+// API. Paths below the repository, e.g. /pulls, are ignored.
+func parseGitHubURL(repoURL string) (models.RepositoryRef, string, error) {
+	if !strings.Contains(repoURL, "://") {
+		repoURL = "https://" + repoURL
+	}
+	parsed, err := models.ParseRepositoryURL(repoURL)
+	if err != nil {
+		return models.RepositoryRef{}, "", err
+	}
+
+	segments := strings.Split(parsed.FullName(), "/")
+	if len(segments) < 2 || segments[0] == "" || segments[1] == "" {
+		return models.RepositoryRef{}, "", fmt.Errorf("invalid GitHub URL format: expected https://github.com/<owner>/<repo>")
+	}
+	host := strings.TrimPrefix(parsed.Host, "www.")
+	ref := models.RepositoryRef{
+		Host:  host,
+		Owner: segments[0],
+		Name:  strings.TrimSuffix(segments[1], ".git"),
+	}
+	ref.URL = fmt.Sprintf("https://%s/%s/%s", host, ref.Owner, ref.Name)
+
+	// GitHub Enterprise serves its API under /api/v3/ of the server, on its scheme
+	baseURL := "https://api.github.com/"
+	if host != "github.com" {
+		scheme, _, _ := strings.Cut(repoURL, "://")
+		baseURL = strings.ToLower(scheme) + "://" + parsed.Host + "/api/v3/"
+	}
+	return ref, baseURL, nil
+}
+
+// ValidateURL checks that url identifies a GitHub repository
+func ValidateURL(url string) error {
+	_, _, err := parseGitHubURL(url)
+	return err
 }
 
 // extractDiffContext extracts the relevant diff context around a specific line
@@ -195,3 +438,29 @@ func extractDiffContext(diff, filePath string, lineNumber int) string {
 
 	return ""
 }
+
+// ListRepositories implements the core.Discoverer interface, listing the repositories of
+// an organization or user
+func (e *Extractor) ListRepositories(ctx context.Context, discovery models.DiscoveryConfig) ([]models.RemoteRepository, error) {
+	client, _, err := e.clientFor("https://github.com/"+discovery.Org, "https://api.github.com/", discovery.Org, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	repos, err := client.ListRepositories(ctx, discovery.Org)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make([]models.RemoteRepository, 0, len(repos))
+	for _, repo := range repos {
+		remote = append(remote, models.RemoteRepository{
+			Name:     repo.GetName(),
+			URL:      repo.GetHTMLURL(),
+			Topics:   repo.Topics,
+			Archived: repo.GetArchived(),
+			Fork:     repo.GetFork(),
+		})
+	}
+	return remote, nil
+}
@@ -2,24 +2,43 @@ package github
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/internal/diff"
 	"github.com/jesper/review-extractor/pkg/models"
 	"github.com/stretchr/testify/assert"
 )
 
 // MockClient implements the GitHub client interface for testing
 type MockClient struct {
-	prs        []*github.PullRequest
-	comments   []*github.PullRequestComment
-	reviews    []*github.PullRequestReview
-	diff       string
-	prErr      error
-	commentErr error
-	reviewErr  error
-	diffErr    error
+	prs      []*github.PullRequest
+	comments []*github.PullRequestComment
+	reviews  []*github.PullRequestReview
+	diff     string
+	commits  []*github.RepositoryCommit
+	// comparisons is keyed by "base...head"
+	comparisons map[string]*github.CommitsComparison
+	prErr       error
+	commentErr  error
+	reviewErr   error
+	diffErr     error
+	commitErr   error
+	compareErr  error
+	user        *github.User
+	repository  *github.Repository
+	rate        *github.Rate
+	queryRate   *github.Rate
+	userErr     error
+	repoErr     error
+	rateErr     error
+	repos       []*github.Repository
+	reposErr    error
+	// commentCalls counts the pull requests whose comments were fetched
+	commentCalls int
 }
 
 func (m *MockClient) GetPullRequests(ctx context.Context, owner, repo string) ([]*github.PullRequest, error) {
@@ -27,6 +46,7 @@ func (m *MockClient) GetPullRequests(ctx context.Context, owner, repo string) ([
 }
 
 func (m *MockClient) GetPullRequestComments(ctx context.Context, owner, repo string, prNumber int) ([]*github.PullRequestComment, error) {
+	m.commentCalls++
 	return m.comments, m.commentErr
 }
 
@@ -38,6 +58,47 @@ func (m *MockClient) GetPullRequestDiff(ctx context.Context, owner, repo string,
 	return m.diff, m.diffErr
 }
 
+func (m *MockClient) GetPullRequestCommits(ctx context.Context, owner, repo string, prNumber int) ([]*github.RepositoryCommit, error) {
+	return m.commits, m.commitErr
+}
+
+func (m *MockClient) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, error) {
+	if m.compareErr != nil {
+		return nil, m.compareErr
+	}
+	if comparison, ok := m.comparisons[base+"..."+head]; ok {
+		return comparison, nil
+	}
+	return &github.CommitsComparison{}, nil
+}
+
+func (m *MockClient) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
+	return m.user, m.userErr
+}
+
+func (m *MockClient) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	return m.repository, m.repoErr
+}
+
+func (m *MockClient) CountPullRequests(ctx context.Context, owner, repo string) (int, *github.PullRequest, error) {
+	if m.prErr != nil || len(m.prs) == 0 {
+		return 0, nil, m.prErr
+	}
+	return len(m.prs), m.prs[0], nil
+}
+
+func (m *MockClient) GetRateLimit(ctx context.Context) (*github.Rate, error) {
+	return m.rate, m.rateErr
+}
+
+func (m *MockClient) GetGraphQLRateLimit(ctx context.Context) (*github.Rate, error) {
+	return m.queryRate, m.rateErr
+}
+
+func (m *MockClient) ListRepositories(ctx context.Context, owner string) ([]*github.Repository, error) {
+	return m.repos, m.reposErr
+}
+
 func TestExtractReviews(t *testing.T) {
 	now := time.Now()
 	mockPR := &github.PullRequest{
@@ -49,12 +110,13 @@ func TestExtractReviews(t *testing.T) {
 	}
 
 	mockComment := &github.PullRequestComment{
-		ID:        github.Int64(1),
-		Body:      github.String("Test comment"),
-		Path:      github.String("test.go"),
-		Line:      github.Int(10),
-		User:      &github.User{Login: github.String("reviewer")},
-		CreatedAt: &now,
+		ID:                  github.Int64(1),
+		Body:                github.String("Test comment"),
+		Path:                github.String("test.go"),
+		Line:                github.Int(10),
+		User:                &github.User{Login: github.String("reviewer")},
+		CreatedAt:           &now,
+		PullRequestReviewID: github.Int64(1),
 	}
 
 	mockReview := &github.PullRequestReview{
@@ -62,6 +124,7 @@ func TestExtractReviews(t *testing.T) {
 		Body:        github.String("Test review"),
 		User:        &github.User{Login: github.String("reviewer")},
 		SubmittedAt: &now,
+		State:       github.String("CHANGES_REQUESTED"),
 	}
 
 	mockClient := &MockClient{
@@ -79,6 +142,20 @@ func TestExtractReviews(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, reviews, 2) // One comment and one review
 
+	expectedRepo := models.RepositoryRef{
+		Host:  "github.com",
+		Owner: "test",
+		Name:  "repo",
+		URL:   "https://github.com/test/repo",
+	}
+	expectedPR := &models.PullRequest{
+		Number:     1,
+		Repository: expectedRepo,
+		Provider:   models.ProviderGitHub,
+		Title:      "Test PR",
+		Author:     "testuser",
+	}
+
 	// Verify comment
 	// Note: DiffContext will be empty because the mock diff is not a real diff format
 	assert.Equal(t, models.Review{
@@ -86,14 +163,18 @@ func TestExtractReviews(t *testing.T) {
 		PRTitle:        "Test PR",
 		PRAuthor:       "testuser",
 		Repository:     "repo",
+		Repo:           expectedRepo,
 		Provider:       models.ProviderGitHub,
 		CommentID:      "1",
 		CommentAuthor:  "reviewer",
 		CommentText:    "Test comment",
 		CommentCreated: now,
+		ReviewID:       "1",
+		ReviewState:    models.ReviewStateChangesRequested,
 		FilePath:       "test.go",
 		LineNumber:     10,
 		DiffContext:    "",
+		PullRequest:    expectedPR,
 	}, reviews[0])
 
 	// Verify review
@@ -102,31 +183,59 @@ func TestExtractReviews(t *testing.T) {
 		PRTitle:        "Test PR",
 		PRAuthor:       "testuser",
 		Repository:     "repo",
+		Repo:           expectedRepo,
 		Provider:       models.ProviderGitHub,
 		CommentID:      "1",
 		CommentAuthor:  "reviewer",
 		CommentText:    "Test review",
 		CommentCreated: now,
+		ReviewID:       "1",
+		ReviewState:    models.ReviewStateChangesRequested,
 		FilePath:       "",
 		LineNumber:     0,
 		DiffContext:    "",
+		PullRequest:    expectedPR,
 	}, reviews[1])
+	assert.Same(t, reviews[0].PullRequest, reviews[1].PullRequest)
 }
 
 func TestParseGitHubURL(t *testing.T) {
 	tests := []struct {
-		name      string
-		url       string
-		wantOwner string
-		wantRepo  string
-		wantErr   bool
+		name    string
+		url     string
+		ref     models.RepositoryRef
+		baseURL string
+		wantErr bool
 	}{
 		{
-			name:      "valid URL",
-			url:       "https://github.com/test/repo",
-			wantOwner: "test",
-			wantRepo:  "repo",
-			wantErr:   false,
+			name:    "valid URL",
+			url:     "https://github.com/test/repo",
+			ref:     models.RepositoryRef{Host: "github.com", Owner: "test", Name: "repo", URL: "https://github.com/test/repo"},
+			baseURL: "https://api.github.com/",
+		},
+		{
+			name:    "URL with .git suffix",
+			url:     "https://github.com/test/repo.git",
+			ref:     models.RepositoryRef{Host: "github.com", Owner: "test", Name: "repo", URL: "https://github.com/test/repo"},
+			baseURL: "https://api.github.com/",
+		},
+		{
+			name:    "page of a repository",
+			url:     "https://www.GitHub.com/customer-a/api/pulls",
+			ref:     models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api", URL: "https://github.com/customer-a/api"},
+			baseURL: "https://api.github.com/",
+		},
+		{
+			name:    "URL without a scheme",
+			url:     "github.com/customer-b/api",
+			ref:     models.RepositoryRef{Host: "github.com", Owner: "customer-b", Name: "api", URL: "https://github.com/customer-b/api"},
+			baseURL: "https://api.github.com/",
+		},
+		{
+			name:    "GitHub Enterprise",
+			url:     "https://github.example.com/platform/api",
+			ref:     models.RepositoryRef{Host: "github.example.com", Owner: "platform", Name: "api", URL: "https://github.example.com/platform/api"},
+			baseURL: "https://github.example.com/api/v3/",
 		},
 		{
 			name:    "invalid URL",
@@ -134,26 +243,84 @@ func TestParseGitHubURL(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "non-github URL",
-			url:     "https://gitlab.com/test/repo",
+			name:    "host only",
+			url:     "https://github.example.com",
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			owner, repo, err := parseGitHubURL(tt.url)
+			ref, baseURL, err := parseGitHubURL(tt.url)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
-			assert.Equal(t, tt.wantOwner, owner)
-			assert.Equal(t, tt.wantRepo, repo)
+			assert.Equal(t, tt.ref, ref)
+			assert.Equal(t, tt.baseURL, baseURL)
 		})
 	}
 }
 
+func TestClientFor(t *testing.T) {
+	extractor := NewExtractor(credentials.NewResolver(&models.Config{
+		Credentials: []models.Credential{{Provider: models.ProviderGitHub, Token: "token"}},
+		Repositories: []models.RepositoryConfig{
+			{Provider: models.ProviderGitHub, URL: "https://github.com/acme/public", Credential: &models.Credential{}},
+		},
+	}), Options{})
+
+	api, method, err := extractor.clientFor("https://github.com/acme/api", "https://api.github.com/", "acme", "api")
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuthBearer, method)
+	web, _, _ := extractor.clientFor("https://github.com/acme/web", "https://api.github.com/", "acme", "web")
+	assert.Same(t, api, web, "repositories with the same credential share a client")
+
+	public, method, err := extractor.clientFor("https://github.com/acme/public", "https://api.github.com/", "acme", "public")
+	assert.NoError(t, err)
+	assert.Empty(t, method)
+	assert.NotSame(t, api, public)
+
+	enterprise, _, err := extractor.clientFor("https://github.example.com/acme/api", "https://github.example.com/api/v3/", "acme", "api")
+	assert.NoError(t, err)
+	assert.NotSame(t, api, enterprise, "servers do not share clients")
+	assert.Equal(t, "https://github.example.com/api/v3/", enterprise.(*Client).client.(*githubClient).client.BaseURL.String())
+	assert.Len(t, extractor.clients, 3)
+}
+
+func TestListRepositories(t *testing.T) {
+	mockClient := &MockClient{
+		repos: []*github.Repository{
+			{
+				Name:     github.String("api"),
+				HTMLURL:  github.String("https://github.com/acme/api"),
+				Topics:   []string{"go"},
+				Archived: github.Bool(true),
+			},
+		},
+	}
+	extractor := &Extractor{client: mockClient}
+
+	repos, err := extractor.ListRepositories(context.Background(), models.DiscoveryConfig{Provider: models.ProviderGitHub, Org: "acme"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RemoteRepository{
+		{Name: "api", URL: "https://github.com/acme/api", Topics: []string{"go"}, Archived: true},
+	}, repos)
+
+	mockClient.reposErr = errors.New("API error")
+	_, err = extractor.ListRepositories(context.Background(), models.DiscoveryConfig{Provider: models.ProviderGitHub, Org: "acme"})
+	assert.Error(t, err)
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://github.com/test/repo"))
+	assert.NoError(t, ValidateURL("https://github.com/test/repo.git"))
+	assert.Error(t, ValidateURL("https://github.com/test"))
+	assert.Error(t, ValidateURL("https://github.com/test/"))
+	assert.NoError(t, ValidateURL("https://github.example.com/test/repo"), "GitHub Enterprise servers have any host")
+}
+
 func TestExtractDiffContext(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -318,6 +485,42 @@ func TestExtractReviews_ErrorCases(t *testing.T) {
 	}
 }
 
+func TestStreamReviews(t *testing.T) {
+	mockClient := &MockClient{
+		prs: []*github.PullRequest{
+			{Number: github.Int(1), Title: github.String("First")},
+			{Number: github.Int(2), Title: github.String("Second")},
+		},
+		reviews: []*github.PullRequestReview{
+			{ID: github.Int64(1), Body: github.String("Looks good"), State: github.String("APPROVED")},
+		},
+	}
+	extractor := &Extractor{client: mockClient}
+
+	var titles []string
+	for review, err := range extractor.StreamReviews(context.Background(), "https://github.com/test/repo") {
+		assert.NoError(t, err)
+		titles = append(titles, review.PRTitle)
+	}
+	assert.Equal(t, []string{"First", "Second"}, titles)
+	assert.Equal(t, 2, mockClient.commentCalls)
+
+	mockClient.commentCalls = 0
+	for review := range extractor.StreamReviews(context.Background(), "https://github.com/test/repo") {
+		assert.Equal(t, "First", review.PRTitle)
+		break
+	}
+	assert.Equal(t, 1, mockClient.commentCalls, "pull requests after the consumer stopped are not fetched")
+
+	mockClient.reviewErr = errors.New("API error")
+	var errs []error
+	for _, err := range extractor.StreamReviews(context.Background(), "https://github.com/test/repo") {
+		errs = append(errs, err)
+	}
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "failed to get reviews for PR #1: API error")
+}
+
 func TestExtractReviews_EmptyResults(t *testing.T) {
 	mockClient := &MockClient{
 		prs:      []*github.PullRequest{},
@@ -365,6 +568,104 @@ func TestExtractReviews_ReviewWithoutBody(t *testing.T) {
 	assert.Empty(t, reviews) // Should not include reviews with empty bodies
 }
 
+func TestConvertPullRequest(t *testing.T) {
+	created := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	merged := time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	pr := &github.PullRequest{
+		Number:    github.Int(7),
+		Title:     github.String("Add feature"),
+		User:      &github.User{Login: github.String("author")},
+		HTMLURL:   github.String("https://github.com/test/repo/pull/7"),
+		State:     github.String("closed"),
+		Draft:     github.Bool(false),
+		CreatedAt: &created,
+		MergedAt:  &merged,
+		ClosedAt:  &merged,
+		Base:      &github.PullRequestBranch{Ref: github.String("main")},
+		Head:      &github.PullRequestBranch{Ref: github.String("feature"), SHA: github.String("abc123")},
+		Labels:    []*github.Label{{Name: github.String("bug")}, {Name: github.String("backend")}},
+	}
+
+	repo := models.RepositoryRef{Host: "github.com", Owner: "test", Name: "repo"}
+	files := diff.Parse("diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,3 @@\n-x\n+y\n+z\n w\n")
+
+	assert.Equal(t, &models.PullRequest{
+		Number:       7,
+		Repository:   repo,
+		Provider:     models.ProviderGitHub,
+		Title:        "Add feature",
+		Author:       "author",
+		URL:          "https://github.com/test/repo/pull/7",
+		State:        "closed",
+		Merged:       true,
+		CreatedAt:    created,
+		MergedAt:     &merged,
+		ClosedAt:     &merged,
+		BaseBranch:   "main",
+		HeadBranch:   "feature",
+		HeadSHA:      "abc123",
+		Labels:       []string{"bug", "backend"},
+		Additions:    2,
+		Deletions:    1,
+		ChangedFiles: 1,
+	}, convertPullRequest(pr, repo, files))
+
+	// Sizes reported by the API take precedence over the diff
+	pr.Additions = github.Int(10)
+	pr.Deletions = github.Int(5)
+	pr.ChangedFiles = github.Int(3)
+	converted := convertPullRequest(pr, repo, files)
+	assert.Equal(t, 10, converted.Additions)
+	assert.Equal(t, 5, converted.Deletions)
+	assert.Equal(t, 3, converted.ChangedFiles)
+}
+
+func TestExtractReviews_IncludeEmptyReviews(t *testing.T) {
+	now := time.Now()
+	mockPR := &github.PullRequest{
+		Number: github.Int(1),
+		Title:  github.String("Test PR"),
+		User:   &github.User{Login: github.String("testuser")},
+	}
+
+	emptyReview := func(id int64, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			ID:          github.Int64(id),
+			Body:        github.String(""),
+			User:        &github.User{Login: github.String("reviewer")},
+			SubmittedAt: &now,
+			State:       github.String(state),
+		}
+	}
+
+	mockClient := &MockClient{
+		prs: []*github.PullRequest{mockPR},
+		reviews: []*github.PullRequestReview{
+			emptyReview(1, "APPROVED"),
+			emptyReview(2, "COMMENTED"),
+			emptyReview(3, "CHANGES_REQUESTED"),
+			emptyReview(4, "DISMISSED"),
+		},
+	}
+
+	extractor := &Extractor{
+		client:  mockClient,
+		options: Options{IncludeEmptyReviews: true},
+	}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 3) // Body-less COMMENTED reviews only wrap inline comments
+
+	assert.Equal(t, "1", reviews[0].ReviewID)
+	assert.Equal(t, models.ReviewStateApproved, reviews[0].ReviewState)
+	assert.Equal(t, "3", reviews[1].ReviewID)
+	assert.Equal(t, models.ReviewStateChangesRequested, reviews[1].ReviewState)
+	assert.Equal(t, "4", reviews[2].ReviewID)
+	assert.Equal(t, models.ReviewStateDismissed, reviews[2].ReviewState)
+}
+
 func TestExtractDiffContext_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name       string
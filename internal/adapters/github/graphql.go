@@ -0,0 +1,669 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+const (
+	// graphqlDefaultPageSize is the number of pull requests fetched by the first list query
+	graphqlDefaultPageSize = 20
+	graphqlMinPageSize     = 5
+	graphqlMaxPageSize     = 50
+	// graphqlTargetCost is the rate limit cost per list query that page sizes are tuned towards
+	graphqlTargetCost = 10
+	// graphqlNestedPageSize is the number of reviews and review threads fetched with each
+	// pull request; the rest are fetched with follow-up queries
+	graphqlNestedPageSize = 50
+	// graphqlThreadCommentsPageSize is the number of comments fetched with each review thread
+	graphqlThreadCommentsPageSize = 20
+	// graphqlFollowUpPageSize is the page size of follow-up queries for a single connection
+	graphqlFollowUpPageSize = 100
+)
+
+const (
+	reviewFields = `
+fragment reviewFields on PullRequestReview {
+  databaseId state body submittedAt
+  author { login }
+}`
+
+	commentFields = `
+fragment commentFields on PullRequestReviewComment {
+  databaseId body createdAt path line startLine originalLine originalStartLine
+  commit { oid }
+  originalCommit { oid }
+  author { login }
+  pullRequestReview { databaseId }
+}`
+
+	threadFields = `
+fragment threadFields on PullRequestReviewThread {
+  id diffSide
+  comments(first: $comments) {
+    pageInfo { hasNextPage endCursor }
+    nodes { ...commentFields }
+  }
+}` + commentFields
+
+	pullRequestFields = `
+fragment pullRequestFields on PullRequest {
+  number title url state isDraft merged createdAt mergedAt closedAt
+  author { login }
+  baseRefName baseRefOid headRefName headRefOid additions deletions changedFiles
+  labels(first: 20) { nodes { name } }
+  reviews(first: $nested) {
+    pageInfo { hasNextPage endCursor }
+    nodes { ...reviewFields }
+  }
+  reviewThreads(first: $nested) {
+    pageInfo { hasNextPage endCursor }
+    nodes { ...threadFields }
+  }
+}` + reviewFields + threadFields
+
+	pullRequestsQuery = `
+query($owner: String!, $name: String!, $first: Int!, $after: String, $nested: Int!, $comments: Int!) {
+  rateLimit { cost remaining }
+  repository(owner: $owner, name: $name) {
+    pullRequests(first: $first, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes { ...pullRequestFields }
+    }
+  }
+}` + pullRequestFields
+
+	pullRequestQuery = `
+query($owner: String!, $name: String!, $number: Int!, $nested: Int!, $comments: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) { ...pullRequestFields }
+  }
+}` + pullRequestFields
+
+	reviewsQuery = `
+query($owner: String!, $name: String!, $number: Int!, $first: Int!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviews(first: $first, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { ...reviewFields }
+      }
+    }
+  }
+}` + reviewFields
+
+	reviewThreadsQuery = `
+query($owner: String!, $name: String!, $number: Int!, $first: Int!, $after: String, $comments: Int!) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: $first, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { ...threadFields }
+      }
+    }
+  }
+}` + threadFields
+
+	rateLimitQuery = `
+query {
+  rateLimit { limit remaining resetAt }
+}`
+
+	threadCommentsQuery = `
+query($id: ID!, $first: Int!, $after: String) {
+  node(id: $id) {
+    ... on PullRequestReviewThread {
+      comments(first: $first, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { ...commentFields }
+      }
+    }
+  }
+}` + commentFields
+)
+
+// graphqlPageInfo is the pagination state of a GraphQL connection
+type graphqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphqlActor is the author of a pull request, review or comment; nil for deleted users
+type graphqlActor struct {
+	Login string `json:"login"`
+}
+
+// graphqlCommit references a commit by its SHA
+type graphqlCommit struct {
+	OID string `json:"oid"`
+}
+
+type graphqlReview struct {
+	DatabaseID  int64         `json:"databaseId"`
+	State       string        `json:"state"`
+	Body        string        `json:"body"`
+	SubmittedAt *time.Time    `json:"submittedAt"`
+	Author      *graphqlActor `json:"author"`
+}
+
+type graphqlReviews struct {
+	PageInfo graphqlPageInfo `json:"pageInfo"`
+	Nodes    []graphqlReview `json:"nodes"`
+}
+
+type graphqlComment struct {
+	DatabaseID        int64          `json:"databaseId"`
+	Body              string         `json:"body"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	Path              string         `json:"path"`
+	Line              *int           `json:"line"`
+	StartLine         *int           `json:"startLine"`
+	OriginalLine      *int           `json:"originalLine"`
+	OriginalStartLine *int           `json:"originalStartLine"`
+	Commit            *graphqlCommit `json:"commit"`
+	OriginalCommit    *graphqlCommit `json:"originalCommit"`
+	Author            *graphqlActor  `json:"author"`
+	PullRequestReview *struct {
+		DatabaseID int64 `json:"databaseId"`
+	} `json:"pullRequestReview"`
+}
+
+type graphqlComments struct {
+	PageInfo graphqlPageInfo  `json:"pageInfo"`
+	Nodes    []graphqlComment `json:"nodes"`
+}
+
+type graphqlThread struct {
+	ID       string          `json:"id"`
+	DiffSide string          `json:"diffSide"`
+	Comments graphqlComments `json:"comments"`
+}
+
+type graphqlThreads struct {
+	PageInfo graphqlPageInfo `json:"pageInfo"`
+	Nodes    []graphqlThread `json:"nodes"`
+}
+
+type graphqlPullRequest struct {
+	Number       int           `json:"number"`
+	Title        string        `json:"title"`
+	URL          string        `json:"url"`
+	State        string        `json:"state"`
+	IsDraft      bool          `json:"isDraft"`
+	Merged       bool          `json:"merged"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	MergedAt     *time.Time    `json:"mergedAt"`
+	ClosedAt     *time.Time    `json:"closedAt"`
+	Author       *graphqlActor `json:"author"`
+	BaseRefName  string        `json:"baseRefName"`
+	BaseRefOID   string        `json:"baseRefOid"`
+	HeadRefName  string        `json:"headRefName"`
+	HeadRefOID   string        `json:"headRefOid"`
+	Additions    int           `json:"additions"`
+	Deletions    int           `json:"deletions"`
+	ChangedFiles int           `json:"changedFiles"`
+	Labels       struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Reviews       graphqlReviews `json:"reviews"`
+	ReviewThreads graphqlThreads `json:"reviewThreads"`
+}
+
+// graphqlError is an error reported in the body of a GraphQL response
+type graphqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphqlErrors are the errors of a GraphQL response
+type graphqlErrors []graphqlError
+
+func (e graphqlErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "GraphQL query failed: " + strings.Join(messages, "; ")
+}
+
+// pullRequestKey identifies a pull request across repositories
+type pullRequestKey struct {
+	owner, repo string
+	number      int
+}
+
+// graphqlClient implements ClientInterface with GitHub's GraphQL API, fetching pull requests
+// together with their reviews and review comments in batched queries. GraphQL has no diffs,
+// so those and every other request still go through the REST client it embeds.
+type graphqlClient struct {
+	*githubClient
+
+	mu       sync.Mutex
+	pageSize int
+	// pullRequests holds the reviews and comments fetched with the last list of pull requests
+	pullRequests map[pullRequestKey]*graphqlPullRequest
+}
+
+// newGraphQLClient creates a GraphQL client authenticating like the REST client
+func newGraphQLClient(rest *githubClient) *graphqlClient {
+	return &graphqlClient{
+		githubClient: rest,
+		pageSize:     graphqlDefaultPageSize,
+		pullRequests: make(map[pullRequestKey]*graphqlPullRequest),
+	}
+}
+
+// withGraphQL returns a client fetching pull requests, reviews and comments through the
+// GraphQL API, authenticating like client
+func withGraphQL(client ClientInterface) ClientInterface {
+	wrapper, ok := client.(*Client)
+	if !ok {
+		return client
+	}
+	rest, ok := wrapper.client.(*githubClient)
+	if !ok {
+		return client
+	}
+	return &Client{client: newGraphQLClient(rest)}
+}
+
+// GetPullRequests fetches the pull requests of a repository, newest first, along with
+// their reviews and review comments, which later calls are served from
+func (c *graphqlClient) GetPullRequests(ctx context.Context, owner, repo string) ([]*github.PullRequest, error) {
+	var allPRs []*github.PullRequest
+	fetched := make(map[pullRequestKey]*graphqlPullRequest)
+	var after any
+
+	for {
+		var data struct {
+			RateLimit struct {
+				Cost int `json:"cost"`
+			} `json:"rateLimit"`
+			Repository *struct {
+				PullRequests struct {
+					PageInfo graphqlPageInfo      `json:"pageInfo"`
+					Nodes    []graphqlPullRequest `json:"nodes"`
+				} `json:"pullRequests"`
+			} `json:"repository"`
+		}
+		variables := map[string]any{
+			"owner":    owner,
+			"name":     repo,
+			"after":    after,
+			"nested":   graphqlNestedPageSize,
+			"comments": graphqlThreadCommentsPageSize,
+		}
+		if err := c.queryPage(ctx, pullRequestsQuery, variables, &data, func() int { return data.RateLimit.Cost }); err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+		if data.Repository == nil {
+			return nil, fmt.Errorf("failed to list pull requests: repository %s/%s not found", owner, repo)
+		}
+
+		for i := range data.Repository.PullRequests.Nodes {
+			pr := &data.Repository.PullRequests.Nodes[i]
+			if err := c.complete(ctx, owner, repo, pr); err != nil {
+				return nil, err
+			}
+			fetched[pullRequestKey{owner, repo, pr.Number}] = pr
+			allPRs = append(allPRs, convertGraphQLPullRequest(pr))
+		}
+
+		pageInfo := data.Repository.PullRequests.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		after = pageInfo.EndCursor
+	}
+
+	c.mu.Lock()
+	c.pullRequests = fetched
+	c.mu.Unlock()
+
+	return allPRs, nil
+}
+
+// GetGraphQLRateLimit fetches the rate limit the queries of the client count against.
+// Reading it costs no points.
+func (c *graphqlClient) GetGraphQLRateLimit(ctx context.Context) (*github.Rate, error) {
+	var data struct {
+		RateLimit struct {
+			Limit     int       `json:"limit"`
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+	}
+	if err := c.query(ctx, rateLimitQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("failed to get GraphQL rate limit: %w", err)
+	}
+
+	return &github.Rate{
+		Limit:     data.RateLimit.Limit,
+		Remaining: data.RateLimit.Remaining,
+		Reset:     github.Timestamp{Time: data.RateLimit.ResetAt},
+	}, nil
+}
+
+// GetPullRequestComments returns the review comments of a pull request, ordered by ID like
+// the REST API
+func (c *graphqlClient) GetPullRequestComments(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	pr, err := c.pullRequest(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+
+	var comments []*github.PullRequestComment
+	for _, thread := range pr.ReviewThreads.Nodes {
+		for i := range thread.Comments.Nodes {
+			comments = append(comments, convertGraphQLComment(&thread.Comments.Nodes[i], thread.DiffSide))
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].GetID() < comments[j].GetID() })
+
+	return comments, nil
+}
+
+// GetPullRequestReviews returns the reviews of a pull request
+func (c *graphqlClient) GetPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	pr, err := c.pullRequest(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull request reviews: %w", err)
+	}
+
+	reviews := make([]*github.PullRequestReview, 0, len(pr.Reviews.Nodes))
+	for i := range pr.Reviews.Nodes {
+		reviews = append(reviews, convertGraphQLReview(&pr.Reviews.Nodes[i]))
+	}
+
+	return reviews, nil
+}
+
+// pullRequest returns a pull request fetched with the last list, or fetches it on its own
+func (c *graphqlClient) pullRequest(ctx context.Context, owner, repo string, number int) (*graphqlPullRequest, error) {
+	c.mu.Lock()
+	pr, ok := c.pullRequests[pullRequestKey{owner, repo, number}]
+	c.mu.Unlock()
+	if ok {
+		return pr, nil
+	}
+
+	var data struct {
+		Repository *struct {
+			PullRequest *graphqlPullRequest `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	variables := map[string]any{
+		"owner":    owner,
+		"name":     repo,
+		"number":   number,
+		"nested":   graphqlNestedPageSize,
+		"comments": graphqlThreadCommentsPageSize,
+	}
+	if err := c.query(ctx, pullRequestQuery, variables, &data); err != nil {
+		return nil, err
+	}
+	if data.Repository == nil || data.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("pull request %s/%s#%d not found", owner, repo, number)
+	}
+
+	pr = data.Repository.PullRequest
+	if err := c.complete(ctx, owner, repo, pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// complete fetches the reviews, review threads and thread comments that did not fit in
+// the pages fetched with a pull request
+func (c *graphqlClient) complete(ctx context.Context, owner, repo string, pr *graphqlPullRequest) error {
+	for pageInfo := pr.Reviews.PageInfo; pageInfo.HasNextPage; {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					Reviews graphqlReviews `json:"reviews"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		variables := map[string]any{
+			"owner":  owner,
+			"name":   repo,
+			"number": pr.Number,
+			"first":  graphqlFollowUpPageSize,
+			"after":  pageInfo.EndCursor,
+		}
+		if err := c.query(ctx, reviewsQuery, variables, &data); err != nil {
+			return fmt.Errorf("failed to list reviews of PR #%d: %w", pr.Number, err)
+		}
+		reviews := data.Repository.PullRequest.Reviews
+		pr.Reviews.Nodes = append(pr.Reviews.Nodes, reviews.Nodes...)
+		pageInfo = reviews.PageInfo
+	}
+
+	for pageInfo := pr.ReviewThreads.PageInfo; pageInfo.HasNextPage; {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads graphqlThreads `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		variables := map[string]any{
+			"owner":    owner,
+			"name":     repo,
+			"number":   pr.Number,
+			"first":    graphqlFollowUpPageSize,
+			"after":    pageInfo.EndCursor,
+			"comments": graphqlThreadCommentsPageSize,
+		}
+		if err := c.query(ctx, reviewThreadsQuery, variables, &data); err != nil {
+			return fmt.Errorf("failed to list review threads of PR #%d: %w", pr.Number, err)
+		}
+		threads := data.Repository.PullRequest.ReviewThreads
+		pr.ReviewThreads.Nodes = append(pr.ReviewThreads.Nodes, threads.Nodes...)
+		pageInfo = threads.PageInfo
+	}
+
+	for i := range pr.ReviewThreads.Nodes {
+		thread := &pr.ReviewThreads.Nodes[i]
+		for pageInfo := thread.Comments.PageInfo; pageInfo.HasNextPage; {
+			var data struct {
+				Node struct {
+					Comments graphqlComments `json:"comments"`
+				} `json:"node"`
+			}
+			variables := map[string]any{
+				"id":    thread.ID,
+				"first": graphqlFollowUpPageSize,
+				"after": pageInfo.EndCursor,
+			}
+			if err := c.query(ctx, threadCommentsQuery, variables, &data); err != nil {
+				return fmt.Errorf("failed to list review comments of PR #%d: %w", pr.Number, err)
+			}
+			thread.Comments.Nodes = append(thread.Comments.Nodes, data.Node.Comments.Nodes...)
+			pageInfo = data.Node.Comments.PageInfo
+		}
+	}
+
+	return nil
+}
+
+// queryPage runs a list query with the current page size as $first. Queries that exceed
+// GitHub's resource limits are retried with half the page size, and the page size is
+// scaled towards graphqlTargetCost using the cost reported by the query.
+func (c *graphqlClient) queryPage(ctx context.Context, query string, variables map[string]any, data any, cost func() int) error {
+	for {
+		c.mu.Lock()
+		pageSize := c.pageSize
+		c.mu.Unlock()
+
+		variables["first"] = pageSize
+		err := c.query(ctx, query, variables, data)
+		if err != nil {
+			if isResourceLimit(err) && pageSize > graphqlMinPageSize {
+				c.setPageSize(pageSize / 2)
+				continue
+			}
+			return err
+		}
+
+		if spent := cost(); spent > 0 {
+			c.setPageSize(pageSize * graphqlTargetCost / spent)
+		}
+		return nil
+	}
+}
+
+// setPageSize changes the page size of list queries, within the allowed bounds
+func (c *graphqlClient) setPageSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pageSize = max(graphqlMinPageSize, min(graphqlMaxPageSize, size))
+}
+
+// query runs a GraphQL query and decodes its data into data
+func (c *graphqlClient) query(ctx context.Context, query string, variables map[string]any, data any) error {
+	req, err := c.client.NewRequest(http.MethodPost, graphqlURL(c.client.BaseURL), map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create GraphQL request: %w", err)
+	}
+
+	var response struct {
+		Data   any           `json:"data"`
+		Errors graphqlErrors `json:"errors"`
+	}
+	response.Data = data
+	if _, err := c.client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		return response.Errors
+	}
+
+	return nil
+}
+
+// graphqlURL returns the GraphQL endpoint next to a REST API base URL. GitHub Enterprise
+// serves REST under /api/v3/ and GraphQL at /api/graphql.
+func graphqlURL(base *url.URL) string {
+	if strings.HasSuffix(base.Path, "/api/v3/") {
+		return base.ResolveReference(&url.URL{Path: strings.TrimSuffix(base.Path, "v3/") + "graphql"}).String()
+	}
+	return base.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
+
+// isResourceLimit reports whether a query failed because it asked for too much at once:
+// GitHub rejects queries over its node limit and times out on expensive ones
+func isResourceLimit(err error) bool {
+	var queryErrs graphqlErrors
+	if errors.As(err, &queryErrs) {
+		for _, queryErr := range queryErrs {
+			if queryErr.Type == "MAX_NODE_LIMIT_EXCEEDED" || queryErr.Type == "RESOURCE_LIMITS_EXCEEDED" {
+				return true
+			}
+		}
+	}
+
+	var responseErr *github.ErrorResponse
+	if errors.As(err, &responseErr) && responseErr.Response != nil {
+		switch responseErr.Response.StatusCode {
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// convertGraphQLPullRequest maps a GraphQL pull request to the REST type the extractor reads
+func convertGraphQLPullRequest(pr *graphqlPullRequest) *github.PullRequest {
+	// REST has no merged state; merged pull requests are closed
+	state := strings.ToLower(pr.State)
+	if state == "merged" {
+		state = "closed"
+	}
+
+	createdAt := pr.CreatedAt
+	converted := &github.PullRequest{
+		Number:       github.Int(pr.Number),
+		Title:        github.String(pr.Title),
+		HTMLURL:      github.String(pr.URL),
+		State:        github.String(state),
+		Draft:        github.Bool(pr.IsDraft),
+		Merged:       github.Bool(pr.Merged),
+		CreatedAt:    &createdAt,
+		MergedAt:     pr.MergedAt,
+		ClosedAt:     pr.ClosedAt,
+		User:         convertGraphQLActor(pr.Author),
+		Base:         &github.PullRequestBranch{Ref: github.String(pr.BaseRefName), SHA: github.String(pr.BaseRefOID)},
+		Head:         &github.PullRequestBranch{Ref: github.String(pr.HeadRefName), SHA: github.String(pr.HeadRefOID)},
+		Additions:    github.Int(pr.Additions),
+		Deletions:    github.Int(pr.Deletions),
+		ChangedFiles: github.Int(pr.ChangedFiles),
+	}
+	for _, label := range pr.Labels.Nodes {
+		converted.Labels = append(converted.Labels, &github.Label{Name: github.String(label.Name)})
+	}
+	return converted
+}
+
+// convertGraphQLReview maps a GraphQL review to the REST type
+func convertGraphQLReview(review *graphqlReview) *github.PullRequestReview {
+	return &github.PullRequestReview{
+		ID:          github.Int64(review.DatabaseID),
+		State:       github.String(review.State),
+		Body:        github.String(review.Body),
+		SubmittedAt: review.SubmittedAt,
+		User:        convertGraphQLActor(review.Author),
+	}
+}
+
+// convertGraphQLComment maps a GraphQL review comment to the REST type. The diff side
+// belongs to the thread in GraphQL.
+func convertGraphQLComment(comment *graphqlComment, side string) *github.PullRequestComment {
+	createdAt := comment.CreatedAt
+	converted := &github.PullRequestComment{
+		ID:                github.Int64(comment.DatabaseID),
+		Body:              github.String(comment.Body),
+		CreatedAt:         &createdAt,
+		Path:              github.String(comment.Path),
+		Line:              comment.Line,
+		StartLine:         comment.StartLine,
+		OriginalLine:      comment.OriginalLine,
+		OriginalStartLine: comment.OriginalStartLine,
+		User:              convertGraphQLActor(comment.Author),
+	}
+	if side != "" {
+		converted.Side = github.String(side)
+	}
+	if comment.Commit != nil {
+		converted.CommitID = github.String(comment.Commit.OID)
+	}
+	if comment.OriginalCommit != nil {
+		converted.OriginalCommitID = github.String(comment.OriginalCommit.OID)
+	}
+	if comment.PullRequestReview != nil {
+		converted.PullRequestReviewID = github.Int64(comment.PullRequestReview.DatabaseID)
+	}
+	return converted
+}
+
+// convertGraphQLActor maps an author to a REST user, nil for deleted users
+func convertGraphQLActor(actor *graphqlActor) *github.User {
+	if actor == nil {
+		return nil
+	}
+	return &github.User{Login: github.String(actor.Login)}
+}
@@ -0,0 +1,252 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// graphqlRequest is a query received by the GraphQL stand-in
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// newGraphQLServer starts a local GraphQL stand-in answering queries with handle, and
+// serving diffs and comparisons over REST
+func newGraphQLServer(t *testing.T, handle func(req graphqlRequest) string) *graphqlClient {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		var req graphqlRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		fmt.Fprint(w, handle(req))
+	})
+	mux.HandleFunc("/repos/acme/api/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n package main\n-var a = 1\n+var a = 2\n")
+	})
+	mux.HandleFunc("/repos/acme/api/compare/base000...old111", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"files":[{"filename":"main.go","status":"modified","patch":"@@ -1,2 +1,2 @@\n package main\n-var a = 1\n+var a = 3"}]}`)
+	})
+	return newGraphQLClient(newFakeServer(t, mux))
+}
+
+// graphqlPR renders a pull request node; reviews and threads are JSON connections
+func graphqlPR(number int, reviews, threads string) string {
+	return fmt.Sprintf(`{
+		"number": %d, "title": "PR %d", "url": "https://github.com/acme/api/pull/%d", "state": "MERGED",
+		"isDraft": false, "merged": true, "createdAt": "2024-05-01T10:00:00Z", "mergedAt": "2024-05-02T10:00:00Z",
+		"closedAt": "2024-05-02T10:00:00Z", "author": {"login": "alice"}, "baseRefName": "main",
+		"baseRefOid": "base000", "headRefName": "feature", "headRefOid": "abc123", "additions": 1, "deletions": 1, "changedFiles": 1,
+		"labels": {"nodes": [{"name": "bug"}]},
+		"reviews": %s, "reviewThreads": %s}`, number, number, number, reviews, threads)
+}
+
+const (
+	graphqlNoReviews = `{"pageInfo": {"hasNextPage": false}, "nodes": []}`
+	graphqlNoThreads = `{"pageInfo": {"hasNextPage": false}, "nodes": []}`
+)
+
+func TestGraphQLClient_GetPullRequests(t *testing.T) {
+	var firsts []any
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		switch {
+		case strings.Contains(req.Query, "pullRequests(first"):
+			firsts = append(firsts, req.Variables["first"])
+			if req.Variables["after"] == nil {
+				// The first page is expensive, so later pages are smaller
+				return `{"data": {"rateLimit": {"cost": 20}, "repository": {"pullRequests": {
+					"pageInfo": {"hasNextPage": true, "endCursor": "cursor-1"},
+					"nodes": [` + graphqlPR(2,
+					`{"pageInfo": {"hasNextPage": true, "endCursor": "reviews-1"}, "nodes": [
+						{"databaseId": 10, "state": "COMMENTED", "body": "", "submittedAt": "2024-05-01T11:00:00Z", "author": {"login": "bob"}}]}`,
+					`{"pageInfo": {"hasNextPage": false}, "nodes": [
+						{"id": "thread-1", "diffSide": "RIGHT", "comments": {"pageInfo": {"hasNextPage": true, "endCursor": "comments-1"}, "nodes": [
+							{"databaseId": 102, "body": "Use a constant", "createdAt": "2024-05-01T11:00:00Z", "path": "main.go",
+							 "line": 2, "originalLine": 2, "commit": {"oid": "abc123"}, "originalCommit": {"oid": "def456"},
+							 "author": {"login": "bob"}, "pullRequestReview": {"databaseId": 10}}]}}]}`) + `]}}}}`
+			}
+			assert.Equal(t, "cursor-1", req.Variables["after"])
+			return `{"data": {"rateLimit": {"cost": 1}, "repository": {"pullRequests": {
+				"pageInfo": {"hasNextPage": false},
+				"nodes": [` + graphqlPR(1, graphqlNoReviews, graphqlNoThreads) + `]}}}}`
+		case strings.Contains(req.Query, "reviews(first: $first"):
+			assert.Equal(t, "reviews-1", req.Variables["after"])
+			assert.Equal(t, float64(2), req.Variables["number"])
+			return `{"data": {"repository": {"pullRequest": {"reviews": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"databaseId": 11, "state": "APPROVED", "body": "LGTM", "submittedAt": "2024-05-01T12:00:00Z", "author": {"login": "carol"}}]}}}}}`
+		case strings.Contains(req.Query, "node(id: $id)"):
+			assert.Equal(t, "thread-1", req.Variables["id"])
+			return `{"data": {"node": {"comments": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"databaseId": 101, "body": "Agreed", "createdAt": "2024-05-01T12:00:00Z", "path": "main.go",
+				 "line": 2, "author": null, "pullRequestReview": {"databaseId": 11}}]}}}}`
+		}
+		t.Errorf("unexpected query: %s", req.Query)
+		return `{}`
+	})
+
+	prs, err := client.GetPullRequests(context.Background(), "acme", "api")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{float64(graphqlDefaultPageSize), float64(graphqlDefaultPageSize / 2)}, firsts)
+	assert.Len(t, prs, 2)
+	assert.Equal(t, 2, prs[0].GetNumber())
+	assert.Equal(t, "closed", prs[0].GetState())
+	assert.True(t, prs[0].GetMerged())
+	assert.Equal(t, "alice", prs[0].GetUser().GetLogin())
+	assert.Equal(t, "abc123", prs[0].GetHead().GetSHA())
+	assert.Equal(t, "bug", prs[0].Labels[0].GetName())
+
+	reviews, err := client.GetPullRequestReviews(context.Background(), "acme", "api", 2)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, int64(11), reviews[1].GetID())
+	assert.Equal(t, "APPROVED", reviews[1].GetState())
+
+	comments, err := client.GetPullRequestComments(context.Background(), "acme", "api", 2)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+	assert.Equal(t, int64(101), comments[0].GetID(), "comments are ordered by ID")
+	assert.Nil(t, comments[0].User, "deleted users have no author")
+	assert.Equal(t, int64(102), comments[1].GetID())
+	assert.Equal(t, "RIGHT", comments[1].GetSide())
+	assert.Equal(t, "def456", comments[1].GetOriginalCommitID())
+	assert.Equal(t, int64(10), comments[1].GetPullRequestReviewID())
+	assert.Equal(t, 2, comments[1].GetLine())
+}
+
+func TestGraphQLClient_ResourceLimits(t *testing.T) {
+	var firsts []any
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		firsts = append(firsts, req.Variables["first"])
+		if len(firsts) == 1 {
+			return `{"errors": [{"type": "MAX_NODE_LIMIT_EXCEEDED", "message": "too many nodes"}]}`
+		}
+		return `{"data": {"rateLimit": {"cost": 1}, "repository": {"pullRequests": {"pageInfo": {"hasNextPage": false}, "nodes": []}}}}`
+	})
+
+	prs, err := client.GetPullRequests(context.Background(), "acme", "api")
+	assert.NoError(t, err)
+	assert.Empty(t, prs)
+	assert.Equal(t, []any{float64(graphqlDefaultPageSize), float64(graphqlDefaultPageSize / 2)}, firsts)
+	assert.Equal(t, graphqlMaxPageSize, client.pageSize, "cheap queries grow the page size")
+}
+
+func TestGraphQLClient_Errors(t *testing.T) {
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		if strings.Contains(req.Query, "pullRequests(first") {
+			return `{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository"}]}`
+		}
+		return `{"data": {"repository": {"pullRequest": null}}}`
+	})
+
+	_, err := client.GetPullRequests(context.Background(), "acme", "missing")
+	assert.ErrorContains(t, err, "failed to list pull requests: GraphQL query failed: Could not resolve to a Repository")
+
+	_, err = client.GetPullRequestComments(context.Background(), "acme", "api", 7)
+	assert.ErrorContains(t, err, "pull request acme/api#7 not found")
+}
+
+func TestGraphQLClient_UncachedPullRequest(t *testing.T) {
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		assert.Contains(t, req.Query, "pullRequest(number: $number)")
+		assert.Equal(t, float64(3), req.Variables["number"])
+		return `{"data": {"repository": {"pullRequest": ` + graphqlPR(3,
+			`{"pageInfo": {"hasNextPage": false}, "nodes": [{"databaseId": 30, "state": "CHANGES_REQUESTED", "body": "Needs tests"}]}`,
+			graphqlNoThreads) + `}}}`
+	})
+
+	reviews, err := client.GetPullRequestReviews(context.Background(), "acme", "api", 3)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, "Needs tests", reviews[0].GetBody())
+}
+
+func TestGraphQLClient_GetGraphQLRateLimit(t *testing.T) {
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		assert.Contains(t, req.Query, "rateLimit { limit remaining resetAt }")
+		return `{"data": {"rateLimit": {"limit": 5000, "remaining": 4990, "resetAt": "2024-11-01T12:00:00Z"}}}`
+	})
+
+	rate, err := client.GetGraphQLRateLimit(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 5000, rate.Limit)
+	assert.Equal(t, 4990, rate.Remaining)
+	assert.Equal(t, time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC), rate.Reset.Time.UTC())
+}
+
+func TestExtractReviews_GraphQL(t *testing.T) {
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		return `{"data": {"rateLimit": {"cost": 1}, "repository": {"pullRequests": {
+			"pageInfo": {"hasNextPage": false},
+			"nodes": [` + graphqlPR(1,
+			`{"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"databaseId": 10, "state": "CHANGES_REQUESTED", "body": "Please fix", "submittedAt": "2024-05-01T11:00:00Z", "author": {"login": "bob"}}]}`,
+			`{"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"id": "thread-1", "diffSide": "RIGHT", "comments": {"pageInfo": {"hasNextPage": false}, "nodes": [
+					{"databaseId": 100, "body": "Why 2?", "createdAt": "2024-05-01T11:00:00Z", "path": "main.go",
+					 "line": 2, "author": {"login": "bob"}, "pullRequestReview": {"databaseId": 10}}]}}]}`) + `]}}}}`
+	})
+	extractor := &Extractor{client: &Client{client: client}, authenticated: true}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://github.com/acme/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, "Why 2?", reviews[0].CommentText)
+	assert.Equal(t, "main.go", reviews[0].FilePath)
+	assert.Equal(t, "10", reviews[0].ReviewID)
+	assert.Equal(t, "CHANGES_REQUESTED", string(reviews[0].ReviewState))
+	assert.NotEmpty(t, reviews[0].DiffContext, "diffs still come from the REST API")
+	assert.Equal(t, "Please fix", reviews[1].CommentText)
+	assert.True(t, reviews[1].PullRequest.Merged)
+}
+
+func TestExtractReviews_GraphQLOutdatedSuggestion(t *testing.T) {
+	client := newGraphQLServer(t, func(req graphqlRequest) string {
+		// The comment was made on commit old111, whose line was since rewritten
+		return `{"data": {"rateLimit": {"cost": 1}, "repository": {"pullRequests": {
+			"pageInfo": {"hasNextPage": false},
+			"nodes": [` + graphqlPR(1, graphqlNoReviews,
+			`{"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"id": "thread-1", "diffSide": "RIGHT", "comments": {"pageInfo": {"hasNextPage": false}, "nodes": [
+					{"databaseId": 100, "body": "`+"```suggestion\\nvar a = 4\\n```"+`", "createdAt": "2024-05-01T11:00:00Z",
+					 "path": "main.go", "line": null, "originalLine": 2, "originalCommit": {"oid": "old111"},
+					 "author": {"login": "bob"}}]}}]}`) + `]}}}}`
+	})
+	extractor := &Extractor{client: &Client{client: client}, authenticated: true}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://github.com/acme/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, []models.Suggestion{
+		{OriginalCode: "var a = 3", SuggestedCode: "var a = 4"},
+	}, reviews[0].Suggestions, "outdated suggestions are paired with the commit they were made on")
+}
+
+func TestGraphQLURL(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://api.github.com/":            "https://api.github.com/graphql",
+		"https://github.example.com/api/v3/": "https://github.example.com/api/graphql",
+		"http://127.0.0.1:8080/":             "http://127.0.0.1:8080/graphql",
+	} {
+		u, err := url.Parse(base)
+		assert.NoError(t, err)
+		assert.Equal(t, want, graphqlURL(u))
+	}
+}
+
+func TestWithGraphQL(t *testing.T) {
+	client := withGraphQL(NewClient("https://api.github.com/", models.Credential{Token: "token"}))
+	_, ok := client.(*Client).client.(*graphqlClient)
+	assert.True(t, ok)
+
+	mockClient := &MockClient{}
+	assert.Same(t, mockClient, withGraphQL(mockClient))
+}
@@ -12,4 +12,12 @@ type ClientInterface interface {
 	GetPullRequestComments(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestComment, error)
 	GetPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestReview, error)
 	GetPullRequestDiff(ctx context.Context, owner, repo string, number int) (string, error)
+	GetPullRequestCommits(ctx context.Context, owner, repo string, number int) ([]*github.RepositoryCommit, error)
+	CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, error)
+	GetAuthenticatedUser(ctx context.Context) (*github.User, error)
+	GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error)
+	CountPullRequests(ctx context.Context, owner, repo string) (int, *github.PullRequest, error)
+	GetRateLimit(ctx context.Context) (*github.Rate, error)
+	GetGraphQLRateLimit(ctx context.Context) (*github.Rate, error)
+	ListRepositories(ctx context.Context, owner string) ([]*github.Repository, error)
 }
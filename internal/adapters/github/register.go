@@ -0,0 +1,30 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+func init() {
+	core.Register(core.ProviderFactory{
+		Name:        models.ProviderGitHub,
+		Description: "GitHub and GitHub Enterprise",
+		Example:     "https://github.com/<owner>/<repo>",
+		AuthMethods: []models.AuthMethod{models.AuthBearer, models.AuthBasic, models.AuthAppPassword, models.AuthGitHubApp},
+		Settings:    []string{"github.api", "github.detect_addressed", "github.include_empty_reviews", "github.token"},
+		MatchURL: func(url string) bool {
+			return strings.TrimPrefix(credentials.Host(url), "www.") == "github.com"
+		},
+		ValidateURL: ValidateURL,
+		New: func(config *models.Config, source credentials.Source) core.Extractor {
+			return NewExtractor(source, Options{
+				DetectAddressed:     config.GitHub.DetectAddressed,
+				IncludeEmptyReviews: config.GitHub.IncludeEmptyReviews,
+				GraphQL:             config.GitHub.API == models.GitHubAPIGraphQL,
+			})
+		},
+	})
+}
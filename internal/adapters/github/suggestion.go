@@ -0,0 +1,138 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v45/github"
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// parseSuggestionBlocks returns the contents of all ```suggestion fences in a comment body
+func parseSuggestionBlocks(body string) []string {
+	var blocks []string
+	var current []string
+	var fence string
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimSpace(line)
+
+		if fence == "" {
+			ticks := len(trimmed) - len(strings.TrimLeft(trimmed, "`"))
+			if ticks >= 3 && strings.TrimSpace(trimmed[ticks:]) == "suggestion" {
+				fence = trimmed[:ticks]
+				current = []string{}
+			}
+			continue
+		}
+
+		// A fence is closed by at least as many backticks as it was opened with
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, "`") == "" {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			fence = ""
+			continue
+		}
+		current = append(current, line)
+	}
+
+	return blocks
+}
+
+// extractSuggestions pairs the suggestion blocks in a comment with the lines they replace
+func extractSuggestions(files []diff.File, body, filePath string, startLine, line int) []models.Suggestion {
+	blocks := parseSuggestionBlocks(body)
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	if startLine <= 0 {
+		startLine = line
+	}
+
+	var original string
+	if file := diff.FindFile(files, filePath); file != nil && line > 0 {
+		original = strings.Join(file.NewLines(startLine, line), "\n")
+	}
+
+	suggestions := make([]models.Suggestion, 0, len(blocks))
+	for _, block := range blocks {
+		suggestions = append(suggestions, models.Suggestion{
+			OriginalCode:  original,
+			SuggestedCode: block,
+		})
+	}
+
+	return suggestions
+}
+
+// suggestionFinder pairs the suggestions of the inline comments of a pull request with the
+// lines they replace. Outdated comments no longer have a line in the current diff, so, as for
+// addressed detection, their original lines are looked up in the diff of the commit they were
+// made on.
+type suggestionFinder struct {
+	client ClientInterface
+	owner  string
+	repo   string
+	// base is the base commit of the pull request
+	base  string
+	files []diff.File
+	// originals caches the diff of the pull request at the commits comments were made on
+	originals map[string][]diff.File
+}
+
+// newSuggestionFinder creates a finder for a pull request whose current diff is files
+func newSuggestionFinder(client ClientInterface, owner, repo string, pr *github.PullRequest, files []diff.File) *suggestionFinder {
+	return &suggestionFinder{
+		client:    client,
+		owner:     owner,
+		repo:      repo,
+		base:      pr.GetBase().GetSHA(),
+		files:     files,
+		originals: make(map[string][]diff.File),
+	}
+}
+
+// suggestions returns the suggestions of a comment
+func (f *suggestionFinder) suggestions(ctx context.Context, comment *github.PullRequestComment) ([]models.Suggestion, error) {
+	body := comment.GetBody()
+	outdated := comment.GetLine() <= 0 && comment.GetOriginalLine() > 0 && comment.GetOriginalCommitID() != ""
+	if !outdated || f.base == "" || len(parseSuggestionBlocks(body)) == 0 {
+		return extractSuggestions(f.files, body, comment.GetPath(), comment.GetStartLine(), comment.GetLine()), nil
+	}
+
+	files, err := f.original(ctx, comment.GetOriginalCommitID())
+	if err != nil {
+		return nil, err
+	}
+	return extractSuggestions(files, body, comment.GetPath(), comment.GetOriginalStartLine(), comment.GetOriginalLine()), nil
+}
+
+// original returns the diff of the pull request at a commit, caching the result
+func (f *suggestionFinder) original(ctx context.Context, commit string) ([]diff.File, error) {
+	if files, ok := f.originals[commit]; ok {
+		return files, nil
+	}
+
+	comparison, err := f.client.CompareCommits(ctx, f.owner, f.repo, f.base, commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %w", f.base, commit, err)
+	}
+
+	files := make([]diff.File, 0, len(comparison.Files))
+	for _, file := range comparison.Files {
+		oldPath := file.GetPreviousFilename()
+		if oldPath == "" {
+			oldPath = file.GetFilename()
+		}
+		files = append(files, diff.File{
+			OldPath: oldPath,
+			NewPath: file.GetFilename(),
+			Hunks:   diff.ParsePatch(file.GetPatch()),
+		})
+	}
+	f.originals[commit] = files
+	return files, nil
+}
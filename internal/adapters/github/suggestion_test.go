@@ -0,0 +1,132 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSuggestionBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "no suggestion",
+			body: "Looks good to me",
+			want: nil,
+		},
+		{
+			name: "single suggestion",
+			body: "Use a constant:\n```suggestion\nconst timeout = 300\n```\nThanks!",
+			want: []string{"const timeout = 300"},
+		},
+		{
+			name: "multi-line suggestion with CRLF",
+			body: "```suggestion\r\nfoo()\r\nbar()\r\n```",
+			want: []string{"foo()\nbar()"},
+		},
+		{
+			name: "deletion suggestion",
+			body: "```suggestion\n```",
+			want: []string{""},
+		},
+		{
+			name: "other code fences are ignored",
+			body: "```go\nfmt.Println()\n```\n```suggestion\nlog.Println()\n```",
+			want: []string{"log.Println()"},
+		},
+		{
+			name: "longer fence can contain backticks",
+			body: "````suggestion\n```go\ncode\n```\n````",
+			want: []string{"```go\ncode\n```"},
+		},
+		{
+			name: "unterminated fence",
+			body: "```suggestion\nfoo()",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseSuggestionBlocks(tt.body))
+		})
+	}
+}
+
+func TestExtractSuggestions(t *testing.T) {
+	files := diff.Parse(`diff --git a/auth.py b/auth.py
+--- a/auth.py
++++ b/auth.py
+@@ -40,4 +40,4 @@
+ def login(user):
+-    timeout = 30
++    timeout = 300
+     return authenticate(user)
+ 
+`)
+
+	body := "```suggestion\n    timeout = DEFAULT_TIMEOUT\n```"
+
+	assert.Equal(t, []models.Suggestion{
+		{OriginalCode: "    timeout = 300", SuggestedCode: "    timeout = DEFAULT_TIMEOUT"},
+	}, extractSuggestions(files, body, "auth.py", 0, 41))
+
+	assert.Equal(t, []models.Suggestion{
+		{OriginalCode: "def login(user):\n    timeout = 300", SuggestedCode: "    timeout = DEFAULT_TIMEOUT"},
+	}, extractSuggestions(files, body, "auth.py", 40, 41))
+
+	// Unknown files still yield the suggested code
+	assert.Equal(t, []models.Suggestion{
+		{OriginalCode: "", SuggestedCode: "    timeout = DEFAULT_TIMEOUT"},
+	}, extractSuggestions(files, body, "other.py", 0, 41))
+
+	assert.Nil(t, extractSuggestions(files, "No suggestion here", "auth.py", 0, 41))
+}
+
+func TestExtractReviews_OutdatedSuggestion(t *testing.T) {
+	compared := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"number":1,"title":"Test PR","user":{"login":"author"},"base":{"sha":"base"}}]`)
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		// Both comments were made on commit aaa, whose lines were since rewritten
+		fmt.Fprint(w, `[
+			{"id":10,"path":"auth.py","original_line":41,"original_commit_id":"aaa",
+			 "body":"`+"```suggestion\\n    timeout = DEFAULT_TIMEOUT\\n```"+`","user":{"login":"reviewer"}},
+			{"id":11,"path":"auth.py","original_start_line":40,"original_line":41,"original_commit_id":"aaa",
+			 "body":"`+"```suggestion\\ndef login(user, timeout):\\n```"+`","user":{"login":"reviewer"}}
+		]`)
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/test/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "diff --git a/auth.py b/auth.py\n--- a/auth.py\n+++ b/auth.py\n@@ -40,2 +40,2 @@\n def login(user):\n-    timeout = 30\n+    timeout = 600\n")
+	})
+	mux.HandleFunc("/repos/test/repo/compare/base...aaa", func(w http.ResponseWriter, r *http.Request) {
+		compared++
+		fmt.Fprint(w, `{"files":[{"filename":"auth.py","status":"modified","patch":"@@ -40,2 +40,2 @@\n def login(user):\n-    timeout = 30\n+    timeout = 300"}]}`)
+	})
+
+	extractor := &Extractor{client: newFakeServer(t, mux)}
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://github.com/test/repo")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, []models.Suggestion{
+		{OriginalCode: "    timeout = 300", SuggestedCode: "    timeout = DEFAULT_TIMEOUT"},
+	}, reviews[0].Suggestions)
+	assert.Equal(t, []models.Suggestion{
+		{OriginalCode: "def login(user):\n    timeout = 300", SuggestedCode: "def login(user, timeout):"},
+	}, reviews[1].Suggestions)
+	assert.Equal(t, 1, compared, "the diff of a commit is fetched once")
+}
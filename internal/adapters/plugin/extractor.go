@@ -0,0 +1,274 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// maxMessageSize bounds a single line of plugin output
+const maxMessageSize = 64 * 1024 * 1024
+
+// Extractor implements the core.Extractor interface by running an external plugin
+type Extractor struct {
+	config      models.PluginConfig
+	credentials credentials.Source
+	// stderr receives the plugin's diagnostics
+	stderr io.Writer
+}
+
+// NewExtractor creates an extractor for the provider of a plugin, passing it the
+// credentials of each repository
+func NewExtractor(config models.PluginConfig, source credentials.Source) *Extractor {
+	return &Extractor{
+		config:      config,
+		credentials: source,
+		stderr:      os.Stderr,
+	}
+}
+
+// errStopped ends reading the output of a plugin whose reviews are no longer wanted
+var errStopped = errors.New("stopped reading reviews")
+
+// ExtractReviews implements the core.Extractor interface
+func (e *Extractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	return core.Collect(e.StreamReviews(ctx, repoURL))
+}
+
+// StreamReviews implements the core.StreamExtractor interface, yielding each review as the
+// plugin writes it. Reviews are linked to the pull requests the plugin sent before them; the
+// provider and repository are filled in when the plugin leaves them out. The plugin is killed
+// if the consumer stops early.
+func (e *Extractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		repo, err := models.ParseRepositoryURL(repoURL)
+		if err != nil {
+			// Plugins may use URL schemes of their own; keep the URL as the identity
+			repo = models.RepositoryRef{Name: repoURL, URL: repoURL}
+		}
+
+		pullRequests := make(map[int]*models.PullRequest)
+		stopped := false
+		_, err = e.run(ctx, MethodExtract, repoURL, func(message Message) error {
+			switch message.Type {
+			case MessagePullRequest:
+				pr := message.PullRequest
+				if pr == nil {
+					return fmt.Errorf("pull_request message without a pull request")
+				}
+				if pr.Provider == "" {
+					pr.Provider = e.config.Provider
+				}
+				if pr.Repository.IsZero() {
+					pr.Repository = repo
+				}
+				pullRequests[pr.Number] = pr
+			case MessageReview:
+				review := message.Review
+				if review == nil {
+					return fmt.Errorf("review message without a review")
+				}
+				if !yield(e.completeReview(*review, repo, pullRequests[review.PRID]), nil) {
+					stopped = true
+					return errStopped
+				}
+			default:
+				return fmt.Errorf("unexpected %q message", message.Type)
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			yield(models.Review{}, err)
+		}
+	}
+}
+
+// completeReview fills in what a plugin may leave out of a review from its pull request and
+// the repository
+func (e *Extractor) completeReview(review models.Review, repo models.RepositoryRef, pr *models.PullRequest) models.Review {
+	if review.Provider == "" {
+		review.Provider = e.config.Provider
+	}
+	if review.Repo.IsZero() {
+		review.Repo = repo
+		if pr != nil {
+			review.Repo = pr.Repository
+		}
+	}
+	if review.Repository == "" {
+		review.Repository = review.Repo.Name
+	}
+	if pr != nil {
+		review.PullRequest = pr
+		if review.PRTitle == "" {
+			review.PRTitle = pr.Title
+		}
+		if review.PRAuthor == "" {
+			review.PRAuthor = pr.Author
+		}
+	}
+	return review
+}
+
+// Plan implements the core.Planner interface by asking the plugin to list the pull requests
+// of a repository. Plugins may estimate the requests a full extraction needs.
+func (e *Extractor) Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error) {
+	count := 0
+	done, err := e.run(ctx, MethodListPullRequests, repoURL, func(message Message) error {
+		if message.Type != MessagePullRequest {
+			return fmt.Errorf("unexpected %q message", message.Type)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RepositoryPlan{
+		URL:            repoURL,
+		Provider:       e.config.Provider,
+		PullRequests:   count,
+		EstimatedCalls: done.EstimatedCalls,
+	}, nil
+}
+
+// run starts the plugin, sends it a request and passes every message up to the final done
+// message to handle. An error message from the plugin is returned as an *Error.
+func (e *Extractor) run(ctx context.Context, method, repoURL string, handle func(Message) error) (Message, error) {
+	request := Request{
+		ProtocolVersion: ProtocolVersion,
+		Method:          method,
+		Repository:      Repository{Provider: e.config.Provider, URL: repoURL},
+		Credential:      e.credential(repoURL),
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.config.Command, e.config.Args...)
+	cmd.Env = os.Environ()
+	for _, name := range sortedKeys(e.config.Env) {
+		cmd.Env = append(cmd.Env, name+"="+e.config.Env[name])
+	}
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stderr = e.stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to start plugin %s: %w", e.config.Command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return Message{}, fmt.Errorf("failed to start plugin %s: %w", e.config.Command, err)
+	}
+
+	final, readErr := readMessages(stdout, handle)
+	if readErr != nil {
+		// Stop a plugin that is still writing rather than waiting for it
+		cancel()
+	}
+	// Drain anything written after the final message so the plugin can exit
+	_, _ = io.Copy(io.Discard, stdout)
+	waitErr := cmd.Wait()
+
+	var pluginErr *Error
+	switch {
+	case errors.As(readErr, &pluginErr):
+		return Message{}, fmt.Errorf("plugin %s: %w", e.config.Command, readErr)
+	case readErr != nil:
+		return Message{}, fmt.Errorf("plugin %s: protocol error: %w", e.config.Command, readErr)
+	case waitErr != nil:
+		return Message{}, fmt.Errorf("plugin %s: %w", e.config.Command, waitErr)
+	case final == nil:
+		return Message{}, fmt.Errorf("plugin %s: exited without a done message", e.config.Command)
+	}
+	return *final, nil
+}
+
+// readMessages decodes plugin output line by line until a done or error message. It returns
+// the done message, or nil if the output ended before one.
+func readMessages(r io.Reader, handle func(Message) error) (*Message, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var message Message
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch message.Type {
+		case MessageDone:
+			return &message, nil
+		case MessageError:
+			if message.Error == nil {
+				return nil, fmt.Errorf("line %d: error message without an error", line)
+			}
+			return nil, message.Error
+		}
+		if err := handle(message); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// explicitSource is implemented by credential sources that can leave out fallback tokens
+// meant for other providers
+type explicitSource interface {
+	Explicit(provider models.Provider, repoURL string) (models.Credential, bool)
+}
+
+// credential returns the credential configured for a repository, or nil if there is none.
+// Plugins are external programs, so they only receive credentials scoped to their provider,
+// host or repository, never the api_token fallback.
+func (e *Extractor) credential(repoURL string) *Credential {
+	if e.credentials == nil {
+		return nil
+	}
+	lookup := e.credentials.Credential
+	if explicit, ok := e.credentials.(explicitSource); ok {
+		lookup = explicit.Explicit
+	}
+	credential, ok := lookup(e.config.Provider, repoURL)
+	if !ok || credential.IsZero() {
+		return nil
+	}
+	return &Credential{
+		Method:   credential.AuthMethod(),
+		Token:    credential.Token,
+		Username: credential.Username,
+		Password: credential.Password,
+	}
+}
+
+// sortedKeys returns the keys of a map in order so plugins see a stable environment
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,186 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const scenarioVariable = "REVIEW_EXTRACTOR_TEST_PLUGIN"
+
+// TestPluginProcess is not a test: it is the plugin the tests below run, by starting the
+// test binary again with the scenario to play in the environment
+func TestPluginProcess(t *testing.T) {
+	scenario := os.Getenv(scenarioVariable)
+	if scenario == "" {
+		return
+	}
+
+	var request Request
+	line, _ := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err := json.Unmarshal(line, &request); err != nil {
+		fmt.Println(`{"type": "error", "error": {"message": "bad request"}}`)
+		os.Exit(0)
+	}
+
+	switch scenario {
+	case "extract":
+		token := ""
+		if request.Credential != nil {
+			token = request.Credential.Token
+		}
+		fmt.Fprintln(os.Stderr, "starting", request.Method)
+		fmt.Println(`{"type": "pull_request", "pull_request": {"number": 7, "title": "Add retries", "author": "alice", "state": "open"}}`)
+		fmt.Println()
+		fmt.Printf(`{"type": "review", "review": {"pr_id": 7, "comment_id": "c1", "comment_author": "bob", "comment_text": %q, "file_path": "retry.go", "line_number": 3}}`+"\n", token)
+		fmt.Println(`{"type": "review", "review": {"pr_id": 8, "comment_id": "c2", "provider": "critic", "repo": {"host": "review.example.com", "name": "other"}}}`)
+		fmt.Println(`{"type": "done"}`)
+		fmt.Println(`ignored after done`)
+	case "list":
+		if request.Method != MethodListPullRequests {
+			fmt.Printf(`{"type": "error", "error": {"code": "unsupported", "message": "method %s"}}`+"\n", request.Method)
+			break
+		}
+		fmt.Println(`{"type": "pull_request", "pull_request": {"number": 1}}`)
+		fmt.Println(`{"type": "pull_request", "pull_request": {"number": 2}}`)
+		fmt.Println(`{"type": "done", "estimated_calls": 12}`)
+	case "error":
+		fmt.Println(`{"type": "error", "error": {"code": "not_found", "message": "no such repository"}}`)
+		os.Exit(2)
+	case "garbage":
+		fmt.Println(`{"type": "done"`)
+	case "crash":
+		fmt.Println(`{"type": "pull_request", "pull_request": {"number": 1}}`)
+		os.Exit(3)
+	case "endless":
+		fmt.Println(`{"type": "pull_request", "pull_request": {"number": 1}}`)
+		for i := 0; ; i++ {
+			fmt.Printf(`{"type": "review", "review": {"pr_id": 1, "comment_id": "c%d"}}`+"\n", i)
+		}
+	case "silent":
+	}
+	os.Exit(0)
+}
+
+// newTestExtractor returns an extractor running the test binary as a plugin
+func newTestExtractor(scenario string, source credentials.Source) (*Extractor, *bytes.Buffer) {
+	extractor := NewExtractor(models.PluginConfig{
+		Provider: "critic",
+		Command:  os.Args[0],
+		Args:     []string{"-test.run=^TestPluginProcess$"},
+		Env:      map[string]string{scenarioVariable: scenario},
+	}, source)
+	var stderr bytes.Buffer
+	extractor.stderr = &stderr
+	return extractor, &stderr
+}
+
+func TestExtractReviews(t *testing.T) {
+	source := credentials.NewResolver(&models.Config{
+		Credentials: []models.Credential{{Provider: "critic", Token: "secret"}},
+	})
+	extractor, stderr := newTestExtractor("extract", source)
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://review.example.com/tools/api")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, "starting extract\n", stderr.String())
+
+	repo := models.RepositoryRef{Host: "review.example.com", Owner: "tools", Name: "api", URL: "https://review.example.com/tools/api"}
+	first := reviews[0]
+	assert.Equal(t, "secret", first.CommentText, "the plugin receives the repository's credential")
+	assert.Equal(t, models.Provider("critic"), first.Provider)
+	assert.Equal(t, repo, first.Repo)
+	assert.Equal(t, "api", first.Repository)
+	assert.Equal(t, "Add retries", first.PRTitle)
+	assert.Equal(t, "alice", first.PRAuthor)
+	assert.Equal(t, "retry.go", first.FilePath)
+	assert.Equal(t, &models.PullRequest{
+		Number:     7,
+		Repository: repo,
+		Provider:   "critic",
+		Title:      "Add retries",
+		Author:     "alice",
+		State:      "open",
+	}, first.PullRequest)
+
+	second := reviews[1]
+	assert.Nil(t, second.PullRequest, "reviews of pull requests that were not sent are kept unlinked")
+	assert.Equal(t, models.RepositoryRef{Host: "review.example.com", Name: "other"}, second.Repo, "what the plugin sets is kept")
+	assert.Equal(t, "other", second.Repository)
+}
+
+func TestExtractReviews_FallbackTokenNotSent(t *testing.T) {
+	source := credentials.NewResolver(&models.Config{
+		APIToken: "github-secret",
+		GitHub:   models.GitHubConfig{Token: "github-secret"},
+	})
+	extractor, _ := newTestExtractor("extract", source)
+
+	reviews, err := extractor.ExtractReviews(context.Background(), "https://review.example.com/tools/api")
+	assert.NoError(t, err)
+	assert.Empty(t, reviews[0].CommentText, "the plugin receives no credential")
+}
+
+func TestStreamReviews_StopEarly(t *testing.T) {
+	extractor, _ := newTestExtractor("endless", nil)
+
+	var ids []string
+	for review, err := range extractor.StreamReviews(context.Background(), "https://review.example.com/tools/api") {
+		assert.NoError(t, err)
+		ids = append(ids, review.CommentID)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"c0", "c1", "c2"}, ids, "the plugin is stopped without an error")
+}
+
+func TestPlan(t *testing.T) {
+	extractor, _ := newTestExtractor("list", nil)
+
+	plan, err := extractor.Plan(context.Background(), "https://review.example.com/tools/api")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryPlan{
+		URL:            "https://review.example.com/tools/api",
+		Provider:       "critic",
+		PullRequests:   2,
+		EstimatedCalls: 12,
+	}, plan)
+
+	_, err = extractor.ExtractReviews(context.Background(), "https://review.example.com/tools/api")
+	var pluginErr *Error
+	assert.ErrorAs(t, err, &pluginErr)
+	assert.Equal(t, ErrorCodeUnsupported, pluginErr.Code)
+}
+
+func TestExtractReviews_Errors(t *testing.T) {
+	tests := []struct {
+		scenario string
+		err      string
+	}{
+		{scenario: "error", err: "not_found: no such repository"},
+		{scenario: "garbage", err: "protocol error: line 1: unexpected end of JSON input"},
+		{scenario: "crash", err: "exit status 3"},
+		{scenario: "silent", err: "exited without a done message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			extractor, _ := newTestExtractor(tt.scenario, nil)
+			_, err := extractor.ExtractReviews(context.Background(), "https://review.example.com/tools/api")
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	extractor := NewExtractor(models.PluginConfig{Provider: "critic", Command: "/nonexistent/plugin"}, nil)
+	_, err := extractor.ExtractReviews(context.Background(), "https://review.example.com/tools/api")
+	assert.ErrorContains(t, err, "failed to start plugin /nonexistent/plugin")
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import "github.com/jesper/review-extractor/pkg/models"
+
+// ProtocolVersion is the version of the plugin protocol described in docs/plugin-protocol.md
+const ProtocolVersion = 1
+
+// Methods a plugin is asked to run
+const (
+	MethodListPullRequests = "list_pull_requests"
+	MethodExtract          = "extract"
+)
+
+// Types of the messages a plugin writes
+const (
+	MessagePullRequest = "pull_request"
+	MessageReview      = "review"
+	MessageError       = "error"
+	MessageDone        = "done"
+)
+
+// ErrorCodeUnsupported is reported by plugins that do not implement a method
+const ErrorCodeUnsupported = "unsupported"
+
+// Request is the single line written to a plugin's stdin
+type Request struct {
+	ProtocolVersion int         `json:"protocol_version"`
+	Method          string      `json:"method"`
+	Repository      Repository  `json:"repository"`
+	Credential      *Credential `json:"credential,omitempty"`
+}
+
+// Repository identifies the repository a request is about
+type Repository struct {
+	Provider models.Provider `json:"provider"`
+	URL      string          `json:"url"`
+}
+
+// Credential is the credential configured for the repository, with secrets read from files
+// and commands already resolved
+type Credential struct {
+	Method   models.AuthMethod `json:"method"`
+	Token    string            `json:"token,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+}
+
+// Message is one line written to a plugin's stdout. Type selects which of the other fields
+// is set.
+type Message struct {
+	Type        string              `json:"type"`
+	PullRequest *models.PullRequest `json:"pull_request,omitempty"`
+	Review      *models.Review      `json:"review,omitempty"`
+	Error       *Error              `json:"error,omitempty"`
+	// EstimatedCalls optionally accompanies the done message of list_pull_requests
+	EstimatedCalls int `json:"estimated_calls,omitempty"`
+}
+
+// Error is an error reported by a plugin
+type Error struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error formats the error as "code: message"
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return e.Code + ": " + e.Message
+}
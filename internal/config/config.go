@@ -0,0 +1,491 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity classifies a configuration problem
+type Severity = models.Severity
+
+const (
+	SeverityError   = models.SeverityError
+	SeverityWarning = models.SeverityWarning
+)
+
+// Problem is a single issue found in a configuration file
+type Problem = models.Problem
+
+// ValidationError reports all errors found in a configuration file
+type ValidationError = models.ValidationError
+
+// URLValidator checks that a repository URL can be handled by a provider's adapter
+type URLValidator func(url string) error
+
+// Options configures how configuration files are loaded and validated
+type Options struct {
+	// URLValidators lists the supported providers and how to check their repository URLs
+	URLValidators map[models.Provider]URLValidator
+	// AuthMethods lists the credential methods each provider accepts; providers missing
+	// from it accept any method
+	AuthMethods map[models.Provider][]models.AuthMethod
+	// InferProvider returns the provider of a repository URL configured without one, or an
+	// empty provider if it cannot tell. Without it, the provider is required.
+	InferProvider func(url string) (models.Provider, error)
+	// StrictSecrets rejects tokens written literally into the configuration
+	StrictSecrets bool
+	// BaseDir is the directory token files and commands are relative to; Load uses the
+	// directory of the configuration file
+	BaseDir string
+}
+
+// Load reads a configuration file, decodes it strictly and validates it. All problems
+// found are returned; if any of them is an error, a *ValidationError is returned as well.
+func Load(path string, opts Options) (*models.Config, []Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if opts.BaseDir == "" {
+		opts.BaseDir = filepath.Dir(path)
+	}
+
+	config, problems, err := Parse(data, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hasErrors(problems) {
+		return config, problems, &ValidationError{Path: path, Problems: problems}
+	}
+	return config, problems, nil
+}
+
+// Parse decodes and validates configuration data, expanding ${VAR} references to
+// environment variables and reading tokens from files and commands. Syntax errors are
+// returned as an error; everything else is reported as problems.
+func Parse(data []byte, opts Options) (*models.Config, []Problem, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var config models.Config
+	var problems []Problem
+
+	// Decode strictly so that typos in field names are reported instead of ignored. Type
+	// errors are reported by the second decode, once environment variables are expanded.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		for _, msg := range typeErr.Errors {
+			if unknownFieldPattern.MatchString(msg) {
+				problems = append(problems, decodeProblem(msg))
+			}
+		}
+	}
+
+	if opts.StrictSecrets {
+		problems = append(problems, checkLiteralSecrets(&config, document(&root))...)
+	}
+
+	problems = append(problems, expandEnv(&root)...)
+	config = models.Config{}
+	if root.Kind != 0 {
+		if err := root.Decode(&config); err != nil {
+			var typeErr *yaml.TypeError
+			if !errors.As(err, &typeErr) {
+				return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+			}
+			for _, msg := range typeErr.Errors {
+				problems = append(problems, decodeProblem(msg))
+			}
+		}
+	}
+
+	problems = append(problems, resolveSecrets(&config, document(&root), opts.BaseDir)...)
+	resolvePluginCommands(&config, opts.BaseDir)
+	problems = append(problems, validate(&config, document(&root), opts)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return &config, problems, nil
+}
+
+// hasErrors reports whether any of the problems is an error
+func hasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeLinePattern matches the "line N: " prefix of yaml.v3 decode errors
+var decodeLinePattern = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// unknownFieldPattern matches yaml.v3 errors about fields missing from the target type
+var unknownFieldPattern = regexp.MustCompile(`^line \d+: field \S+ not found in type `)
+
+// decodeProblem converts a yaml.v3 decode error message into a problem
+func decodeProblem(msg string) Problem {
+	if m := decodeLinePattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Problem{Line: line, Severity: SeverityError, Message: m[2]}
+	}
+	return Problem{Severity: SeverityError, Message: msg}
+}
+
+// validate checks the decoded configuration for semantic problems
+func validate(config *models.Config, root *yaml.Node, opts Options) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, severity Severity, format string, args ...any) {
+		problems = append(problems, Problem{Line: line(node), Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Plugins add providers, which accept any repository URL; the plugin checks them itself
+	problems = append(problems, validatePlugins(config, root, opts)...)
+	opts = withPlugins(opts, config)
+
+	reposNode := field(root, "repositories")
+	if len(config.Repositories) == 0 && len(config.Discover) == 0 {
+		add(reposNode, SeverityError, "no repositories configured")
+	}
+
+	seen := make(map[string]int)
+	for i, repo := range config.Repositories {
+		node := item(reposNode, i)
+
+		if repo.URL == "" {
+			add(node, SeverityError, "repositories[%d]: url is required", i)
+		}
+
+		if repo.Provider == "" && repo.URL != "" && opts.InferProvider != nil {
+			provider, err := opts.InferProvider(repo.URL)
+			if err != nil {
+				add(field(node, "url"), SeverityError, "repositories[%d]: provider is required: %v", i, err)
+				continue
+			}
+			repo.Provider = provider
+			config.Repositories[i].Provider = provider
+		}
+
+		validateURL, supported := opts.URLValidators[repo.Provider]
+		switch {
+		case repo.Provider == "" && repo.URL != "" && opts.InferProvider != nil:
+			add(node, SeverityError, "repositories[%d]: provider is required; it cannot be inferred from %q", i, repo.URL)
+		case repo.Provider == "":
+			add(node, SeverityError, "repositories[%d]: provider is required", i)
+		case !supported:
+			add(field(node, "provider"), SeverityError, "repositories[%d]: unsupported provider %q (supported: %s)",
+				i, repo.Provider, supportedProviders(opts))
+		case repo.URL != "" && validateURL != nil:
+			if err := validateURL(repo.URL); err != nil {
+				add(field(node, "url"), SeverityError, "repositories[%d]: invalid %s URL %q: %v", i, repo.Provider, repo.URL, err)
+			}
+		}
+
+		if repo.URL == "" {
+			continue
+		}
+		key := string(repo.Provider) + " " + normalizeURL(repo.URL)
+		if first, ok := seen[key]; ok {
+			add(field(node, "url"), SeverityError, "repositories[%d]: duplicate of repositories[%d] (line %d)",
+				i, first, line(field(item(reposNode, first), "url")))
+			continue
+		}
+		seen[key] = i
+	}
+
+	switch config.GitHub.API {
+	case "", models.GitHubAPIREST, models.GitHubAPIGraphQL:
+	default:
+		add(field(field(root, "github"), "api"), SeverityError, "github.api: unsupported API %q (supported: %s, %s)",
+			config.GitHub.API, models.GitHubAPIREST, models.GitHubAPIGraphQL)
+	}
+
+	problems = append(problems, validateDiscovery(config, root, opts)...)
+	problems = append(problems, validateCredentials(config, root, opts)...)
+
+	return problems
+}
+
+// validatePlugins checks the plugins section. Plugins cannot replace a built-in provider.
+func validatePlugins(config *models.Config, root *yaml.Node, opts Options) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: line(node), Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+	}
+
+	pluginsNode := field(root, "plugins")
+	seen := make(map[models.Provider]int)
+	for i, plugin := range config.Plugins {
+		node := item(pluginsNode, i)
+
+		if plugin.Command == "" {
+			add(node, "plugins[%d]: command is required", i)
+		}
+
+		if _, builtIn := opts.URLValidators[plugin.Provider]; plugin.Provider == "" {
+			add(node, "plugins[%d]: provider is required", i)
+		} else if builtIn {
+			add(field(node, "provider"), "plugins[%d]: provider %q is built in", i, plugin.Provider)
+		} else if first, ok := seen[plugin.Provider]; ok {
+			add(field(node, "provider"), "plugins[%d]: provider %q is already provided by plugins[%d]", i, plugin.Provider, first)
+		} else {
+			seen[plugin.Provider] = i
+		}
+	}
+
+	return problems
+}
+
+// withPlugins returns options that also support the providers of the configured plugins
+func withPlugins(opts Options, config *models.Config) Options {
+	if len(config.Plugins) == 0 {
+		return opts
+	}
+
+	validators := make(map[models.Provider]URLValidator, len(opts.URLValidators)+len(config.Plugins))
+	for _, plugin := range config.Plugins {
+		if plugin.Provider != "" {
+			validators[plugin.Provider] = nil
+		}
+	}
+	for provider, validate := range opts.URLValidators {
+		validators[provider] = validate
+	}
+	opts.URLValidators = validators
+	return opts
+}
+
+// resolvePluginCommands makes plugin commands given as a path relative to baseDir; bare
+// names are left to be looked up on PATH
+func resolvePluginCommands(config *models.Config, baseDir string) {
+	if baseDir == "" {
+		return
+	}
+	for i, plugin := range config.Plugins {
+		if strings.Contains(plugin.Command, "/") && !filepath.IsAbs(plugin.Command) {
+			config.Plugins[i].Command = filepath.Join(baseDir, plugin.Command)
+		}
+	}
+}
+
+// validateDiscovery checks the discovery entries
+func validateDiscovery(config *models.Config, root *yaml.Node, opts Options) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{Line: line(node), Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+	}
+
+	discoverNode := field(root, "discover")
+	for i, discovery := range config.Discover {
+		node := item(discoverNode, i)
+
+		if discovery.Org == "" {
+			add(node, "discover[%d]: org is required", i)
+		}
+
+		if _, supported := opts.URLValidators[discovery.Provider]; discovery.Provider == "" {
+			add(node, "discover[%d]: provider is required", i)
+		} else if !supported {
+			add(field(node, "provider"), "discover[%d]: unsupported provider %q (supported: %s)",
+				i, discovery.Provider, supportedProviders(opts))
+		}
+
+		for key, patterns := range map[string][]string{"include": discovery.Include, "exclude": discovery.Exclude} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					add(field(node, key), "discover[%d]: invalid %s pattern %q: %v", i, key, pattern, err)
+				}
+			}
+		}
+
+		if discovery.Credential != nil {
+			problems = append(problems, checkCredential(fmt.Sprintf("discover[%d].credential", i), *discovery.Credential, field(node, "credential"))...)
+		}
+	}
+
+	return problems
+}
+
+// validateCredentials checks the credentials section and repository credentials, and warns
+// about repositories that will be accessed without credentials
+func validateCredentials(config *models.Config, root *yaml.Node, opts Options) []Problem {
+	var problems []Problem
+	add := func(node *yaml.Node, severity Severity, format string, args ...any) {
+		problems = append(problems, Problem{Line: line(node), Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	credentialsNode := field(root, "credentials")
+	for i, credential := range config.Credentials {
+		node := item(credentialsNode, i)
+		name := fmt.Sprintf("credentials[%d]", i)
+		if credential.Host == "" && credential.Provider == "" {
+			add(node, SeverityError, "%s: host or provider is required", name)
+		}
+		problems = append(problems, checkCredential(name, credential, node)...)
+	}
+
+	reposNode := field(root, "repositories")
+	resolver := credentials.NewResolver(config)
+	// Hosts the repositories using each host-less provider entry are on
+	entryHosts := make(map[int]map[string]bool)
+	for i, repo := range config.Repositories {
+		node := item(reposNode, i)
+		if repo.Credential != nil {
+			problems = append(problems, checkCredential(fmt.Sprintf("repositories[%d].credential", i), *repo.Credential, field(node, "credential"))...)
+		}
+
+		// Repositories without a supported provider are already reported
+		if _, supported := opts.URLValidators[repo.Provider]; !supported {
+			continue
+		}
+
+		credential, ok := resolver.Credential(repo.Provider, repo.URL)
+		if !ok || credential.IsZero() {
+			add(node, SeverityWarning, "repositories[%d]: no credentials configured; requests will be unauthenticated and heavily rate limited", i)
+			continue
+		}
+		if methods, known := opts.AuthMethods[repo.Provider]; known && !slices.Contains(methods, credential.AuthMethod()) {
+			add(node, SeverityError, "repositories[%d]: %s does not support %s authentication", i, repo.Provider, credential.AuthMethod())
+		}
+		for j, entry := range config.Credentials {
+			if entry.Host == "" && entry == credential {
+				if entryHosts[j] == nil {
+					entryHosts[j] = make(map[string]bool)
+				}
+				entryHosts[j][credentials.Host(repo.URL)] = true
+			}
+		}
+	}
+
+	// A credential is usually valid on one host only; sending it to others leaks it
+	for i, credential := range config.Credentials {
+		if hosts := entryHosts[i]; len(hosts) > 1 {
+			names := make([]string, 0, len(hosts))
+			for host := range hosts {
+				names = append(names, host)
+			}
+			sort.Strings(names)
+			add(item(credentialsNode, i), SeverityWarning, "credentials[%d]: used for every %s host (%s); set host to limit it to one",
+				i, credential.Provider, strings.Join(names, ", "))
+		}
+	}
+
+	return problems
+}
+
+// checkCredential checks that a credential has the fields its method needs
+func checkCredential(name string, credential models.Credential, node *yaml.Node) []Problem {
+	var messages []string
+	switch credential.AuthMethod() {
+	case models.AuthBearer:
+		if credential.Token == "" {
+			messages = append(messages, "token is required for bearer authentication")
+		}
+	case models.AuthBasic, models.AuthAppPassword:
+		if credential.Username == "" {
+			messages = append(messages, fmt.Sprintf("username is required for %s authentication", credential.Method))
+		}
+		if credential.Password == "" {
+			messages = append(messages, fmt.Sprintf("password is required for %s authentication", credential.Method))
+		}
+	case models.AuthGitHubApp:
+		if credential.AppID == 0 {
+			messages = append(messages, "app_id is required for github_app authentication")
+		}
+		if credential.PrivateKey == "" {
+			messages = append(messages, "private_key is required for github_app authentication")
+		}
+	default:
+		messages = append(messages, fmt.Sprintf("unsupported method %q (supported: %s, %s, %s, %s)",
+			credential.Method, models.AuthBearer, models.AuthBasic, models.AuthAppPassword, models.AuthGitHubApp))
+	}
+
+	problems := make([]Problem, 0, len(messages))
+	for _, msg := range messages {
+		problems = append(problems, Problem{Line: line(node), Severity: SeverityError, Message: name + ": " + msg})
+	}
+	return problems
+}
+
+// supportedProviders lists the providers with a URL validator
+func supportedProviders(opts Options) string {
+	names := make([]string, 0, len(opts.URLValidators))
+	for provider := range opts.URLValidators {
+		names = append(names, string(provider))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// normalizeURL makes equivalent repository URLs compare equal
+func normalizeURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	url = strings.TrimPrefix(url, "https://")
+	url = strings.TrimPrefix(url, "http://")
+	return url
+}
+
+// document returns the top-level node of a parsed YAML document
+func document(root *yaml.Node) *yaml.Node {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		return root.Content[0]
+	}
+	return root
+}
+
+// field returns the value node of a key in a mapping node, or nil
+func field(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// item returns the i-th element of a sequence node, or nil
+func item(node *yaml.Node, i int) *yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+		return nil
+	}
+	return node.Content[i]
+}
+
+// line returns the line of a node, or 0 if it is missing
+func line(node *yaml.Node) int {
+	if node == nil {
+		return 0
+	}
+	return node.Line
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// envPattern matches ${VAR} references to environment variables
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${VAR} references in the scalar values of the node tree with the
+// values of environment variables, reporting references to variables that are not set
+func expandEnv(node *yaml.Node) []Problem {
+	if node == nil {
+		return nil
+	}
+
+	var problems []Problem
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			problems = append(problems, expandEnv(child)...)
+		}
+	case yaml.MappingNode:
+		// Only values are expanded; keys are field names
+		for i := 1; i < len(node.Content); i += 2 {
+			problems = append(problems, expandEnv(node.Content[i])...)
+		}
+	case yaml.ScalarNode:
+		if !envPattern.MatchString(node.Value) {
+			return nil
+		}
+		node.Value = envPattern.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			name := envPattern.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				problems = append(problems, Problem{
+					Line:     node.Line,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("environment variable %s is not set", name),
+				})
+			}
+			return value
+		})
+		// Let plain scalars resolve to the type of their expanded value, e.g. a bool
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+	return problems
+}
+
+// secret is a token field that can also be read from a file or a command
+type secret struct {
+	// parent is the path of the mapping holding the fields, empty for top-level fields
+	parent               []string
+	key, fileKey, cmdKey string
+	value, file, command *string
+}
+
+// name returns the name of the token field, e.g. credentials[0].token
+func (s secret) name() string {
+	var name strings.Builder
+	for _, key := range append(append([]string(nil), s.parent...), s.key) {
+		if _, err := strconv.Atoi(key); err == nil {
+			name.WriteString("[" + key + "]")
+			continue
+		}
+		if name.Len() > 0 {
+			name.WriteString(".")
+		}
+		name.WriteString(key)
+	}
+	return name.String()
+}
+
+// secrets lists the token and password fields of a configuration
+func secrets(config *models.Config) []secret {
+	list := []secret{
+		{
+			key:     "api_token",
+			fileKey: "api_token_file",
+			cmdKey:  "api_token_cmd",
+			value:   &config.APIToken,
+			file:    &config.APITokenFile,
+			command: &config.APITokenCmd,
+		},
+		{
+			parent:  []string{"github"},
+			key:     "token",
+			fileKey: "token_file",
+			cmdKey:  "token_cmd",
+			value:   &config.GitHub.Token,
+			file:    &config.GitHub.TokenFile,
+			command: &config.GitHub.TokenCmd,
+		},
+	}
+
+	for i := range config.Credentials {
+		list = append(list, credentialSecrets([]string{"credentials", strconv.Itoa(i)}, &config.Credentials[i])...)
+	}
+	for i, repo := range config.Repositories {
+		if repo.Credential != nil {
+			list = append(list, credentialSecrets([]string{"repositories", strconv.Itoa(i), "credential"}, repo.Credential)...)
+		}
+	}
+	for i, discovery := range config.Discover {
+		if discovery.Credential != nil {
+			list = append(list, credentialSecrets([]string{"discover", strconv.Itoa(i), "credential"}, discovery.Credential)...)
+		}
+	}
+
+	return list
+}
+
+// credentialSecrets lists the token, password and private key fields of a credential
+func credentialSecrets(parent []string, credential *models.Credential) []secret {
+	return []secret{
+		{
+			parent:  parent,
+			key:     "token",
+			fileKey: "token_file",
+			cmdKey:  "token_cmd",
+			value:   &credential.Token,
+			file:    &credential.TokenFile,
+			command: &credential.TokenCmd,
+		},
+		{
+			parent:  parent,
+			key:     "password",
+			fileKey: "password_file",
+			cmdKey:  "password_cmd",
+			value:   &credential.Password,
+			file:    &credential.PasswordFile,
+			command: &credential.PasswordCmd,
+		},
+		{
+			parent:  parent,
+			key:     "private_key",
+			fileKey: "private_key_file",
+			cmdKey:  "private_key_cmd",
+			value:   &credential.PrivateKey,
+			file:    &credential.PrivateKeyFile,
+			command: &credential.PrivateKeyCmd,
+		},
+	}
+}
+
+// checkLiteralSecrets reports token fields whose values are written into the configuration
+// file instead of being read from the environment, a file or a command. The environment of
+// plugins often carries their tokens, so its values must come from the environment too.
+func checkLiteralSecrets(config *models.Config, root *yaml.Node) []Problem {
+	var problems []Problem
+	for _, s := range secrets(config) {
+		node := field(fieldPath(root, s.parent), s.key)
+		if !isLiteral(node) {
+			continue
+		}
+		problems = append(problems, Problem{
+			Line:     node.Line,
+			Severity: SeverityError,
+			Message: fmt.Sprintf("%s contains a literal token, which strict secrets forbid; use ${ENV_VAR}, %s or %s",
+				s.name(), s.fileKey, s.cmdKey),
+		})
+	}
+
+	for i := range config.Plugins {
+		env := fieldPath(root, []string{"plugins", strconv.Itoa(i), "env"})
+		if env == nil || env.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(env.Content); j += 2 {
+			if node := env.Content[j+1]; isLiteral(node) {
+				problems = append(problems, Problem{
+					Line:     node.Line,
+					Severity: SeverityError,
+					Message: fmt.Sprintf("plugins[%d].env.%s contains a literal value, which strict secrets forbid; use ${ENV_VAR}",
+						i, env.Content[j].Value),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// isLiteral reports whether a scalar holds anything besides ${VAR} references
+func isLiteral(node *yaml.Node) bool {
+	if node == nil || node.Kind != yaml.ScalarNode || node.Value == "" {
+		return false
+	}
+	return strings.TrimSpace(envPattern.ReplaceAllString(node.Value, "")) != ""
+}
+
+// resolveSecrets reads tokens configured through a file or a command into their token fields
+func resolveSecrets(config *models.Config, root *yaml.Node, baseDir string) []Problem {
+	var problems []Problem
+	for _, s := range secrets(config) {
+		parent := fieldPath(root, s.parent)
+		add := func(key, format string, args ...any) {
+			problems = append(problems, Problem{
+				Line:     line(field(parent, key)),
+				Severity: SeverityError,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		set := 0
+		for _, v := range []string{*s.value, *s.file, *s.command} {
+			if v != "" {
+				set++
+			}
+		}
+		if set > 1 {
+			add(s.key, "%s: only one of %s, %s and %s may be set", s.name(), s.key, s.fileKey, s.cmdKey)
+			continue
+		}
+
+		switch {
+		case *s.file != "":
+			token, err := readTokenFile(*s.file, baseDir)
+			if err != nil {
+				add(s.fileKey, "%s: %v", s.name()+"_file", err)
+				continue
+			}
+			*s.value = token
+		case *s.command != "":
+			token, err := runTokenCommand(*s.command, baseDir)
+			if err != nil {
+				add(s.cmdKey, "%s: %v", s.name()+"_cmd", err)
+				continue
+			}
+			*s.value = token
+		}
+	}
+	return problems
+}
+
+// readTokenFile reads a token from a file, relative to baseDir unless absolute
+func readTokenFile(name, baseDir string) (string, error) {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(baseDir, name)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", name)
+	}
+	return token, nil
+}
+
+// runTokenCommand runs a shell command in baseDir and returns its output as the token
+func runTokenCommand(command, baseDir string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = baseDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("token command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("token command printed nothing")
+	}
+	return token, nil
+}
+
+// fieldPath follows a path of keys and sequence indices through nested nodes
+func fieldPath(node *yaml.Node, keys []string) *yaml.Node {
+	for _, key := range keys {
+		if i, err := strconv.Atoi(key); err == nil {
+			node = item(node, i)
+			continue
+		}
+		node = field(node, key)
+	}
+	return node
+}
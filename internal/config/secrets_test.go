@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_ExpandsEnvironment(t *testing.T) {
+	t.Setenv("REVIEW_TOKEN", "secret")
+	t.Setenv("REVIEW_ORG", "acme")
+	t.Setenv("REVIEW_DETECT", "true")
+
+	data := `github:
+  token: ${REVIEW_TOKEN}
+  detect_addressed: ${REVIEW_DETECT}
+repositories:
+  - provider: github
+    url: https://github.com/${REVIEW_ORG}/api
+`
+	config, problems, err := Parse([]byte(data), testOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, "secret", config.GitHub.Token)
+	assert.True(t, config.GitHub.DetectAddressed)
+	assert.Equal(t, "https://github.com/acme/api", config.Repositories[0].URL)
+}
+
+func TestParse_UnsetEnvironment(t *testing.T) {
+	data := `api_token: ${REVIEW_EXTRACTOR_UNSET_TOKEN}
+repositories:
+  - provider: github
+    url: https://github.com/acme/api
+`
+	_, problems, err := Parse([]byte(data), testOptions())
+	assert.NoError(t, err)
+	assert.Contains(t, problems, Problem{Line: 1, Severity: SeverityError, Message: "environment variable REVIEW_EXTRACTOR_UNSET_TOKEN is not set"})
+}
+
+func TestParse_TokenFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0600))
+
+	data := `github:
+  token_file: token
+repositories:
+  - provider: github
+    url: https://github.com/acme/api
+`
+	config, problems, err := Parse([]byte(data), Options{URLValidators: testOptions().URLValidators, BaseDir: dir})
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, "from-file", config.GitHub.Token)
+
+	_, problems, err = Parse([]byte("api_token_file: missing\n"+data[len("github:\n  token_file: token\n"):]), Options{BaseDir: dir})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "api_token_file: failed to read token file")
+}
+
+func TestParse_TokenCmd(t *testing.T) {
+	data := `github:
+  token_cmd: echo from-command
+repositories:
+  - provider: github
+    url: https://github.com/acme/api
+`
+	config, problems, err := Parse([]byte(data), testOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, "from-command", config.GitHub.Token)
+
+	data = `github:
+  token: literal
+  token_cmd: echo oops >&2; exit 3
+`
+	_, problems, err = Parse([]byte(data), testOptions())
+	assert.NoError(t, err)
+	assert.Contains(t, problems, Problem{Line: 2, Severity: SeverityError, Message: "github.token: only one of token, token_file and token_cmd may be set"})
+
+	data = `github:
+  token_cmd: echo oops >&2; exit 3
+`
+	_, problems, err = Parse([]byte(data), testOptions())
+	assert.NoError(t, err)
+	assert.Contains(t, problems, Problem{Line: 2, Severity: SeverityError, Message: "github.token_cmd: token command failed: exit status 3: oops"})
+}
+
+func TestParse_StrictSecrets(t *testing.T) {
+	t.Setenv("REVIEW_TOKEN", "secret")
+
+	data := `api_token: ghp_literal
+github:
+  token: ${REVIEW_TOKEN}
+repositories:
+  - provider: github
+    url: https://github.com/acme/api
+`
+	opts := testOptions()
+	_, problems, err := Parse([]byte(data), opts)
+	assert.NoError(t, err)
+	assert.Empty(t, problems, "literal tokens are allowed by default")
+
+	opts.StrictSecrets = true
+	_, problems, err = Parse([]byte(data), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []Problem{{
+		Line:     1,
+		Severity: SeverityError,
+		Message:  "api_token contains a literal token, which strict secrets forbid; use ${ENV_VAR}, api_token_file or api_token_cmd",
+	}}, problems)
+}
+
+func TestParse_StrictSecretsPluginEnv(t *testing.T) {
+	t.Setenv("TRACKER_TOKEN", "secret")
+
+	data := `plugins:
+  - provider: tracker
+    command: tracker-plugin
+    env:
+      TRACKER_TOKEN: ${TRACKER_TOKEN}
+      TRACKER_PASSWORD: hunter2
+repositories:
+  - provider: tracker
+    url: https://tracker.example.com/acme/api
+`
+	opts := testOptions()
+	opts.StrictSecrets = true
+	_, problems, err := Parse([]byte(data), opts)
+	assert.NoError(t, err)
+	assert.Contains(t, problems, Problem{
+		Line:     6,
+		Severity: SeverityError,
+		Message:  "plugins[0].env.TRACKER_PASSWORD contains a literal value, which strict secrets forbid; use ${ENV_VAR}",
+	})
+	for _, problem := range problems {
+		assert.NotContains(t, problem.Message, "TRACKER_TOKEN")
+	}
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+// Checker is implemented by extractors that can verify access to a repository before extracting it
+type Checker = provider.Checker
+
+// Check runs the preflight checks of every configured and discovered repository. Problems
+// are reported as failed checks rather than errors, so every repository is checked; only
+// failing to discover repositories is an error.
+func (e *ReviewExtractor) Check(ctx context.Context) ([]models.RepositoryCheck, error) {
+	repositories, err := e.Repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.RepositoryCheck, 0, len(repositories))
+	for _, repo := range repositories {
+		failed := func(name, detail string) models.RepositoryCheck {
+			return models.RepositoryCheck{
+				URL:      repo.URL,
+				Provider: repo.Provider,
+				Checks:   []models.Check{{Name: name, Status: models.CheckFailed, Detail: detail}},
+			}
+		}
+
+		extractor, ok := e.extractors[repo.Provider]
+		if !ok {
+			results = append(results, failed("provider", "no extractor available for provider: "+string(repo.Provider)))
+			continue
+		}
+
+		checker, ok := extractor.(Checker)
+		if !ok {
+			results = append(results, models.RepositoryCheck{
+				URL:      repo.URL,
+				Provider: repo.Provider,
+				Checks:   []models.Check{{Name: "provider", Status: models.CheckSkipped, Detail: "provider does not support preflight checks"}},
+			})
+			continue
+		}
+
+		result, err := checker.Check(ctx, repo.URL)
+		if err != nil {
+			results = append(results, failed("repository", err.Error()))
+			continue
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// CheckBudgets sums the requests the checked repositories need per rate limit budget, as
+// Plan does, with the rate limits the checks reported
+func (e *ReviewExtractor) CheckBudgets(results []models.RepositoryCheck) []models.ProviderPlan {
+	budgets := e.newBudgets()
+	for _, result := range results {
+		budgets.add(models.RepositoryPlan{
+			URL:                result.URL,
+			Provider:           result.Provider,
+			EstimatedCalls:     result.EstimatedCalls,
+			RateLimit:          result.RateLimit,
+			EstimatedQueryCost: result.EstimatedQueryCost,
+			QueryRateLimit:     result.QueryRateLimit,
+		})
+	}
+	return budgets.list(time.Now())
+}
+
+// ErrNoDiff is returned by RepositoryProbe.Diff for a pull request without changes to diff;
+// the diff check is skipped rather than failed
+var ErrNoDiff = errors.New("pull request has no changes")
+
+// RepositoryProbe gives CheckRepository and PlanRepository access to one repository of a
+// platform that reports no request budget up front
+type RepositoryProbe interface {
+	// Connect creates the client of the repository, reporting whether it has a credential
+	Connect() (authenticated bool, err error)
+	// Authenticate returns the name of the user the credential belongs to
+	Authenticate(ctx context.Context) (string, error)
+	// Repository describes the repository, e.g. "acme/api (private)"
+	Repository(ctx context.Context) (string, error)
+	// CountPullRequests returns the number of pull requests and the number of the most
+	// recent one, zero if there are none
+	CountPullRequests(ctx context.Context) (count, latest int, err error)
+	// Diff fetches the diff of a pull request and describes it, e.g. "fetched diff of #3 (120 bytes)"
+	Diff(ctx context.Context, number int) (string, error)
+	// EstimateCalls approximates the requests a full extraction of count pull requests needs
+	EstimateCalls(count int) int
+}
+
+// CheckRepository verifies that the credential can read the repository, list its pull
+// requests and fetch a diff. Problems are reported as failed checks; the checks that depend
+// on a failed one are skipped.
+func CheckRepository(ctx context.Context, repoURL string, provider models.Provider, probe RepositoryProbe) *models.RepositoryCheck {
+	result := &models.RepositoryCheck{URL: repoURL, Provider: provider}
+	add := func(name string, status models.CheckStatus, format string, args ...any) {
+		result.Checks = append(result.Checks, models.Check{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	authenticated, err := probe.Connect()
+	if err != nil {
+		add("authentication", models.CheckFailed, "%v", err)
+		return result
+	}
+
+	// Authentication
+	if !authenticated {
+		add("authentication", models.CheckWarning, "no credentials configured; using unauthenticated access")
+	} else if user, err := probe.Authenticate(ctx); err != nil {
+		add("authentication", models.CheckFailed, "%v", err)
+	} else {
+		add("authentication", models.CheckOK, "authenticated as %s", user)
+	}
+
+	// Repository visibility
+	repository, err := probe.Repository(ctx)
+	if err != nil {
+		add("repository", models.CheckFailed, "%v", err)
+		add("pull requests", models.CheckSkipped, "repository is not accessible")
+		add("diff", models.CheckSkipped, "repository is not accessible")
+		return result
+	}
+	add("repository", models.CheckOK, "%s", repository)
+
+	// Listing pull requests and fetching a diff
+	count, latest, err := probe.CountPullRequests(ctx)
+	switch {
+	case err != nil:
+		add("pull requests", models.CheckFailed, "%v", err)
+		add("diff", models.CheckSkipped, "pull requests could not be listed")
+	case latest == 0:
+		add("pull requests", models.CheckOK, "no pull requests")
+		add("diff", models.CheckSkipped, "no pull requests")
+	default:
+		add("pull requests", models.CheckOK, "%d pull requests", count)
+		result.EstimatedCalls = probe.EstimateCalls(count)
+
+		if detail, err := probe.Diff(ctx, latest); errors.Is(err, ErrNoDiff) {
+			add("diff", models.CheckSkipped, "#%d: %v", latest, err)
+		} else if err != nil {
+			add("diff", models.CheckFailed, "#%d: %v", latest, err)
+		} else {
+			add("diff", models.CheckOK, "%s", detail)
+		}
+	}
+
+	return result
+}
+
+// PlanRepository counts the pull requests of a repository and estimates the requests a full
+// extraction needs
+func PlanRepository(ctx context.Context, repoURL string, provider models.Provider, probe RepositoryProbe) (*models.RepositoryPlan, error) {
+	if _, err := probe.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	count, _, err := probe.CountPullRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RepositoryPlan{
+		URL:            repoURL,
+		Provider:       provider,
+		PullRequests:   count,
+		EstimatedCalls: probe.EstimateCalls(count),
+	}, nil
+}
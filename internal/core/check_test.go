@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockChecker is an extractor that supports preflight checks
+type mockChecker struct {
+	MockExtractor
+	result *models.RepositoryCheck
+	err    error
+}
+
+func (m *mockChecker) Check(ctx context.Context, repoURL string) (*models.RepositoryCheck, error) {
+	return m.result, m.err
+}
+
+func TestCheck(t *testing.T) {
+	config := &models.Config{
+		Repositories: []models.RepositoryConfig{
+			{Provider: models.ProviderGitHub, URL: "https://github.com/test/repo"},
+			{Provider: "gitea", URL: "https://gitea.com/test"},
+			{Provider: models.ProviderGitLab, URL: "https://gitlab.com/test/repo"},
+			{Provider: "bitbucket", URL: "https://bitbucket.org/test/repo"},
+		},
+	}
+
+	ok := &models.RepositoryCheck{
+		URL:      "https://github.com/test/repo",
+		Provider: models.ProviderGitHub,
+		Checks:   []models.Check{{Name: "repository", Status: models.CheckOK}},
+	}
+	extractor := NewReviewExtractor(config, map[models.Provider]Extractor{
+		models.ProviderGitHub: &mockChecker{result: ok},
+		"gitea":               &mockChecker{err: errors.New("invalid Gitea URL")},
+		models.ProviderGitLab: &MockExtractor{},
+	})
+	results, err := extractor.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	assert.Equal(t, *ok, results[0])
+	assert.False(t, results[0].Failed())
+
+	assert.True(t, results[1].Failed())
+	assert.Equal(t, "invalid Gitea URL", results[1].Checks[0].Detail)
+
+	assert.Equal(t, models.CheckSkipped, results[2].Checks[0].Status)
+	assert.False(t, results[2].Failed())
+
+	assert.True(t, results[3].Failed())
+	assert.Equal(t, "no extractor available for provider: bitbucket", results[3].Checks[0].Detail)
+}
+
+func TestCheckBudgets(t *testing.T) {
+	limitA := &models.RateLimit{Limit: 5000, Remaining: 100}
+	limitB := &models.RateLimit{Limit: 5000, Remaining: 4000}
+	results := []models.RepositoryCheck{
+		{URL: "https://github.com/customer-a/api", Provider: models.ProviderGitHub, EstimatedCalls: 70, RateLimit: limitA},
+		{URL: "https://github.com/customer-b/api", Provider: models.ProviderGitHub, EstimatedCalls: 25, RateLimit: limitB},
+		{URL: "https://github.com/customer-a/web", Provider: models.ProviderGitHub, EstimatedCalls: 40, RateLimit: limitA},
+	}
+	extractor := NewReviewExtractor(&models.Config{}, nil)
+	extractor.SetCredentials(mapSource{
+		results[0].URL: {Token: "token-a"},
+		results[1].URL: {Token: "token-b"},
+		results[2].URL: {Token: "token-a"},
+	})
+
+	budgets := extractor.CheckBudgets(results)
+	assert.Len(t, budgets, 2, "each credential on a provider has its own budget")
+	assert.Equal(t, []string{results[0].URL, results[2].URL}, budgets[0].Repositories)
+	assert.Equal(t, 110, budgets[0].EstimatedCalls)
+	assert.Equal(t, limitA, budgets[0].RateLimit)
+	assert.Equal(t, []string{results[1].URL}, budgets[1].Repositories)
+	assert.Equal(t, 25, budgets[1].EstimatedCalls)
+	assert.Equal(t, limitB, budgets[1].RateLimit)
+}
+
+// fakeProbe is a repository probe returning canned answers
+type fakeProbe struct {
+	anonymous  bool
+	connectErr error
+	repoErr    error
+	count      int
+	latest     int
+	countErr   error
+	diffErr    error
+}
+
+func (p *fakeProbe) Connect() (bool, error) { return !p.anonymous, p.connectErr }
+
+func (p *fakeProbe) Authenticate(ctx context.Context) (string, error) { return "review-bot", nil }
+
+func (p *fakeProbe) Repository(ctx context.Context) (string, error) {
+	return "acme/api (private)", p.repoErr
+}
+
+func (p *fakeProbe) CountPullRequests(ctx context.Context) (int, int, error) {
+	return p.count, p.latest, p.countErr
+}
+
+func (p *fakeProbe) Diff(ctx context.Context, number int) (string, error) {
+	return "fetched diff", p.diffErr
+}
+
+func (p *fakeProbe) EstimateCalls(count int) int { return 1 + 2*count }
+
+func TestCheckRepository(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe *fakeProbe
+		want  []models.Check
+		calls int
+	}{
+		{
+			name:  "all checks pass",
+			probe: &fakeProbe{count: 3, latest: 7},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+				{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+				{Name: "pull requests", Status: models.CheckOK, Detail: "3 pull requests"},
+				{Name: "diff", Status: models.CheckOK, Detail: "fetched diff"},
+			},
+			calls: 7,
+		},
+		{
+			name:  "client cannot be created",
+			probe: &fakeProbe{connectErr: errors.New("gitea does not support app authentication")},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckFailed, Detail: "gitea does not support app authentication"},
+			},
+		},
+		{
+			name:  "repository not found",
+			probe: &fakeProbe{anonymous: true, repoErr: errors.New("404 Not Found")},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckWarning, Detail: "no credentials configured; using unauthenticated access"},
+				{Name: "repository", Status: models.CheckFailed, Detail: "404 Not Found"},
+				{Name: "pull requests", Status: models.CheckSkipped, Detail: "repository is not accessible"},
+				{Name: "diff", Status: models.CheckSkipped, Detail: "repository is not accessible"},
+			},
+		},
+		{
+			name:  "pull requests cannot be listed",
+			probe: &fakeProbe{countErr: errors.New("403 Forbidden")},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+				{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+				{Name: "pull requests", Status: models.CheckFailed, Detail: "403 Forbidden"},
+				{Name: "diff", Status: models.CheckSkipped, Detail: "pull requests could not be listed"},
+			},
+		},
+		{
+			name:  "no pull requests",
+			probe: &fakeProbe{},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+				{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+				{Name: "pull requests", Status: models.CheckOK, Detail: "no pull requests"},
+				{Name: "diff", Status: models.CheckSkipped, Detail: "no pull requests"},
+			},
+		},
+		{
+			name:  "nothing to diff",
+			probe: &fakeProbe{count: 1, latest: 4, diffErr: ErrNoDiff},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+				{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+				{Name: "pull requests", Status: models.CheckOK, Detail: "1 pull requests"},
+				{Name: "diff", Status: models.CheckSkipped, Detail: "#4: pull request has no changes"},
+			},
+			calls: 3,
+		},
+		{
+			name:  "diff fails",
+			probe: &fakeProbe{count: 1, latest: 4, diffErr: errors.New("500 Internal Server Error")},
+			want: []models.Check{
+				{Name: "authentication", Status: models.CheckOK, Detail: "authenticated as review-bot"},
+				{Name: "repository", Status: models.CheckOK, Detail: "acme/api (private)"},
+				{Name: "pull requests", Status: models.CheckOK, Detail: "1 pull requests"},
+				{Name: "diff", Status: models.CheckFailed, Detail: "#4: 500 Internal Server Error"},
+			},
+			calls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CheckRepository(context.Background(), "https://git.example.com/acme/api", "gitea", tt.probe)
+			assert.Equal(t, &models.RepositoryCheck{
+				URL:            "https://git.example.com/acme/api",
+				Provider:       "gitea",
+				Checks:         tt.want,
+				EstimatedCalls: tt.calls,
+			}, result)
+		})
+	}
+}
+
+func TestPlanRepository(t *testing.T) {
+	plan, err := PlanRepository(context.Background(), "https://git.example.com/acme/api", "gitea", &fakeProbe{count: 3, latest: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RepositoryPlan{
+		URL:            "https://git.example.com/acme/api",
+		Provider:       "gitea",
+		PullRequests:   3,
+		EstimatedCalls: 7,
+	}, plan)
+
+	_, err = PlanRepository(context.Background(), "https://git.example.com/acme/api", "gitea", &fakeProbe{connectErr: errors.New("unsupported")})
+	assert.EqualError(t, err, "failed to authenticate: unsupported")
+
+	_, err = PlanRepository(context.Background(), "https://git.example.com/acme/api", "gitea", &fakeProbe{countErr: errors.New("403 Forbidden")})
+	assert.EqualError(t, err, "403 Forbidden")
+}
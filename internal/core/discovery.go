@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+// Discoverer is implemented by extractors that can list the repositories of an organization
+type Discoverer = provider.Discoverer
+
+// Repositories returns the configured repositories followed by those found by the discovery
+// entries, skipping repositories that are already listed, even under a URL spelled
+// differently, e.g. with a ".git" suffix
+func (e *ReviewExtractor) Repositories(ctx context.Context) ([]models.RepositoryConfig, error) {
+	repositories := append([]models.RepositoryConfig(nil), e.config.Repositories...)
+	type repoKey struct {
+		provider models.Provider
+		url      string
+	}
+
+	seen := make(map[repoKey]bool)
+	for _, repo := range repositories {
+		seen[repoKey{repo.Provider, repositoryKey(repo.URL)}] = true
+	}
+
+	for _, discovery := range e.config.Discover {
+		extractor, ok := e.extractors[discovery.Provider]
+		if !ok {
+			return nil, fmt.Errorf("no extractor available for provider: %s", discovery.Provider)
+		}
+		discoverer, ok := extractor.(Discoverer)
+		if !ok {
+			return nil, fmt.Errorf("provider %s does not support repository discovery", discovery.Provider)
+		}
+
+		remote, err := discoverer.ListRepositories(ctx, discovery)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover repositories of %s: %w", discovery.Org, err)
+		}
+
+		for _, repo := range remote {
+			key := repoKey{discovery.Provider, repositoryKey(repo.URL)}
+			if seen[key] || !matchDiscovery(discovery, repo) {
+				continue
+			}
+			seen[key] = true
+			repositories = append(repositories, models.RepositoryConfig{
+				Provider:   discovery.Provider,
+				URL:        repo.URL,
+				Credential: discovery.Credential,
+			})
+		}
+	}
+
+	return repositories, nil
+}
+
+// repositoryKey identifies the repository at a URL regardless of its spelling, or is the URL
+// itself if it cannot be parsed
+func repositoryKey(url string) string {
+	ref, err := models.ParseRepositoryURL(url)
+	if err != nil {
+		return url
+	}
+	return ref.Key()
+}
+
+// matchDiscovery reports whether a listed repository passes the filters of a discovery entry
+func matchDiscovery(discovery models.DiscoveryConfig, repo models.RemoteRepository) bool {
+	if repo.Archived && !discovery.IncludeArchived {
+		return false
+	}
+	if repo.Fork && !discovery.IncludeForks {
+		return false
+	}
+	if len(discovery.Include) > 0 && !matchAny(discovery.Include, repo.Name) {
+		return false
+	}
+	if matchAny(discovery.Exclude, repo.Name) {
+		return false
+	}
+	if len(discovery.Topics) > 0 && !hasAnyTopic(discovery.Topics, repo.Topics) {
+		return false
+	}
+	return true
+}
+
+// matchAny reports whether name matches any of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyTopic reports whether the repository has at least one of the wanted topics
+func hasAnyTopic(wanted, topics []string) bool {
+	for _, w := range wanted {
+		for _, topic := range topics {
+			if w == topic {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDiscoverer is an extractor that supports repository discovery
+type mockDiscoverer struct {
+	MockExtractor
+	repositories []models.RemoteRepository
+	err          error
+}
+
+func (m *mockDiscoverer) ListRepositories(ctx context.Context, discovery models.DiscoveryConfig) ([]models.RemoteRepository, error) {
+	return m.repositories, m.err
+}
+
+func TestRepositories(t *testing.T) {
+	credential := &models.Credential{Token: "org-token"}
+	config := &models.Config{
+		Repositories: []models.RepositoryConfig{
+			{Provider: models.ProviderGitHub, URL: "https://github.com/acme/api"},
+		},
+		Discover: []models.DiscoveryConfig{
+			{
+				Provider:   models.ProviderGitHub,
+				Org:        "acme",
+				Exclude:    []string{"*-legacy"},
+				Credential: credential,
+			},
+		},
+	}
+	discoverer := &mockDiscoverer{repositories: []models.RemoteRepository{
+		{Name: "api", URL: "https://github.com/acme/api"},
+		{Name: "web", URL: "https://github.com/acme/web"},
+		{Name: "web-legacy", URL: "https://github.com/acme/web-legacy"},
+		{Name: "old", URL: "https://github.com/acme/old", Archived: true},
+	}}
+	extractor := NewReviewExtractor(config, map[models.Provider]Extractor{models.ProviderGitHub: discoverer})
+
+	repositories, err := extractor.Repositories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RepositoryConfig{
+		{Provider: models.ProviderGitHub, URL: "https://github.com/acme/api"},
+		{Provider: models.ProviderGitHub, URL: "https://github.com/acme/web", Credential: credential},
+	}, repositories)
+
+	discoverer.err = errors.New("404 Not Found")
+	_, err = extractor.Repositories(context.Background())
+	assert.EqualError(t, err, "failed to discover repositories of acme: 404 Not Found")
+
+	discoverer.err = nil
+	discoverer.repositories = []models.RemoteRepository{
+		{Name: "api", URL: "https://github.com/acme/api.git"},
+		{Name: "web", URL: "https://github.com/acme/web"},
+	}
+	repositories, err = extractor.Repositories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RepositoryConfig{
+		{Provider: models.ProviderGitHub, URL: "https://github.com/acme/api"},
+		{Provider: models.ProviderGitHub, URL: "https://github.com/acme/web", Credential: credential},
+	}, repositories, "a discovered URL with a .git suffix is the configured repository")
+
+	extractor.extractors[models.ProviderGitHub] = &MockExtractor{}
+	_, err = extractor.Repositories(context.Background())
+	assert.EqualError(t, err, "provider github does not support repository discovery")
+}
+
+func TestMatchDiscovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		discovery models.DiscoveryConfig
+		repo      models.RemoteRepository
+		want      bool
+	}{
+		{"no filters", models.DiscoveryConfig{}, models.RemoteRepository{Name: "api"}, true},
+		{"archived skipped", models.DiscoveryConfig{}, models.RemoteRepository{Name: "api", Archived: true}, false},
+		{"archived included", models.DiscoveryConfig{IncludeArchived: true}, models.RemoteRepository{Name: "api", Archived: true}, true},
+		{"fork skipped", models.DiscoveryConfig{}, models.RemoteRepository{Name: "api", Fork: true}, false},
+		{"fork included", models.DiscoveryConfig{IncludeForks: true}, models.RemoteRepository{Name: "api", Fork: true}, true},
+		{"include matches", models.DiscoveryConfig{Include: []string{"svc-*"}}, models.RemoteRepository{Name: "svc-billing"}, true},
+		{"include does not match", models.DiscoveryConfig{Include: []string{"svc-*"}}, models.RemoteRepository{Name: "web"}, false},
+		{"exclude wins", models.DiscoveryConfig{Include: []string{"svc-*"}, Exclude: []string{"*-sandbox"}}, models.RemoteRepository{Name: "svc-sandbox"}, false},
+		{"topic matches", models.DiscoveryConfig{Topics: []string{"backend", "api"}}, models.RemoteRepository{Name: "x", Topics: []string{"go", "api"}}, true},
+		{"topic missing", models.DiscoveryConfig{Topics: []string{"backend"}}, models.RemoteRepository{Name: "x", Topics: []string{"frontend"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchDiscovery(tt.discovery, tt.repo))
+		})
+	}
+}
@@ -3,21 +3,20 @@ package core
 import (
 	"context"
 	"fmt"
-	"sort"
 	"time"
 
 	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
 )
 
 // Extractor defines the interface for extracting reviews from a Git platform
-type Extractor interface {
-	ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error)
-}
+type Extractor = provider.Extractor
 
 // ReviewExtractor orchestrates the extraction process across multiple repositories
 type ReviewExtractor struct {
-	extractors map[models.Provider]Extractor
-	config     *models.Config
+	extractors  map[models.Provider]Extractor
+	config      *models.Config
+	credentials provider.CredentialSource
 }
 
 // NewReviewExtractor creates a new ReviewExtractor instance
@@ -28,106 +27,98 @@ func NewReviewExtractor(config *models.Config, extractors map[models.Provider]Ex
 	}
 }
 
+// SetCredentials sets the source of the credentials the extractors use. Plans group
+// repositories by credential with it, since rate limits are counted per credential.
+func (e *ReviewExtractor) SetCredentials(source provider.CredentialSource) {
+	e.credentials = source
+}
+
 // ExtractReviews extracts reviews from all configured repositories
 func (e *ReviewExtractor) ExtractReviews(ctx context.Context) (*models.ExtractionResult, error) {
-	var allReviews []models.Review
+	var collector Collector
+	repositories, err := e.Run(ctx, &collector)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewResult(collector.Reviews, repositories), nil
+}
+
+// Run extracts the reviews of all configured repositories, writing each one that passes the
+// output filters to the sinks as soon as its extractor yields it. It returns the number of
+// repositories processed; on error, the sinks have received the reviews extracted so far.
+func (e *ReviewExtractor) Run(ctx context.Context, sinks ...Sink) (int, error) {
+	// Expand discovery entries
+	repositories, err := e.Repositories(ctx)
+	if err != nil {
+		return 0, err
+	}
 
 	// Process each repository
-	for _, repo := range e.config.Repositories {
+	for _, repo := range repositories {
 		extractor, ok := e.extractors[repo.Provider]
 		if !ok {
-			return nil, fmt.Errorf("no extractor available for provider: %s", repo.Provider)
+			return 0, fmt.Errorf("no extractor available for provider: %s", repo.Provider)
 		}
 
-		reviews, err := extractor.ExtractReviews(ctx, repo.URL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract reviews from %s: %w", repo.URL, err)
+		for review, err := range StreamReviews(ctx, extractor, repo.URL) {
+			if err != nil {
+				return 0, fmt.Errorf("failed to extract reviews from %s: %w", repo.URL, err)
+			}
+
+			// Apply output filters
+			if e.config.SuggestionsOnly && !review.HasSuggestions() {
+				continue
+			}
+
+			for _, sink := range sinks {
+				if err := sink.WriteReview(review); err != nil {
+					return 0, fmt.Errorf("failed to write review %s: %w", review.CommentID, err)
+				}
+			}
 		}
-
-		allReviews = append(allReviews, reviews...)
 	}
 
-	// Generate statistics
-	stats := generateStatistics(allReviews)
-
-	// Create result
-	result := &models.ExtractionResult{
-		Reviews:               allReviews,
-		Statistics:            stats,
-		ExtractedAt:           time.Now(),
-		TotalComments:         len(allReviews),
-		RepositoriesProcessed: len(e.config.Repositories),
-	}
-
-	return result, nil
+	return len(repositories), nil
 }
 
-// generateStatistics analyzes the reviews and returns aggregated statistics
-func generateStatistics(reviews []models.Review) models.Statistics {
-	reviewerCounts := make(map[string]int)
-	repoCounts := make(map[string]int)
-	prCounts := make(map[int]bool)
-	prSizes := make(map[int]int)
-
-	for _, review := range reviews {
-		reviewerCounts[review.CommentAuthor]++
-		repoCounts[review.Repository]++
-		prCounts[review.PRID] = true
-		prSizes[review.PRID]++
-	}
-
-	// Calculate average PR size
-	var totalPRSize int
-	for _, size := range prSizes {
-		totalPRSize += size
-	}
-	averagePRSize := 0.0
-	if len(prCounts) > 0 {
-		averagePRSize = float64(totalPRSize) / float64(len(prCounts))
-	}
-
-	// Calculate review frequency (reviews per PR)
-	reviewFrequency := 0.0
-	if len(prCounts) > 0 {
-		reviewFrequency = float64(len(reviews)) / float64(len(prCounts))
-	}
-
-	return models.Statistics{
-		TotalReviews:    len(reviews),
-		TotalPRs:        len(prCounts),
-		TopReviewers:    getTopN(reviewerCounts, 5),
-		TopRepositories: getTopN(repoCounts, 5),
-		AveragePRSize:   averagePRSize,
-		ReviewFrequency: reviewFrequency,
+// NewResult builds an extraction result from reviews collected from the given number of
+// repositories, gathering their pull requests and generating statistics
+func NewResult(reviews []models.Review, repositories int) *models.ExtractionResult {
+	pullRequests := collectPullRequests(reviews)
+	return &models.ExtractionResult{
+		SchemaVersion:         models.SchemaVersion,
+		Reviews:               reviews,
+		PullRequests:          pullRequests,
+		Statistics:            GenerateStatistics(reviews, pullRequests),
+		ExtractedAt:           time.Now(),
+		TotalComments:         len(reviews),
+		RepositoriesProcessed: repositories,
 	}
 }
 
-// getTopN returns the top N keys from a map based on their values
-func getTopN(counts map[string]int, n int) []string {
-	if n <= 0 {
-		return []string{}
+// collectPullRequests returns the distinct pull requests referenced by the reviews, in order of first appearance
+func collectPullRequests(reviews []models.Review) []models.PullRequest {
+	type prKey struct {
+		provider   models.Provider
+		repository string
+		number     int
 	}
 
-	type kv struct {
-		key   string
-		value int
-	}
-
-	var ss []kv
-	for k, v := range counts {
-		ss = append(ss, kv{k, v})
-	}
-
-	// Sort by value in descending order
-	sort.Slice(ss, func(i, j int) bool {
-		return ss[i].value > ss[j].value
-	})
+	seen := make(map[prKey]bool)
+	var pullRequests []models.PullRequest
+	for _, review := range reviews {
+		if review.PullRequest == nil {
+			continue
+		}
 
-	// Take top N
-	result := make([]string, 0, n)
-	for i := 0; i < n && i < len(ss); i++ {
-		result = append(result, ss[i].key)
+		key := prKey{review.PullRequest.Provider, review.PullRequest.Repository.Key(), review.PullRequest.Number}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		pullRequests = append(pullRequests, *review.PullRequest)
 	}
 
-	return result
+	return pullRequests
 }
@@ -151,6 +151,83 @@ func TestExtractReviews_ExtractionError(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to extract reviews from")
 }
 
+func TestExtractReviews_PullRequests(t *testing.T) {
+	// Setup
+	config := &models.Config{
+		Repositories: []models.RepositoryConfig{
+			{
+				Provider: models.ProviderGitHub,
+				URL:      "https://github.com/test/repo",
+			},
+		},
+	}
+
+	mockExtractor := new(MockExtractor)
+	extractors := map[models.Provider]Extractor{
+		models.ProviderGitHub: mockExtractor,
+	}
+
+	repo := models.RepositoryRef{Host: "github.com", Owner: "test", Name: "repo"}
+	pr1 := &models.PullRequest{Number: 1, Repository: repo, Provider: models.ProviderGitHub}
+	pr2 := &models.PullRequest{Number: 2, Repository: repo, Provider: models.ProviderGitHub}
+
+	mockExtractor.On("ExtractReviews", mock.Anything, "https://github.com/test/repo").Return([]models.Review{
+		{PRID: 1, CommentID: "1", PullRequest: pr1},
+		{PRID: 2, CommentID: "2", PullRequest: pr2},
+		{PRID: 1, CommentID: "3", PullRequest: pr1},
+		{PRID: 3, CommentID: "4"},
+	}, nil)
+
+	extractor := NewReviewExtractor(config, extractors)
+
+	// Execute
+	result, err := extractor.ExtractReviews(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PullRequest{*pr1, *pr2}, result.PullRequests)
+}
+
+func TestExtractReviews_SuggestionsOnly(t *testing.T) {
+	// Setup
+	config := &models.Config{
+		SuggestionsOnly: true,
+		Repositories: []models.RepositoryConfig{
+			{
+				Provider: models.ProviderGitHub,
+				URL:      "https://github.com/test/repo",
+			},
+		},
+	}
+
+	mockExtractor := new(MockExtractor)
+	extractors := map[models.Provider]Extractor{
+		models.ProviderGitHub: mockExtractor,
+	}
+
+	withSuggestion := models.Review{
+		CommentID: "1",
+		Suggestions: []models.Suggestion{
+			{OriginalCode: "timeout = 30", SuggestedCode: "timeout = defaultTimeout"},
+		},
+	}
+	withoutSuggestion := models.Review{CommentID: "2"}
+
+	mockExtractor.On("ExtractReviews", mock.Anything, "https://github.com/test/repo").
+		Return([]models.Review{withSuggestion, withoutSuggestion}, nil)
+
+	extractor := NewReviewExtractor(config, extractors)
+
+	// Execute
+	result, err := extractor.ExtractReviews(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Review{withSuggestion}, result.Reviews)
+	assert.Equal(t, 1, result.TotalComments)
+	assert.Equal(t, 1, result.Statistics.TotalReviews)
+}
+
 func TestGenerateStatistics(t *testing.T) {
 	reviews := []models.Review{
 		{
@@ -171,16 +248,34 @@ func TestGenerateStatistics(t *testing.T) {
 		},
 	}
 
-	stats := generateStatistics(reviews)
+	stats := GenerateStatistics(reviews, nil)
 
 	// Since Statistics struct does not have MostActiveReviewers or FilesWithMostComments fields,
 	// we only check the fields that exist.
 	assert.Equal(t, 4, stats.TotalReviews)
-	assert.Equal(t, 0, stats.TotalPRs) // Not set in generateStatistics
+	assert.Equal(t, 1, stats.TotalPRs) // All reviews share the zero PR of the same (empty) repository
 	assert.NotNil(t, stats.TopReviewers)
 	assert.NotNil(t, stats.TopRepositories)
 }
 
+func TestGenerateStatistics_RepositoryIdentity(t *testing.T) {
+	customerA := models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}
+	customerB := models.RepositoryRef{Host: "github.com", Owner: "customer-b", Name: "api"}
+
+	reviews := []models.Review{
+		{PRID: 1, Repository: "api", Repo: customerA, CommentAuthor: "user1"},
+		{PRID: 1, Repository: "api", Repo: customerA, CommentAuthor: "user1"},
+		{PRID: 1, Repository: "api", Repo: customerB, CommentAuthor: "user2"},
+	}
+
+	stats := GenerateStatistics(reviews, nil)
+
+	// Same repository name and PR number, but different owners
+	assert.Equal(t, 2, stats.TotalPRs)
+	assert.Equal(t, []string{"github.com/customer-a/api", "github.com/customer-b/api"}, stats.TopRepositories)
+	assert.Equal(t, 1.5, stats.ReviewFrequency)
+}
+
 func TestGetTopN(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,42 @@
+package core
+
+import "github.com/jesper/review-extractor/pkg/models"
+
+// ReviewFilter selects reviews from an extraction result. Zero-valued fields match everything.
+type ReviewFilter = models.ReviewFilter
+
+// FilterResult returns a copy of the result with only the matching reviews and
+// the pull requests they reference, with statistics recomputed
+func FilterResult(result *models.ExtractionResult, filter ReviewFilter) *models.ExtractionResult {
+	reviews := filterReviews(result.Reviews, filter.Match)
+
+	referenced := make(map[prKey]bool)
+	for _, review := range reviews {
+		referenced[prKey{review.RepositoryKey(), review.PRID}] = true
+	}
+
+	var pullRequests []models.PullRequest
+	for _, pr := range result.PullRequests {
+		if referenced[prKey{pr.Repository.Key(), pr.Number}] {
+			pullRequests = append(pullRequests, pr)
+		}
+	}
+
+	filtered := *result
+	filtered.Reviews = reviews
+	filtered.PullRequests = pullRequests
+	filtered.Statistics = GenerateStatistics(reviews, pullRequests)
+	filtered.TotalComments = len(reviews)
+	return &filtered
+}
+
+// filterReviews returns the reviews for which keep returns true
+func filterReviews(reviews []models.Review, keep func(models.Review) bool) []models.Review {
+	filtered := make([]models.Review, 0, len(reviews))
+	for _, review := range reviews {
+		if keep(review) {
+			filtered = append(filtered, review)
+		}
+	}
+	return filtered
+}
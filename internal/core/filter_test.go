@@ -0,0 +1,40 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterResult(t *testing.T) {
+	repoA := models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}
+	repoB := models.RepositoryRef{Host: "github.com", Owner: "customer-b", Name: "api"}
+
+	result := &models.ExtractionResult{
+		Reviews: []models.Review{
+			{PRID: 1, Repo: repoA, CommentAuthor: "jane"},
+			{PRID: 1, Repo: repoB, CommentAuthor: "jane"},
+			{PRID: 2, Repo: repoB, CommentAuthor: "john"},
+		},
+		PullRequests: []models.PullRequest{
+			{Number: 1, Repository: repoA},
+			{Number: 1, Repository: repoB},
+			{Number: 2, Repository: repoB},
+		},
+		TotalComments:         3,
+		RepositoriesProcessed: 2,
+	}
+
+	filtered := FilterResult(result, ReviewFilter{Repository: "customer-b/api"})
+
+	assert.Len(t, filtered.Reviews, 2)
+	assert.Equal(t, []models.PullRequest{{Number: 1, Repository: repoB}, {Number: 2, Repository: repoB}}, filtered.PullRequests)
+	assert.Equal(t, 2, filtered.TotalComments)
+	assert.Equal(t, 2, filtered.Statistics.TotalReviews)
+	assert.Equal(t, 2, filtered.Statistics.TotalPRs)
+
+	// The input is left untouched
+	assert.Len(t, result.Reviews, 3)
+	assert.Equal(t, 3, result.TotalComments)
+}
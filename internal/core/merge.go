@@ -0,0 +1,186 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// reviewKey identifies a comment across extraction runs. Review bodies, which are not
+// anchored to a file, are kept apart from inline comments since platforms such as GitHub
+// number reviews and comments independently.
+func reviewKey(review models.Review) string {
+	kind := "comment"
+	if review.FilePath == "" {
+		kind = "review"
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", review.Provider, review.RepositoryKey(), kind, review.CommentID)
+}
+
+// MergeResults combines extraction results into one, removing duplicate comments
+// (same provider, repository, kind and comment ID) and pull requests. When a comment
+// appears more than once, the copy from the most recent extraction wins.
+func MergeResults(results ...*models.ExtractionResult) *models.ExtractionResult {
+	// Apply results oldest first so newer copies replace older ones
+	ordered := append([]*models.ExtractionResult(nil), results...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ExtractedAt.Before(ordered[j].ExtractedAt)
+	})
+
+	var reviews []models.Review
+	reviewIndex := make(map[string]int)
+	var pullRequests []models.PullRequest
+	prIndex := make(map[prKey]int)
+	var extractedAt time.Time
+
+	for _, result := range ordered {
+		if result.ExtractedAt.After(extractedAt) {
+			extractedAt = result.ExtractedAt
+		}
+
+		for _, review := range result.Reviews {
+			key := reviewKey(review)
+			if i, ok := reviewIndex[key]; ok {
+				reviews[i] = review
+				continue
+			}
+			reviewIndex[key] = len(reviews)
+			reviews = append(reviews, review)
+		}
+
+		for _, pr := range result.PullRequests {
+			key := prKey{pr.Repository.Key(), pr.Number}
+			if i, ok := prIndex[key]; ok {
+				pullRequests[i] = pr
+				continue
+			}
+			prIndex[key] = len(pullRequests)
+			pullRequests = append(pullRequests, pr)
+		}
+	}
+
+	repositories := make(map[string]bool)
+	for _, review := range reviews {
+		repositories[review.RepositoryKey()] = true
+	}
+
+	return &models.ExtractionResult{
+		SchemaVersion:         models.SchemaVersion,
+		Reviews:               reviews,
+		PullRequests:          pullRequests,
+		Statistics:            GenerateStatistics(reviews, pullRequests),
+		ExtractedAt:           extractedAt,
+		TotalComments:         len(reviews),
+		RepositoriesProcessed: len(repositories),
+	}
+}
+
+// ChangeType describes how a comment changed between two extraction results
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeEdited  ChangeType = "edited"
+)
+
+// ReviewChange is a single comment that differs between two extraction results
+type ReviewChange struct {
+	Type ChangeType     `json:"type"`
+	Key  string         `json:"key"`
+	Old  *models.Review `json:"old,omitempty"`
+	New  *models.Review `json:"new,omitempty"`
+	// Fields lists the JSON names of the fields that changed in an edited comment
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DiffSummary counts the changes between two extraction results
+type DiffSummary struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Edited    int `json:"edited"`
+	Unchanged int `json:"unchanged"`
+}
+
+// ResultDiff describes the changes between two extraction results
+type ResultDiff struct {
+	Summary DiffSummary    `json:"summary"`
+	Changes []ReviewChange `json:"changes"`
+}
+
+// DiffResults compares two extraction results by comment, listing the comments that were
+// added, removed or edited in newer. Changes are ordered by type, then by key.
+func DiffResults(older, newer *models.ExtractionResult) *ResultDiff {
+	oldReviews := make(map[string]models.Review, len(older.Reviews))
+	for _, review := range older.Reviews {
+		oldReviews[reviewKey(review)] = review
+	}
+	newReviews := make(map[string]models.Review, len(newer.Reviews))
+	for _, review := range newer.Reviews {
+		newReviews[reviewKey(review)] = review
+	}
+
+	diff := &ResultDiff{Changes: []ReviewChange{}}
+
+	for key, newReview := range newReviews {
+		oldReview, ok := oldReviews[key]
+		if !ok {
+			diff.Changes = append(diff.Changes, ReviewChange{Type: ChangeAdded, Key: key, New: &newReview})
+			diff.Summary.Added++
+			continue
+		}
+
+		fields := changedFields(oldReview, newReview)
+		if len(fields) == 0 {
+			diff.Summary.Unchanged++
+			continue
+		}
+		diff.Changes = append(diff.Changes, ReviewChange{Type: ChangeEdited, Key: key, Old: &oldReview, New: &newReview, Fields: fields})
+		diff.Summary.Edited++
+	}
+
+	for key, oldReview := range oldReviews {
+		if _, ok := newReviews[key]; !ok {
+			diff.Changes = append(diff.Changes, ReviewChange{Type: ChangeRemoved, Key: key, Old: &oldReview})
+			diff.Summary.Removed++
+		}
+	}
+
+	order := map[ChangeType]int{ChangeAdded: 0, ChangeRemoved: 1, ChangeEdited: 2}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		a, b := diff.Changes[i], diff.Changes[j]
+		if a.Type != b.Type {
+			return order[a.Type] < order[b.Type]
+		}
+		return a.Key < b.Key
+	})
+
+	return diff
+}
+
+// changedFields returns the JSON names of the comment fields that differ
+func changedFields(before, after models.Review) []string {
+	var fields []string
+	for _, f := range []struct {
+		name          string
+		before, after any
+	}{
+		{"comment_text", before.CommentText, after.CommentText},
+		{"review_state", before.ReviewState, after.ReviewState},
+		{"thread_status", before.ThreadStatus, after.ThreadStatus},
+		{"file_path", before.FilePath, after.FilePath},
+		{"start_line", before.StartLine, after.StartLine},
+		{"line_number", before.LineNumber, after.LineNumber},
+		{"suggestions", before.Suggestions, after.Suggestions},
+		{"addressed", before.Addressed, after.Addressed},
+		{"addressed_by", before.AddressedBy, after.AddressedBy},
+	} {
+		if !reflect.DeepEqual(f.before, f.after) {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
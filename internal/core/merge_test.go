@@ -0,0 +1,133 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	mergeRepoA = models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}
+	mergeRepoB = models.RepositoryRef{Host: "github.com", Owner: "customer-b", Name: "api"}
+)
+
+func mergeReview(repo models.RepositoryRef, id, text string) models.Review {
+	return models.Review{
+		PRID:        1,
+		Repo:        repo,
+		Provider:    models.ProviderGitHub,
+		CommentID:   id,
+		CommentText: text,
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	older := &models.ExtractionResult{
+		ExtractedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Reviews: []models.Review{
+			mergeReview(mergeRepoA, "1", "old text"),
+			mergeReview(mergeRepoA, "2", "unchanged"),
+		},
+		PullRequests: []models.PullRequest{{Number: 1, Repository: mergeRepoA, Title: "Old title"}},
+	}
+	newer := &models.ExtractionResult{
+		ExtractedAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Reviews: []models.Review{
+			mergeReview(mergeRepoA, "1", "new text"),
+			mergeReview(mergeRepoB, "1", "same ID, other repository"),
+		},
+		PullRequests: []models.PullRequest{
+			{Number: 1, Repository: mergeRepoA, Title: "New title"},
+			{Number: 1, Repository: mergeRepoB},
+		},
+	}
+
+	// Argument order doesn't matter, the newest extraction wins
+	merged := MergeResults(newer, older)
+
+	assert.Equal(t, models.SchemaVersion, merged.SchemaVersion)
+	assert.Equal(t, []models.Review{
+		mergeReview(mergeRepoA, "1", "new text"),
+		mergeReview(mergeRepoA, "2", "unchanged"),
+		mergeReview(mergeRepoB, "1", "same ID, other repository"),
+	}, merged.Reviews)
+	assert.Equal(t, []models.PullRequest{
+		{Number: 1, Repository: mergeRepoA, Title: "New title"},
+		{Number: 1, Repository: mergeRepoB},
+	}, merged.PullRequests)
+	assert.Equal(t, newer.ExtractedAt, merged.ExtractedAt)
+	assert.Equal(t, 3, merged.TotalComments)
+	assert.Equal(t, 2, merged.RepositoriesProcessed)
+	assert.Equal(t, 3, merged.Statistics.TotalReviews)
+	assert.Equal(t, 2, merged.Statistics.TotalPRs)
+}
+
+func TestMergeResults_ReviewAndCommentShareID(t *testing.T) {
+	body := mergeReview(mergeRepoA, "5", "Looks good overall")
+	inline := mergeReview(mergeRepoA, "5", "Retry forever?")
+	inline.FilePath = "client.go"
+
+	merged := MergeResults(
+		&models.ExtractionResult{Reviews: []models.Review{body, inline}},
+		&models.ExtractionResult{Reviews: []models.Review{inline}},
+	)
+	assert.Equal(t, []models.Review{body, inline}, merged.Reviews, "a review body does not replace an inline comment")
+
+	diff := DiffResults(
+		&models.ExtractionResult{Reviews: []models.Review{body}},
+		&models.ExtractionResult{Reviews: []models.Review{body, inline}},
+	)
+	assert.Equal(t, DiffSummary{Added: 1, Unchanged: 1}, diff.Summary)
+	assert.Len(t, diff.Changes, 1)
+	assert.Equal(t, "github/github.com/customer-a/api/comment/5", diff.Changes[0].Key)
+	assert.Equal(t, &inline, diff.Changes[0].New)
+}
+
+func TestMergeResults_Empty(t *testing.T) {
+	merged := MergeResults()
+	assert.Empty(t, merged.Reviews)
+	assert.Equal(t, 0, merged.TotalComments)
+}
+
+func TestDiffResults(t *testing.T) {
+	older := &models.ExtractionResult{
+		Reviews: []models.Review{
+			mergeReview(mergeRepoA, "1", "before"),
+			mergeReview(mergeRepoA, "2", "unchanged"),
+			mergeReview(mergeRepoA, "3", "deleted"),
+		},
+	}
+
+	edited := mergeReview(mergeRepoA, "1", "after")
+	edited.ReviewState = models.ReviewStateApproved
+	added := mergeReview(mergeRepoB, "3", "new")
+
+	newer := &models.ExtractionResult{
+		Reviews: []models.Review{
+			edited,
+			mergeReview(mergeRepoA, "2", "unchanged"),
+			added,
+		},
+	}
+
+	diff := DiffResults(older, newer)
+
+	assert.Equal(t, DiffSummary{Added: 1, Removed: 1, Edited: 1, Unchanged: 1}, diff.Summary)
+	assert.Len(t, diff.Changes, 3)
+
+	assert.Equal(t, ChangeAdded, diff.Changes[0].Type)
+	assert.Equal(t, "github/github.com/customer-b/api/review/3", diff.Changes[0].Key)
+	assert.Equal(t, &added, diff.Changes[0].New)
+	assert.Nil(t, diff.Changes[0].Old)
+
+	assert.Equal(t, ChangeRemoved, diff.Changes[1].Type)
+	assert.Equal(t, "github/github.com/customer-a/api/review/3", diff.Changes[1].Key)
+	assert.Equal(t, "deleted", diff.Changes[1].Old.CommentText)
+
+	assert.Equal(t, ChangeEdited, diff.Changes[2].Type)
+	assert.Equal(t, []string{"comment_text", "review_state"}, diff.Changes[2].Fields)
+	assert.Equal(t, "before", diff.Changes[2].Old.CommentText)
+	assert.Equal(t, "after", diff.Changes[2].New.CommentText)
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+const (
+	// averageRequestDuration is the assumed latency of one API request; runs are sequential
+	averageRequestDuration = 250 * time.Millisecond
+	// rateLimitWindow is how often a rate limit budget is refilled after its first reset
+	rateLimitWindow = time.Hour
+)
+
+// Planner is implemented by extractors that can estimate the cost of extracting a repository
+type Planner = provider.Planner
+
+// Plan estimates the cost of extracting every configured and discovered repository, in the
+// order a run would extract them. Repositories that cannot be planned are reported in the
+// plan rather than as errors; only failing to discover repositories is an error.
+func (e *ReviewExtractor) Plan(ctx context.Context) (*models.Plan, error) {
+	repositories, err := e.Repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.Plan{Repositories: make([]models.RepositoryPlan, 0, len(repositories))}
+	budgets := e.newBudgets()
+	for _, repo := range repositories {
+		result := e.planRepository(ctx, repo)
+		plan.Repositories = append(plan.Repositories, result)
+		if result.Error != "" {
+			continue
+		}
+
+		plan.PullRequests += result.PullRequests
+		plan.EstimatedCalls += result.EstimatedCalls
+		budgets.add(result)
+	}
+
+	plan.Providers = budgets.list(time.Now())
+	for _, budget := range plan.Providers {
+		plan.EstimatedDuration += budget.EstimatedDuration
+	}
+
+	return plan, nil
+}
+
+// budgetKey identifies a rate limit budget
+type budgetKey struct {
+	provider   models.Provider
+	host       string
+	credential models.Credential
+}
+
+// budgetKey returns the budget the requests for a repository count against
+func (e *ReviewExtractor) budgetKey(provider models.Provider, repoURL string) budgetKey {
+	key := budgetKey{provider: provider}
+	if u, err := url.Parse(repoURL); err == nil {
+		key.host = strings.ToLower(u.Host)
+	}
+	if e.credentials != nil {
+		key.credential, _ = e.credentials.Credential(provider, repoURL)
+	}
+	return key
+}
+
+// budgets sums the requests of repositories per rate limit budget. Rate limits are counted
+// per credential on each host, so only repositories extracted with the same credential
+// share a budget.
+type budgets struct {
+	extractor *ReviewExtractor
+	byKey     map[budgetKey]*models.ProviderPlan
+	order     []budgetKey
+}
+
+// newBudgets creates an empty set of budgets, grouping repositories by their credentials
+func (e *ReviewExtractor) newBudgets() *budgets {
+	return &budgets{extractor: e, byKey: make(map[budgetKey]*models.ProviderPlan)}
+}
+
+// add counts the requests of a repository against its budget, taking the rate limits the
+// provider reported
+func (b *budgets) add(repo models.RepositoryPlan) {
+	key := b.extractor.budgetKey(repo.Provider, repo.URL)
+	budget, ok := b.byKey[key]
+	if !ok {
+		budget = &models.ProviderPlan{Provider: repo.Provider, Host: key.host}
+		b.byKey[key] = budget
+		b.order = append(b.order, key)
+	}
+	budget.Repositories = append(budget.Repositories, repo.URL)
+	budget.EstimatedCalls += repo.EstimatedCalls
+	budget.EstimatedQueryCost += repo.EstimatedQueryCost
+	if repo.RateLimit != nil {
+		budget.RateLimit = repo.RateLimit
+	}
+	if repo.QueryRateLimit != nil {
+		budget.QueryRateLimit = repo.QueryRateLimit
+	}
+}
+
+// list returns the budgets in the order their first repository was added, with the
+// duration of their requests estimated from now
+func (b *budgets) list(now time.Time) []models.ProviderPlan {
+	list := make([]models.ProviderPlan, 0, len(b.order))
+	for _, key := range b.order {
+		budget := b.byKey[key]
+		// The run waits for whichever budget runs out last
+		budget.EstimatedDuration = time.Duration(budget.EstimatedCalls)*averageRequestDuration +
+			max(waitDuration(budget.EstimatedCalls, budget.RateLimit, now), waitDuration(budget.EstimatedQueryCost, budget.QueryRateLimit, now))
+		list = append(list, *budget)
+	}
+	return list
+}
+
+// planRepository plans one repository, recording failures in the plan
+func (e *ReviewExtractor) planRepository(ctx context.Context, repo models.RepositoryConfig) models.RepositoryPlan {
+	failed := func(msg string) models.RepositoryPlan {
+		return models.RepositoryPlan{URL: repo.URL, Provider: repo.Provider, Error: msg}
+	}
+
+	extractor, ok := e.extractors[repo.Provider]
+	if !ok {
+		return failed("no extractor available for provider: " + string(repo.Provider))
+	}
+
+	planner, ok := extractor.(Planner)
+	if !ok {
+		return failed("provider does not support planning")
+	}
+
+	result, err := planner.Plan(ctx, repo.URL)
+	if err != nil {
+		return failed(err.Error())
+	}
+	return *result
+}
+
+// waitDuration approximates how long a run waits for a budget to cover cost. Beyond the
+// remaining budget, it waits for the budget to reset, and then for another window per
+// further budget.
+func waitDuration(cost int, limit *models.RateLimit, now time.Time) time.Duration {
+	if limit == nil || limit.Limit <= 0 || cost <= limit.Remaining {
+		return 0
+	}
+
+	wait := limit.Reset.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	windows := (cost - limit.Remaining - 1) / limit.Limit
+	return wait + time.Duration(windows)*rateLimitWindow
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPlanner is an extractor that can estimate the cost of a repository
+type mockPlanner struct {
+	MockExtractor
+	plans map[string]*models.RepositoryPlan
+	err   error
+}
+
+func (m *mockPlanner) Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.plans[repoURL], nil
+}
+
+func TestPlan(t *testing.T) {
+	limit := &models.RateLimit{Limit: 5000, Remaining: 4000, Reset: time.Now().Add(time.Hour)}
+	config := &models.Config{
+		Repositories: []models.RepositoryConfig{
+			{Provider: models.ProviderGitHub, URL: "https://github.com/test/api"},
+			{Provider: models.ProviderGitHub, URL: "https://github.com/test/web"},
+			{Provider: "gitea", URL: "https://gitea.com/test/repo"},
+			{Provider: models.ProviderGitLab, URL: "https://gitlab.com/test/repo"},
+			{Provider: "bitbucket", URL: "https://bitbucket.org/test/repo"},
+		},
+	}
+	extractor := NewReviewExtractor(config, map[models.Provider]Extractor{
+		models.ProviderGitHub: &mockPlanner{plans: map[string]*models.RepositoryPlan{
+			"https://github.com/test/api": {URL: "https://github.com/test/api", Provider: models.ProviderGitHub, PullRequests: 10, EstimatedCalls: 31, RateLimit: limit},
+			"https://github.com/test/web": {URL: "https://github.com/test/web", Provider: models.ProviderGitHub, PullRequests: 3, EstimatedCalls: 10, RateLimit: limit},
+		}},
+		"gitea":               &mockPlanner{err: errors.New("404 Not Found")},
+		models.ProviderGitLab: &MockExtractor{},
+	})
+
+	plan, err := extractor.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, plan.Repositories, 5)
+	assert.Equal(t, 13, plan.PullRequests)
+	assert.Equal(t, 41, plan.EstimatedCalls)
+	assert.Equal(t, 41*averageRequestDuration, plan.EstimatedDuration)
+	assert.Equal(t, []models.ProviderPlan{
+		{
+			Provider:          models.ProviderGitHub,
+			Host:              "github.com",
+			Repositories:      []string{"https://github.com/test/api", "https://github.com/test/web"},
+			EstimatedCalls:    41,
+			RateLimit:         limit,
+			EstimatedDuration: 41 * averageRequestDuration,
+		},
+	}, plan.Providers)
+
+	assert.Equal(t, 3, plan.Failed())
+	assert.Equal(t, "404 Not Found", plan.Repositories[2].Error)
+	assert.Equal(t, "provider does not support planning", plan.Repositories[3].Error)
+	assert.Equal(t, "no extractor available for provider: bitbucket", plan.Repositories[4].Error)
+}
+
+// mapSource returns the credential configured for each repository URL
+type mapSource map[string]models.Credential
+
+func (s mapSource) Credential(provider models.Provider, repoURL string) (models.Credential, bool) {
+	credential, ok := s[repoURL]
+	return credential, ok
+}
+
+func TestPlan_BudgetPerCredential(t *testing.T) {
+	repos := []string{
+		"https://github.com/customer-a/api",
+		"https://github.com/customer-a/web",
+		"https://github.com/customer-b/api",
+		"https://github.example.com/customer-a/api",
+	}
+	config := &models.Config{}
+	plans := make(map[string]*models.RepositoryPlan)
+	for _, url := range repos {
+		config.Repositories = append(config.Repositories, models.RepositoryConfig{Provider: models.ProviderGitHub, URL: url})
+		plans[url] = &models.RepositoryPlan{URL: url, Provider: models.ProviderGitHub, EstimatedCalls: 10}
+	}
+
+	extractor := NewReviewExtractor(config, map[models.Provider]Extractor{
+		models.ProviderGitHub: &mockPlanner{plans: plans},
+	})
+	customerA := models.Credential{Token: "token-a"}
+	extractor.SetCredentials(mapSource{
+		repos[0]: customerA,
+		repos[1]: customerA,
+		repos[2]: {Token: "token-b"},
+		repos[3]: customerA,
+	})
+
+	plan, err := extractor.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, plan.Providers, 3)
+	assert.Equal(t, "github.com", plan.Providers[0].Host)
+	assert.Equal(t, repos[:2], plan.Providers[0].Repositories)
+	assert.Equal(t, 20, plan.Providers[0].EstimatedCalls)
+	assert.Equal(t, "github.com", plan.Providers[1].Host)
+	assert.Equal(t, repos[2:3], plan.Providers[1].Repositories, "another token has its own budget")
+	assert.Equal(t, "github.example.com", plan.Providers[2].Host)
+	assert.Equal(t, repos[3:], plan.Providers[2].Repositories, "the same token on another host has its own budget")
+}
+
+func TestPlan_QueryBudget(t *testing.T) {
+	now := time.Now()
+	config := &models.Config{Repositories: []models.RepositoryConfig{{Provider: models.ProviderGitHub, URL: "https://github.com/test/api"}}}
+	extractor := NewReviewExtractor(config, map[models.Provider]Extractor{
+		models.ProviderGitHub: &mockPlanner{plans: map[string]*models.RepositoryPlan{
+			"https://github.com/test/api": {
+				URL: "https://github.com/test/api", Provider: models.ProviderGitHub, PullRequests: 400,
+				EstimatedCalls: 400, RateLimit: &models.RateLimit{Limit: 5000, Remaining: 4000, Reset: now.Add(time.Hour)},
+				EstimatedQueryCost: 200, QueryRateLimit: &models.RateLimit{Limit: 5000, Remaining: 100, Reset: now.Add(30 * time.Minute)},
+			},
+		}},
+	})
+
+	plan, err := extractor.Plan(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, plan.Providers, 1)
+	assert.Equal(t, 200, plan.Providers[0].EstimatedQueryCost)
+	assert.Equal(t, 100, plan.Providers[0].QueryRateLimit.Remaining)
+	assert.InDelta(t, float64(400*averageRequestDuration+30*time.Minute), float64(plan.EstimatedDuration), float64(time.Second),
+		"the run waits for the query budget to reset")
+}
+
+func TestWaitDuration(t *testing.T) {
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	limit := &models.RateLimit{Limit: 5000, Remaining: 1000, Reset: now.Add(20 * time.Minute)}
+
+	assert.Equal(t, time.Duration(0), waitDuration(100, nil, now))
+	assert.Equal(t, time.Duration(0), waitDuration(1000, limit, now))
+	assert.Equal(t, 20*time.Minute, waitDuration(1001, limit, now), "waits for the reset")
+	assert.Equal(t, 20*time.Minute, waitDuration(6000, limit, now))
+	assert.Equal(t, 20*time.Minute+time.Hour, waitDuration(6001, limit, now), "and for another window")
+}
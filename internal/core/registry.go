@@ -0,0 +1,99 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+// ProviderFactory describes the adapter of a provider
+type ProviderFactory = provider.Factory
+
+// Registry holds the provider adapters available to a run
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[models.Provider]ProviderFactory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[models.Provider]ProviderFactory)}
+}
+
+// DefaultRegistry is the registry adapters add themselves to when their package is imported
+var DefaultRegistry = NewRegistry()
+
+// Register adds a provider to the default registry. It panics if the provider is registered
+// twice, since that is a programming error.
+func Register(factory ProviderFactory) {
+	DefaultRegistry.Register(factory)
+}
+
+// Register adds a provider to the registry, panicking if it is incomplete or already registered
+func (r *Registry) Register(factory ProviderFactory) {
+	if factory.Name == "" || factory.New == nil || factory.ValidateURL == nil {
+		panic("core: provider registered without a name, constructor or URL validator")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[factory.Name]; ok {
+		panic(fmt.Sprintf("core: provider %s registered twice", factory.Name))
+	}
+	r.factories[factory.Name] = factory
+}
+
+// Lookup returns the adapter of a provider
+func (r *Registry) Lookup(provider models.Provider) (ProviderFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[provider]
+	return factory, ok
+}
+
+// Providers returns the registered adapters ordered by provider name
+func (r *Registry) Providers() []ProviderFactory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factories := make([]ProviderFactory, 0, len(r.factories))
+	for _, factory := range r.factories {
+		factories = append(factories, factory)
+	}
+	sort.Slice(factories, func(i, j int) bool {
+		return factories[i].Name < factories[j].Name
+	})
+	return factories
+}
+
+// InferProvider returns the provider a repository URL certainly belongs to, or an empty
+// provider if none recognizes it. It is an error for more than one provider to claim a URL.
+func (r *Registry) InferProvider(url string) (models.Provider, error) {
+	var matches []string
+	for _, factory := range r.Providers() {
+		if factory.MatchURL != nil && factory.MatchURL(url) {
+			matches = append(matches, string(factory.Name))
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return models.Provider(matches[0]), nil
+	default:
+		return "", fmt.Errorf("URL matches several providers: %s", strings.Join(matches, ", "))
+	}
+}
+
+// Extractors creates the extractors of every registered provider for a configuration
+func (r *Registry) Extractors(config *models.Config, source provider.CredentialSource) map[models.Provider]Extractor {
+	extractors := make(map[models.Provider]Extractor)
+	for _, factory := range r.Providers() {
+		extractors[factory.Name] = factory.New(config, source)
+	}
+	return extractors
+}
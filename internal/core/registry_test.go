@@ -0,0 +1,89 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFactory returns a provider recognizing the URLs on a host
+func testFactory(name models.Provider, host string) ProviderFactory {
+	return ProviderFactory{
+		Name:        name,
+		AuthMethods: []models.AuthMethod{models.AuthBearer},
+		MatchURL: func(url string) bool {
+			return credentials.Host(url) == host
+		},
+		ValidateURL: func(url string) error { return nil },
+		New: func(config *models.Config, source credentials.Source) Extractor {
+			return &MockExtractor{}
+		},
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(testFactory("gitea", "gitea.com"))
+	registry.Register(testFactory("forgejo", "codeberg.org"))
+
+	factory, ok := registry.Lookup("gitea")
+	assert.True(t, ok)
+	assert.Equal(t, models.Provider("gitea"), factory.Name)
+	assert.True(t, factory.SupportsAuth(models.AuthBearer))
+	assert.False(t, factory.SupportsAuth(models.AuthBasic))
+
+	_, ok = registry.Lookup("gerrit")
+	assert.False(t, ok)
+
+	var names []models.Provider
+	for _, factory := range registry.Providers() {
+		names = append(names, factory.Name)
+	}
+	assert.Equal(t, []models.Provider{"forgejo", "gitea"}, names)
+
+	assert.Panics(t, func() { registry.Register(testFactory("gitea", "gitea.io")) }, "providers are registered once")
+	assert.Panics(t, func() { registry.Register(ProviderFactory{Name: "gerrit"}) }, "factories must be complete")
+}
+
+func TestRegistry_InferProvider(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(testFactory("gitea", "gitea.com"))
+	registry.Register(testFactory("forgejo", "codeberg.org"))
+	registry.Register(testFactory("mirror", "codeberg.org"))
+	selfHosted := testFactory("gerrit", "")
+	selfHosted.MatchURL = nil
+	registry.Register(selfHosted)
+
+	provider, err := registry.InferProvider("https://Gitea.com/acme/api")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Provider("gitea"), provider)
+
+	provider, err = registry.InferProvider("https://git.example.com/acme/api")
+	assert.NoError(t, err)
+	assert.Empty(t, provider, "self-hosted URLs are not recognized")
+
+	_, err = registry.InferProvider("https://codeberg.org/acme/api")
+	assert.EqualError(t, err, "URL matches several providers: forgejo, mirror")
+}
+
+func TestRegistry_Extractors(t *testing.T) {
+	registry := NewRegistry()
+	var configs []*models.Config
+	for _, name := range []models.Provider{"gitea", "forgejo"} {
+		factory := testFactory(name, string(name)+".example.com")
+		factory.New = func(config *models.Config, source credentials.Source) Extractor {
+			configs = append(configs, config)
+			return &MockExtractor{}
+		}
+		registry.Register(factory)
+	}
+
+	config := &models.Config{}
+	extractors := registry.Extractors(config, nil)
+	assert.Len(t, extractors, 2)
+	assert.Contains(t, extractors, models.Provider("gitea"))
+	assert.Contains(t, extractors, models.Provider("forgejo"))
+	assert.Equal(t, []*models.Config{config, config}, configs, "every extractor is created for the run's configuration")
+}
@@ -0,0 +1,369 @@
+package core
+
+import (
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// statisticsTopN limits the ranked lists in the statistics
+const statisticsTopN = 10
+
+// prKey identifies a pull request; numbers are only unique within a repository
+type prKey struct {
+	repository string
+	number     int
+}
+
+// pathKey identifies a file or directory; paths are only unique within a repository
+type pathKey struct {
+	repository string
+	path       string
+}
+
+// GenerateStatistics analyzes the reviews and pull requests and returns aggregated statistics
+func GenerateStatistics(reviews []models.Review, pullRequests []models.PullRequest) models.Statistics {
+	reviewerCounts := make(map[string]int)
+	repoCounts := make(map[string]int)
+	fileCounts := make(map[pathKey]int)
+	dirCounts := make(map[pathKey]int)
+	languageFiles := make(map[string]map[pathKey]int)
+	interactions := make(map[[2]string]int)
+	prCounts := make(map[prKey]bool)
+	firstReview := make(map[prKey]time.Time)
+	inlineComments := make(map[prKey]int)
+
+	for _, review := range reviews {
+		pr := prKey{review.RepositoryKey(), review.PRID}
+		reviewerCounts[review.CommentAuthor]++
+		repoCounts[pr.repository]++
+		prCounts[pr] = true
+
+		if review.FilePath != "" {
+			file := pathKey{pr.repository, review.FilePath}
+			fileCounts[file]++
+			dirCounts[pathKey{pr.repository, path.Dir(review.FilePath)}]++
+
+			language := detectLanguage(review.FilePath)
+			if languageFiles[language] == nil {
+				languageFiles[language] = make(map[pathKey]int)
+			}
+			languageFiles[language][file]++
+			inlineComments[pr]++
+		}
+
+		if review.CommentAuthor != "" && review.PRAuthor != "" && review.CommentAuthor != review.PRAuthor {
+			interactions[[2]string{review.CommentAuthor, review.PRAuthor}]++
+
+			created := review.CommentCreated
+			if first, ok := firstReview[pr]; !created.IsZero() && (!ok || created.Before(first)) {
+				firstReview[pr] = created
+			}
+		}
+	}
+
+	// Calculate review frequency (reviews per PR)
+	reviewFrequency := 0.0
+	if len(prCounts) > 0 {
+		reviewFrequency = float64(len(reviews)) / float64(len(prCounts))
+	}
+
+	// PR sizes, density and timings need pull request metadata
+	var totalChanged, sizedChanged, sizedInline int
+	var firstReviewHours, mergeHours []float64
+	for _, pr := range pullRequests {
+		key := prKey{pr.Repository.Key(), pr.Number}
+		changed := pr.Additions + pr.Deletions
+		totalChanged += changed
+
+		if changed > 0 {
+			sizedChanged += changed
+			sizedInline += inlineComments[key]
+		}
+
+		if pr.CreatedAt.IsZero() {
+			continue
+		}
+		if first, ok := firstReview[key]; ok && !first.Before(pr.CreatedAt) {
+			firstReviewHours = append(firstReviewHours, first.Sub(pr.CreatedAt).Hours())
+		}
+		if pr.MergedAt != nil && !pr.MergedAt.Before(pr.CreatedAt) {
+			mergeHours = append(mergeHours, pr.MergedAt.Sub(pr.CreatedAt).Hours())
+		}
+	}
+
+	averagePRSize := 0.0
+	if len(pullRequests) > 0 {
+		averagePRSize = float64(totalChanged) / float64(len(pullRequests))
+	}
+
+	commentDensity := 0.0
+	if sizedChanged > 0 {
+		commentDensity = float64(sizedInline) / float64(sizedChanged)
+	}
+
+	return models.Statistics{
+		TotalReviews:      len(reviews),
+		TotalPRs:          len(prCounts),
+		TopReviewers:      getTopN(reviewerCounts, 5),
+		TopRepositories:   getTopN(repoCounts, 5),
+		AveragePRSize:     averagePRSize,
+		ReviewFrequency:   reviewFrequency,
+		Reviewers:         topCounts(reviewerCounts, statisticsTopN),
+		Repositories:      topCounts(repoCounts, statisticsTopN),
+		Files:             topPathCounts(fileCounts, statisticsTopN),
+		Directories:       topPathCounts(dirCounts, statisticsTopN),
+		Languages:         languageHotspots(languageFiles),
+		CommentDensity:    commentDensity,
+		TimeToFirstReview: distribution(firstReviewHours),
+		TimeToMerge:       distribution(mergeHours),
+		Interactions:      interactionMatrix(interactions),
+		Weekly:            weeklySeries(reviews),
+	}
+}
+
+// getTopN returns the top N keys from a map based on their values
+func getTopN(counts map[string]int, n int) []string {
+	if n <= 0 {
+		return []string{}
+	}
+
+	ranked := topCounts(counts, n)
+
+	// Take top N
+	result := make([]string, 0, n)
+	for _, c := range ranked {
+		result = append(result, c.Name)
+	}
+
+	return result
+}
+
+// topCounts returns the n highest counts, ordered by count and then by name
+func topCounts(counts map[string]int, n int) []models.Count {
+	ranked := make([]models.Count, 0, len(counts))
+	for name, count := range counts {
+		ranked = append(ranked, models.Count{Name: name, Count: count})
+	}
+	return rankCounts(ranked, n)
+}
+
+// topPathCounts returns the n most commented paths, ordered by count, repository and path
+func topPathCounts(counts map[pathKey]int, n int) []models.Count {
+	ranked := make([]models.Count, 0, len(counts))
+	for key, count := range counts {
+		ranked = append(ranked, models.Count{Repository: key.repository, Name: key.path, Count: count})
+	}
+	return rankCounts(ranked, n)
+}
+
+// rankCounts orders counts by count, then by repository and name, and keeps the first n
+func rankCounts(ranked []models.Count, n int) []models.Count {
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		if ranked[i].Repository != ranked[j].Repository {
+			return ranked[i].Repository < ranked[j].Repository
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// languageHotspots ranks languages by comment count, listing their most commented files
+func languageHotspots(languageFiles map[string]map[pathKey]int) []models.LanguageHotspot {
+	hotspots := make([]models.LanguageHotspot, 0, len(languageFiles))
+	for language, files := range languageFiles {
+		var comments int
+		for _, count := range files {
+			comments += count
+		}
+		hotspots = append(hotspots, models.LanguageHotspot{
+			Language: language,
+			Comments: comments,
+			Files:    topPathCounts(files, statisticsTopN),
+		})
+	}
+
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].Comments != hotspots[j].Comments {
+			return hotspots[i].Comments > hotspots[j].Comments
+		}
+		return hotspots[i].Language < hotspots[j].Language
+	})
+
+	return hotspots
+}
+
+// interactionMatrix flattens reviewer/author comment counts, most frequent pairs first
+func interactionMatrix(counts map[[2]string]int) []models.Interaction {
+	interactions := make([]models.Interaction, 0, len(counts))
+	for pair, count := range counts {
+		interactions = append(interactions, models.Interaction{
+			Reviewer: pair[0],
+			Author:   pair[1],
+			Comments: count,
+		})
+	}
+
+	sort.Slice(interactions, func(i, j int) bool {
+		a, b := interactions[i], interactions[j]
+		if a.Comments != b.Comments {
+			return a.Comments > b.Comments
+		}
+		if a.Reviewer != b.Reviewer {
+			return a.Reviewer < b.Reviewer
+		}
+		return a.Author < b.Author
+	})
+
+	return interactions
+}
+
+// weeklySeries groups review activity by the UTC week (starting Monday) it happened in
+func weeklySeries(reviews []models.Review) []models.WeeklyCount {
+	type week struct {
+		comments  int
+		prs       map[prKey]bool
+		reviewers map[string]bool
+	}
+
+	weeks := make(map[time.Time]*week)
+	for _, review := range reviews {
+		if review.CommentCreated.IsZero() {
+			continue
+		}
+
+		start := weekStart(review.CommentCreated)
+		w, ok := weeks[start]
+		if !ok {
+			w = &week{prs: make(map[prKey]bool), reviewers: make(map[string]bool)}
+			weeks[start] = w
+		}
+		w.comments++
+		w.prs[prKey{review.RepositoryKey(), review.PRID}] = true
+		w.reviewers[review.CommentAuthor] = true
+	}
+
+	series := make([]models.WeeklyCount, 0, len(weeks))
+	for start, w := range weeks {
+		series = append(series, models.WeeklyCount{
+			WeekStart:    start,
+			Comments:     w.comments,
+			PullRequests: len(w.prs),
+			Reviewers:    len(w.reviewers),
+		})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].WeekStart.Before(series[j].WeekStart)
+	})
+
+	return series
+}
+
+// weekStart returns midnight UTC of the Monday starting the week of t
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
+
+// distribution summarizes values with their mean and percentiles
+func distribution(values []float64) models.Distribution {
+	if len(values) == 0 {
+		return models.Distribution{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	return models.Distribution{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  sum / float64(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P75:   percentile(sorted, 75),
+		P90:   percentile(sorted, 90),
+		P95:   percentile(sorted, 95),
+	}
+}
+
+// percentile returns the p-th percentile of sorted values using linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// languages maps file extensions to the language they are written in
+var languages = map[string]string{
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".go":    "Go",
+	".html":  "HTML",
+	".java":  "Java",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".json":  "JSON",
+	".kt":    "Kotlin",
+	".kts":   "Kotlin",
+	".md":    "Markdown",
+	".php":   "PHP",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".scala": "Scala",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".sql":   "SQL",
+	".swift": "Swift",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".vue":   "Vue",
+	".xml":   "XML",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+}
+
+// detectLanguage guesses the language of a file from its name
+func detectLanguage(filePath string) string {
+	base := path.Base(filePath)
+	switch {
+	case base == "Dockerfile" || strings.HasPrefix(base, "Dockerfile."):
+		return "Dockerfile"
+	case base == "Makefile":
+		return "Makefile"
+	}
+
+	if language, ok := languages[strings.ToLower(path.Ext(base))]; ok {
+		return language
+	}
+	return "Other"
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateStatistics_Detailed(t *testing.T) {
+	repo := models.RepositoryRef{Host: "github.com", Owner: "test", Name: "repo"}
+	created := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC) // Monday
+	merged := created.Add(48 * time.Hour)
+
+	pullRequests := []models.PullRequest{
+		{Number: 1, Repository: repo, Author: "alice", CreatedAt: created, MergedAt: &merged, Additions: 8, Deletions: 2},
+		{Number: 2, Repository: repo, Author: "bob", CreatedAt: created, Additions: 10},
+	}
+
+	review := func(pr int, author, prAuthor, file string, at time.Time) models.Review {
+		return models.Review{
+			PRID:           pr,
+			Repo:           repo,
+			PRAuthor:       prAuthor,
+			CommentAuthor:  author,
+			FilePath:       file,
+			CommentCreated: at,
+		}
+	}
+
+	reviews := []models.Review{
+		review(1, "bob", "alice", "cmd/main.go", created.Add(4*time.Hour)),
+		review(1, "bob", "alice", "cmd/main.go", created.Add(2*time.Hour)),
+		review(1, "alice", "alice", "cmd/main.go", created.Add(time.Hour)), // Author replies don't count as reviews
+		review(1, "carol", "alice", "web/app.ts", created.Add(8*24*time.Hour)),
+		review(2, "alice", "bob", "", created.Add(6*time.Hour)),
+	}
+
+	stats := GenerateStatistics(reviews, pullRequests)
+
+	assert.Equal(t, 5, stats.TotalReviews)
+	assert.Equal(t, 2, stats.TotalPRs)
+	assert.Equal(t, 10.0, stats.AveragePRSize)
+	assert.Equal(t, 2.5, stats.ReviewFrequency)
+
+	assert.Equal(t, []models.Count{{Name: "alice", Count: 2}, {Name: "bob", Count: 2}, {Name: "carol", Count: 1}}, stats.Reviewers)
+	assert.Equal(t, []models.Count{
+		{Repository: "github.com/test/repo", Name: "cmd/main.go", Count: 3},
+		{Repository: "github.com/test/repo", Name: "web/app.ts", Count: 1},
+	}, stats.Files)
+	assert.Equal(t, []models.Count{
+		{Repository: "github.com/test/repo", Name: "cmd", Count: 3},
+		{Repository: "github.com/test/repo", Name: "web", Count: 1},
+	}, stats.Directories)
+	assert.Equal(t, []models.LanguageHotspot{
+		{Language: "Go", Comments: 3, Files: []models.Count{{Repository: "github.com/test/repo", Name: "cmd/main.go", Count: 3}}},
+		{Language: "TypeScript", Comments: 1, Files: []models.Count{{Repository: "github.com/test/repo", Name: "web/app.ts", Count: 1}}},
+	}, stats.Languages)
+
+	// 4 inline comments over 20 changed lines
+	assert.Equal(t, 0.2, stats.CommentDensity)
+
+	assert.Equal(t, models.Distribution{Count: 2, Min: 2, Max: 6, Mean: 4, P50: 4, P75: 5, P90: 5.6, P95: 5.8}, stats.TimeToFirstReview)
+	assert.Equal(t, models.Distribution{Count: 1, Min: 48, Max: 48, Mean: 48, P50: 48, P75: 48, P90: 48, P95: 48}, stats.TimeToMerge)
+
+	assert.Equal(t, []models.Interaction{
+		{Reviewer: "bob", Author: "alice", Comments: 2},
+		{Reviewer: "alice", Author: "bob", Comments: 1},
+		{Reviewer: "carol", Author: "alice", Comments: 1},
+	}, stats.Interactions)
+
+	assert.Equal(t, []models.WeeklyCount{
+		{WeekStart: created.Truncate(24 * time.Hour), Comments: 4, PullRequests: 2, Reviewers: 2},
+		{WeekStart: created.Truncate(24 * time.Hour).Add(7 * 24 * time.Hour), Comments: 1, PullRequests: 1, Reviewers: 1},
+	}, stats.Weekly)
+}
+
+func TestGenerateStatistics_Empty(t *testing.T) {
+	stats := GenerateStatistics(nil, nil)
+
+	assert.Equal(t, 0, stats.TotalReviews)
+	assert.Equal(t, 0.0, stats.AveragePRSize)
+	assert.Equal(t, 0.0, stats.CommentDensity)
+	assert.Equal(t, models.Distribution{}, stats.TimeToFirstReview)
+	assert.Empty(t, stats.Files)
+	assert.Empty(t, stats.Weekly)
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, monday, weekStart(time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, monday, weekStart(time.Date(2024, 6, 9, 23, 59, 0, 0, time.UTC)))
+	assert.Equal(t, monday.AddDate(0, 0, 7), weekStart(time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)))
+
+	// Times are bucketed in UTC
+	cet := time.FixedZone("CET", 2*60*60)
+	assert.Equal(t, monday.AddDate(0, 0, -7), weekStart(time.Date(2024, 6, 3, 1, 0, 0, 0, cet)))
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4}
+
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 2.5, percentile(values, 50))
+	assert.Equal(t, 4.0, percentile(values, 100))
+	assert.Equal(t, 7.0, percentile([]float64{7}, 90))
+}
+
+func TestGenerateStatistics_SamePathInSeveralRepositories(t *testing.T) {
+	api := models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}
+	web := models.RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "web"}
+
+	reviews := []models.Review{
+		{PRID: 1, Repo: api, FilePath: "main.go"},
+		{PRID: 1, Repo: api, FilePath: "main.go"},
+		{PRID: 1, Repo: web, FilePath: "main.go"},
+	}
+
+	stats := GenerateStatistics(reviews, nil)
+
+	files := []models.Count{
+		{Repository: "github.com/customer-a/api", Name: "main.go", Count: 2},
+		{Repository: "github.com/customer-a/web", Name: "main.go", Count: 1},
+	}
+	assert.Equal(t, files, stats.Files)
+	assert.Equal(t, []models.Count{
+		{Repository: "github.com/customer-a/api", Name: ".", Count: 2},
+		{Repository: "github.com/customer-a/web", Name: ".", Count: 1},
+	}, stats.Directories)
+	assert.Equal(t, []models.LanguageHotspot{{Language: "Go", Comments: 3, Files: files}}, stats.Languages)
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"main.go":             "Go",
+		"src/App.TSX":         "TypeScript",
+		"config/app.yml":      "YAML",
+		"build/Dockerfile":    "Dockerfile",
+		"Dockerfile.prod":     "Dockerfile",
+		"Makefile":            "Makefile",
+		"LICENSE":             "Other",
+		"scripts/setup.bash":  "Shell",
+		"docs/guide.markdown": "Other",
+	}
+
+	for file, want := range tests {
+		assert.Equal(t, want, detectLanguage(file), file)
+	}
+}
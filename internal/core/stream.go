@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"iter"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+// StreamExtractor is implemented by extractors that yield the reviews of a repository as they
+// are fetched, pull request by pull request, instead of returning them all at the end
+type StreamExtractor = provider.StreamExtractor
+
+// StreamReviews returns the reviews of a repository as a sequence. Extractors that do not
+// implement StreamExtractor extract the whole repository before the first review is yielded.
+// An error is yielded once, as the last element of the sequence.
+func StreamReviews(ctx context.Context, extractor Extractor, repoURL string) iter.Seq2[models.Review, error] {
+	if streamer, ok := extractor.(StreamExtractor); ok {
+		return streamer.StreamReviews(ctx, repoURL)
+	}
+
+	return func(yield func(models.Review, error) bool) {
+		reviews, err := extractor.ExtractReviews(ctx, repoURL)
+		if err != nil {
+			yield(models.Review{}, err)
+			return
+		}
+		for _, review := range reviews {
+			if !yield(review, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Collect gathers the reviews of a sequence, stopping at the first error. Streaming extractors
+// use it to implement ExtractReviews.
+func Collect(reviews iter.Seq2[models.Review, error]) ([]models.Review, error) {
+	var collected []models.Review
+	for review, err := range reviews {
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, review)
+	}
+	return collected, nil
+}
+
+// Sink receives the reviews of a run as they are extracted
+type Sink = models.Sink
+
+// Collector is a sink keeping the reviews in memory
+type Collector = models.Collector
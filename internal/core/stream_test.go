@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamingExtractor yields its reviews one by one and then its error, recording how many
+// reviews were consumed
+type streamingExtractor struct {
+	reviews  []models.Review
+	err      error
+	consumed int
+}
+
+func (s *streamingExtractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	return Collect(s.StreamReviews(ctx, repoURL))
+}
+
+func (s *streamingExtractor) StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		for _, review := range s.reviews {
+			s.consumed++
+			if !yield(review, nil) {
+				return
+			}
+		}
+		if s.err != nil {
+			yield(models.Review{}, s.err)
+		}
+	}
+}
+
+// failingSink rejects the review with the given comment ID
+type failingSink struct {
+	commentID string
+}
+
+func (f failingSink) WriteReview(review models.Review) error {
+	if review.CommentID == f.commentID {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func TestStreamReviews_SliceExtractor(t *testing.T) {
+	mockExtractor := &MockExtractor{}
+	mockExtractor.On("ExtractReviews", context.Background(), "https://github.com/test/repo").
+		Return([]models.Review{{CommentID: "1"}, {CommentID: "2"}, {CommentID: "3"}}, nil)
+	mockExtractor.On("ExtractReviews", context.Background(), "https://github.com/test/broken").
+		Return([]models.Review{}, errors.New("API error"))
+
+	var ids []string
+	for review, err := range StreamReviews(context.Background(), mockExtractor, "https://github.com/test/repo") {
+		assert.NoError(t, err)
+		ids = append(ids, review.CommentID)
+		if len(ids) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"1", "2"}, ids)
+
+	reviews, err := Collect(StreamReviews(context.Background(), mockExtractor, "https://github.com/test/broken"))
+	assert.EqualError(t, err, "API error")
+	assert.Nil(t, reviews)
+}
+
+func TestReviewExtractor_Run(t *testing.T) {
+	config := &models.Config{
+		SuggestionsOnly: true,
+		Repositories: []models.RepositoryConfig{
+			{Provider: models.ProviderGitHub, URL: "https://github.com/test/repo"},
+		},
+	}
+	suggestion := []models.Suggestion{{OriginalCode: "a", SuggestedCode: "b"}}
+	streamer := &streamingExtractor{reviews: []models.Review{
+		{CommentID: "1", Suggestions: suggestion},
+		{CommentID: "2"},
+		{CommentID: "3", Suggestions: suggestion},
+	}}
+	extractor := NewReviewExtractor(config, map[models.Provider]Extractor{models.ProviderGitHub: streamer})
+
+	var first, second Collector
+	repositories, err := extractor.Run(context.Background(), &first, &second)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repositories)
+	assert.Equal(t, []models.Review{{CommentID: "1", Suggestions: suggestion}, {CommentID: "3", Suggestions: suggestion}}, first.Reviews)
+	assert.Equal(t, first.Reviews, second.Reviews)
+
+	// Extraction stops at the first review a sink rejects
+	streamer.consumed = 0
+	_, err = extractor.Run(context.Background(), failingSink{commentID: "1"})
+	assert.EqualError(t, err, "failed to write review 1: disk full")
+	assert.Equal(t, 1, streamer.consumed)
+
+	// Reviews yielded before an error have been written
+	streamer.err = errors.New("rate limited")
+	var partial Collector
+	_, err = extractor.Run(context.Background(), &partial)
+	assert.EqualError(t, err, "failed to extract reviews from https://github.com/test/repo: rate limited")
+	assert.Len(t, partial.Reviews, 2)
+}
@@ -0,0 +1,126 @@
+package credentials
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+// Source resolves the credential to use for a repository
+type Source = provider.CredentialSource
+
+// Resolver resolves credentials from a configuration
+type Resolver struct {
+	repositories []models.RepositoryConfig
+	discover     []models.DiscoveryConfig
+	credentials  []models.Credential
+	legacy       map[models.Provider]models.Credential
+}
+
+// NewResolver creates a credential source for the repositories of a configuration.
+// Tokens read from files or commands must already be resolved.
+func NewResolver(config *models.Config) *Resolver {
+	// api_token predates support for other providers and is usually a GitHub token, so like
+	// github.token it is never sent to another provider
+	legacy := make(map[models.Provider]models.Credential)
+	switch {
+	case config.GitHub.Token != "":
+		legacy[models.ProviderGitHub] = models.Credential{Method: models.AuthBearer, Token: config.GitHub.Token}
+	case config.APIToken != "":
+		legacy[models.ProviderGitHub] = models.Credential{Method: models.AuthBearer, Token: config.APIToken}
+	}
+
+	return &Resolver{
+		repositories: config.Repositories,
+		discover:     config.Discover,
+		credentials:  config.Credentials,
+		legacy:       legacy,
+	}
+}
+
+// Credential returns the credential for a repository, trying in order: the repository's own
+// credential, the credential of a discovery entry for its owner, a credentials entry for its
+// host, a credentials entry for its provider and, for GitHub repositories only, github.token
+// and finally api_token
+func (r *Resolver) Credential(provider models.Provider, repoURL string) (models.Credential, bool) {
+	if credential, ok := r.Explicit(provider, repoURL); ok {
+		return credential, true
+	}
+
+	if credential, ok := r.legacy[provider]; ok {
+		return credential, true
+	}
+
+	return models.Credential{}, false
+}
+
+// Explicit returns the credential configured specifically for a repository: its own
+// credential, that of a discovery entry for its owner, or a credentials entry for its host
+// or provider. The github.token and api_token fallbacks are never returned.
+func (r *Resolver) Explicit(provider models.Provider, repoURL string) (models.Credential, bool) {
+	for _, repo := range r.repositories {
+		if repo.Credential != nil && repo.Provider == provider && repo.URL == repoURL {
+			return *repo.Credential, true
+		}
+	}
+
+	owner := Owner(repoURL)
+	for _, discovery := range r.discover {
+		if discovery.Credential != nil && discovery.Provider == provider && strings.EqualFold(discovery.Org, owner) {
+			return *discovery.Credential, true
+		}
+	}
+
+	host := Host(repoURL)
+	for _, credential := range r.credentials {
+		if credential.Host != "" && strings.EqualFold(credential.Host, host) &&
+			(credential.Provider == "" || credential.Provider == provider) {
+			return credential, true
+		}
+	}
+
+	for _, credential := range r.credentials {
+		if credential.Host == "" && credential.Provider == provider {
+			return credential, true
+		}
+	}
+
+	return models.Credential{}, false
+}
+
+// Host returns the lowercase host of a repository URL, or an empty string if it has none
+func Host(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// Owner returns the first path segment of a repository URL, the organization or user owning
+// it, or an empty string if it has none
+func Owner(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return ""
+	}
+	owner, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	return owner
+}
+
+// staticSource returns the same credential for every repository
+type staticSource struct {
+	credential models.Credential
+}
+
+// Static creates a source returning the same credential for every repository
+func Static(credential models.Credential) Source {
+	return staticSource{credential: credential}
+}
+
+// Credential implements Source
+func (s staticSource) Credential(provider models.Provider, repoURL string) (models.Credential, bool) {
+	return s.credential, !s.credential.IsZero()
+}
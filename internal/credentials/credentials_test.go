@@ -0,0 +1,107 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver(t *testing.T) {
+	override := &models.Credential{Method: models.AuthBearer, Token: "repo-token"}
+	config := &models.Config{
+		APIToken: "api-token",
+		GitHub:   models.GitHubConfig{Token: "github-token"},
+		Credentials: []models.Credential{
+			{Provider: "bitbucket", Method: models.AuthAppPassword, Username: "bot", Password: "app-password"},
+			{Host: "GitHub.Example.com", Token: "enterprise-token"},
+			{Provider: "gitlab", Token: "gitlab-token"},
+		},
+		Repositories: []models.RepositoryConfig{
+			{Provider: models.ProviderGitHub, URL: "https://github.com/acme/secret", Credential: override},
+		},
+		Discover: []models.DiscoveryConfig{
+			{Provider: models.ProviderGitHub, Org: "Customer-A", Credential: &models.Credential{Token: "customer-token"}},
+		},
+	}
+	resolver := NewResolver(config)
+
+	tests := []struct {
+		name     string
+		provider models.Provider
+		url      string
+		want     string
+	}{
+		{"repository override", models.ProviderGitHub, "https://github.com/acme/secret", "repo-token"},
+		{"discovery", models.ProviderGitHub, "https://github.com/customer-a/api", "customer-token"},
+		{"discovery org", models.ProviderGitHub, "https://github.com/customer-a", "customer-token"},
+		{"host", models.ProviderGitHub, "https://github.example.com/acme/api", "enterprise-token"},
+		{"provider", "gitlab", "https://gitlab.com/acme/api", "gitlab-token"},
+		{"github token", models.ProviderGitHub, "https://github.com/acme/api", "github-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, ok := resolver.Credential(tt.provider, tt.url)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, credential.Token)
+		})
+	}
+
+	credential, ok := resolver.Credential("bitbucket", "https://bitbucket.org/acme/api")
+	assert.True(t, ok)
+	assert.Equal(t, models.Credential{Provider: "bitbucket", Method: models.AuthAppPassword, Username: "bot", Password: "app-password"}, credential)
+}
+
+func TestResolver_APITokenOnlyForGitHub(t *testing.T) {
+	resolver := NewResolver(&models.Config{APIToken: "api-token"})
+
+	credential, ok := resolver.Credential(models.ProviderGitHub, "https://github.com/acme/api")
+	assert.True(t, ok)
+	assert.Equal(t, "api-token", credential.Token)
+
+	for _, repo := range []struct {
+		provider models.Provider
+		url      string
+	}{
+		{models.ProviderGitea, "https://gitea.com/acme/api"},
+		{models.ProviderGerrit, "https://review.example.com/acme/api"},
+		{models.ProviderAzureDevOps, "https://dev.azure.com/acme/platform/_git/api"},
+	} {
+		_, ok := resolver.Credential(repo.provider, repo.url)
+		assert.False(t, ok, "api_token is not sent to %s", repo.provider)
+	}
+}
+
+func TestResolver_Explicit(t *testing.T) {
+	resolver := NewResolver(&models.Config{
+		APIToken:    "api-token",
+		Credentials: []models.Credential{{Host: "review.example.com", Token: "host-token"}},
+	})
+
+	credential, ok := resolver.Explicit("critic", "https://review.example.com/tools/api")
+	assert.True(t, ok)
+	assert.Equal(t, "host-token", credential.Token)
+
+	_, ok = resolver.Explicit(models.ProviderGitHub, "https://github.com/acme/api")
+	assert.False(t, ok, "fallback tokens are not explicit")
+}
+
+func TestResolver_None(t *testing.T) {
+	_, ok := NewResolver(&models.Config{}).Credential(models.ProviderGitHub, "https://github.com/acme/api")
+	assert.False(t, ok)
+}
+
+func TestOwner(t *testing.T) {
+	assert.Equal(t, "acme", Owner("https://github.com/acme/api"))
+	assert.Equal(t, "acme", Owner("https://github.com/acme"))
+	assert.Equal(t, "", Owner("https://github.com"))
+}
+
+func TestStatic(t *testing.T) {
+	credential, ok := Static(models.Credential{Token: "token"}).Credential(models.ProviderGitHub, "https://github.com/acme/api")
+	assert.True(t, ok)
+	assert.Equal(t, "token", credential.Token)
+
+	_, ok = Static(models.Credential{}).Credential(models.ProviderGitHub, "https://github.com/acme/api")
+	assert.False(t, ok)
+}
@@ -0,0 +1,289 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LineKind identifies the role of a line inside a diff hunk
+type LineKind int
+
+const (
+	LineContext LineKind = iota
+	LineAdded
+	LineRemoved
+)
+
+// Line represents a single line inside a diff hunk
+type Line struct {
+	Kind      LineKind
+	Content   string
+	OldNumber int
+	NewNumber int
+}
+
+// Hunk represents a contiguous block of changes in a file diff
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// File represents the changes made to a single file in a unified diff
+type File struct {
+	OldPath string
+	NewPath string
+	Hunks   []Hunk
+}
+
+// Parse parses a unified diff (as produced by git) into per-file hunks
+func Parse(diff string) []File {
+	var files []File
+	var file *File
+	var hunk *Hunk
+	var oldLine, newLine, oldLeft, newLeft int
+
+	for _, line := range strings.Split(diff, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, File{})
+			file = &files[len(files)-1]
+			hunk = nil
+			if oldPath, newPath, ok := parseGitHeader(line); ok {
+				file.OldPath = oldPath
+				file.NewPath = newPath
+			}
+			continue
+		case hunk == nil && strings.HasPrefix(line, "--- "):
+			if file == nil {
+				files = append(files, File{})
+				file = &files[len(files)-1]
+			}
+			file.OldPath = trimPathPrefix(strings.TrimPrefix(line, "--- "), "a/")
+			continue
+		case hunk == nil && strings.HasPrefix(line, "+++ "):
+			if file != nil {
+				file.NewPath = trimPathPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			}
+			continue
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				// Hunks without a file header, as in the per-file patches returned by APIs
+				files = append(files, File{})
+				file = &files[len(files)-1]
+			}
+			h, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			file.Hunks = append(file.Hunks, h)
+			hunk = &file.Hunks[len(file.Hunks)-1]
+			oldLine, newLine = h.OldStart, h.NewStart
+			oldLeft, newLeft = h.OldLines, h.NewLines
+			continue
+		}
+
+		if hunk == nil {
+			continue
+		}
+
+		// Some tools strip the trailing space from empty context lines
+		if line == "" {
+			line = " "
+		}
+
+		switch line[0] {
+		case '+':
+			hunk.Lines = append(hunk.Lines, Line{Kind: LineAdded, Content: line[1:], NewNumber: newLine})
+			newLine++
+			newLeft--
+		case '-':
+			hunk.Lines = append(hunk.Lines, Line{Kind: LineRemoved, Content: line[1:], OldNumber: oldLine})
+			oldLine++
+			oldLeft--
+		case ' ':
+			hunk.Lines = append(hunk.Lines, Line{Kind: LineContext, Content: line[1:], OldNumber: oldLine, NewNumber: newLine})
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		case '\\':
+			// "\ No newline at end of file"
+			continue
+		default:
+			// Anything else ends the hunk (e.g. extended headers of the next file)
+			hunk = nil
+			continue
+		}
+
+		// The header tells us how many lines the hunk spans, which keeps
+		// "--- " removals from being mistaken for the next file header
+		if oldLeft <= 0 && newLeft <= 0 {
+			hunk = nil
+		}
+	}
+
+	return files
+}
+
+// ParsePatch parses the hunks of a single-file patch that has no file headers
+func ParsePatch(patch string) []Hunk {
+	files := Parse(patch)
+	if len(files) == 0 {
+		return nil
+	}
+	return files[0].Hunks
+}
+
+// Touches reports whether any of the hunks change old-side lines start..end (inclusive),
+// either by removing or replacing them or by inserting new lines between them
+func Touches(hunks []Hunk, start, end int) bool {
+	for _, h := range hunks {
+		// oldCursor is the old-side line the next added line is inserted before. A hunk with
+		// no old lines, such as "@@ -5,0 +6,2 @@", inserts after its start line.
+		oldCursor := h.OldStart
+		if h.OldLines == 0 {
+			oldCursor++
+		}
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case LineRemoved:
+				if l.OldNumber >= start && l.OldNumber <= end {
+					return true
+				}
+				oldCursor = l.OldNumber + 1
+			case LineContext:
+				oldCursor = l.OldNumber + 1
+			case LineAdded:
+				if oldCursor > start && oldCursor <= end {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// FindFile returns the diff for the given path, matching either side of a rename
+func FindFile(files []File, path string) *File {
+	for i := range files {
+		if files[i].NewPath == path || files[i].OldPath == path {
+			return &files[i]
+		}
+	}
+	return nil
+}
+
+// NewLines returns the content of lines start..end (inclusive) on the new side of the file.
+// Lines that are not visible in the diff are skipped.
+func (f *File) NewLines(start, end int) []string {
+	var lines []string
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind != LineRemoved && l.NewNumber >= start && l.NewNumber <= end {
+				lines = append(lines, l.Content)
+			}
+		}
+	}
+	return lines
+}
+
+// OldLines returns the content of lines start..end (inclusive) on the old side of the file.
+// Lines that are not visible in the diff are skipped.
+func (f *File) OldLines(start, end int) []string {
+	var lines []string
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind != LineAdded && l.OldNumber >= start && l.OldNumber <= end {
+				lines = append(lines, l.Content)
+			}
+		}
+	}
+	return lines
+}
+
+// Context returns the lines of the hunks within radius lines of line, on the old side of the
+// file if old is set and on the new side otherwise, trimmed and joined like the diff
+// context of review comments. It returns an empty string if the line is not in the hunks.
+func Context(hunks []Hunk, line int, old bool, radius int) string {
+	var lines []string
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			number := l.NewNumber
+			if old {
+				number = l.OldNumber
+			}
+			if (old && l.Kind == LineAdded) || (!old && l.Kind == LineRemoved) {
+				continue
+			}
+			if number >= line-radius && number <= line+radius {
+				lines = append(lines, strings.TrimSpace(l.Content))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseGitHeader extracts the old and new paths from a "diff --git a/x b/y" line
+func parseGitHeader(line string) (oldPath, newPath string, ok bool) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	idx := strings.Index(rest, " b/")
+	if !strings.HasPrefix(rest, "a/") || idx < 0 {
+		return "", "", false
+	}
+	return rest[2:idx], rest[idx+3:], true
+}
+
+// parseHunkHeader parses a "@@ -a,b +c,d @@" hunk header
+func parseHunkHeader(line string) (Hunk, bool) {
+	parts := strings.Fields(line)
+	if len(parts) < 3 || !strings.HasPrefix(parts[1], "-") || !strings.HasPrefix(parts[2], "+") {
+		return Hunk{}, false
+	}
+
+	oldStart, oldLines, ok := parseRange(parts[1][1:])
+	if !ok {
+		return Hunk{}, false
+	}
+	newStart, newLines, ok := parseRange(parts[2][1:])
+	if !ok {
+		return Hunk{}, false
+	}
+
+	return Hunk{
+		OldStart: oldStart,
+		OldLines: oldLines,
+		NewStart: newStart,
+		NewLines: newLines,
+	}, true
+}
+
+// parseRange parses a "start,count" range where the count defaults to 1
+func parseRange(s string) (start, count int, ok bool) {
+	startStr, countStr, hasCount := strings.Cut(s, ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !hasCount {
+		return start, 1, true
+	}
+	count, err = strconv.Atoi(countStr)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, count, true
+}
+
+// trimPathPrefix strips the a/ or b/ prefix git adds to paths, mapping /dev/null to an empty path
+func trimPathPrefix(path, prefix string) string {
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, prefix)
+}
@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,5 +1,6 @@
+ package main
+ 
+-var timeout = 30
++const defaultTimeout = 300
++var timeout = defaultTimeout
+ 
+ func main() {}
+diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+--- a/old.go
++++ b/new.go
+@@ -10,2 +10,2 @@ func helper() {
+--- removed line that looks like a header
++added line
+ context
+`
+
+func TestParse(t *testing.T) {
+	files := Parse(sampleDiff)
+	assert.Len(t, files, 2)
+
+	assert.Equal(t, "main.go", files[0].OldPath)
+	assert.Equal(t, "main.go", files[0].NewPath)
+	assert.Len(t, files[0].Hunks, 1)
+
+	hunk := files[0].Hunks[0]
+	assert.Equal(t, 1, hunk.OldStart)
+	assert.Equal(t, 5, hunk.OldLines)
+	assert.Equal(t, 1, hunk.NewStart)
+	assert.Equal(t, 6, hunk.NewLines)
+	assert.Len(t, hunk.Lines, 7)
+	assert.Equal(t, Line{Kind: LineRemoved, Content: "var timeout = 30", OldNumber: 3}, hunk.Lines[2])
+	assert.Equal(t, Line{Kind: LineAdded, Content: "var timeout = defaultTimeout", NewNumber: 4}, hunk.Lines[4])
+	assert.Equal(t, Line{Kind: LineContext, Content: "func main() {}", OldNumber: 5, NewNumber: 6}, hunk.Lines[6])
+
+	assert.Equal(t, "old.go", files[1].OldPath)
+	assert.Equal(t, "new.go", files[1].NewPath)
+	assert.Len(t, files[1].Hunks, 1)
+	assert.Equal(t, LineRemoved, files[1].Hunks[0].Lines[0].Kind)
+	assert.Equal(t, "-- removed line that looks like a header", files[1].Hunks[0].Lines[0].Content)
+}
+
+func TestParse_Empty(t *testing.T) {
+	assert.Empty(t, Parse(""))
+	assert.Empty(t, Parse("not a diff"))
+}
+
+func TestFindFile(t *testing.T) {
+	files := Parse(sampleDiff)
+
+	assert.Equal(t, "main.go", FindFile(files, "main.go").NewPath)
+	assert.Equal(t, "new.go", FindFile(files, "old.go").NewPath)
+	assert.Equal(t, "new.go", FindFile(files, "new.go").NewPath)
+	assert.Nil(t, FindFile(files, "missing.go"))
+}
+
+func TestFileLines(t *testing.T) {
+	file := FindFile(Parse(sampleDiff), "main.go")
+
+	assert.Equal(t, []string{"const defaultTimeout = 300", "var timeout = defaultTimeout"}, file.NewLines(3, 4))
+	assert.Equal(t, []string{"var timeout = 30"}, file.OldLines(3, 3))
+	assert.Empty(t, file.NewLines(50, 60))
+}
+
+func TestParsePatch(t *testing.T) {
+	hunks := ParsePatch("@@ -3,2 +3,2 @@ func main() {\n-old\n+new\n context")
+	assert.Len(t, hunks, 1)
+	assert.Equal(t, 3, hunks[0].OldStart)
+	assert.Len(t, hunks[0].Lines, 3)
+
+	assert.Nil(t, ParsePatch(""))
+}
+
+func TestContext(t *testing.T) {
+	hunks := ParsePatch("@@ -10,4 +10,5 @@\n a\n-b\n+B\n c\n+inserted\n d")
+
+	assert.Equal(t, "B\nc\ninserted", Context(hunks, 12, false, 1))
+	assert.Equal(t, "a\nb", Context(hunks, 10, true, 1))
+	assert.Empty(t, Context(hunks, 50, false, 3))
+}
+
+func TestTouches(t *testing.T) {
+	hunks := ParsePatch("@@ -10,4 +10,5 @@\n a\n-b\n+B\n c\n+inserted\n d")
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       bool
+	}{
+		{name: "replaced line", start: 11, end: 11, want: true},
+		{name: "range containing replaced line", start: 9, end: 12, want: true},
+		{name: "insertion inside range", start: 12, end: 13, want: true},
+		{name: "insertion right after single line", start: 12, end: 12, want: false},
+		{name: "context line", start: 10, end: 10, want: false},
+		{name: "outside hunk", start: 40, end: 42, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Touches(hunks, tt.start, tt.end))
+		})
+	}
+}
+
+func TestTouches_PureInsertion(t *testing.T) {
+	// Two lines inserted after old line 5
+	hunks := ParsePatch("@@ -5,0 +6,2 @@\n+one\n+two")
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       bool
+	}{
+		{name: "range around insertion", start: 5, end: 6, want: true},
+		{name: "range ending at insertion point", start: 4, end: 5, want: false},
+		{name: "range starting after insertion", start: 6, end: 7, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Touches(hunks, tt.start, tt.end))
+		})
+	}
+}
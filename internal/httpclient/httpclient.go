@@ -0,0 +1,162 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// Error is an error response of a provider's API
+type Error struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Message)
+}
+
+// Get performs a GET request with client and returns the response body and headers. prepare
+// adds the credential and any headers to the request. Responses other than 200 OK are
+// returned as an *Error, with the message that message finds in the body, or the status text.
+func Get(ctx context.Context, client *http.Client, url string, prepare func(*http.Request), message func([]byte) string) ([]byte, http.Header, error) {
+	return do(ctx, client, http.MethodGet, url, nil, prepare, message)
+}
+
+// Post performs a POST request with a JSON body, for the few read-only API calls that take
+// their parameters in the body. It otherwise behaves like Get.
+func Post(ctx context.Context, client *http.Client, url string, body any, prepare func(*http.Request), message func([]byte) string) ([]byte, http.Header, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return do(ctx, client, http.MethodPost, url, data, prepare, message)
+}
+
+// do performs a request for Get and Post
+func do(ctx context.Context, client *http.Client, method, url string, body []byte, prepare func(*http.Request), message func([]byte) string) ([]byte, http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	prepare(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{Method: method, URL: url, StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		if text := message(data); text != "" {
+			apiErr.Message = text
+		}
+		return nil, nil, apiErr
+	}
+	return data, resp.Header, nil
+}
+
+// JSONMessage returns the "message" field of a JSON error body, as Gitea and Azure DevOps
+// send it
+func JSONMessage(data []byte) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return ""
+	}
+	return body.Message
+}
+
+// Authorize adds a credential to a request. Logins and app passwords use basic auth; tokens
+// the Authorization header with scheme, e.g. "Bearer", or, if scheme is empty, the password
+// of basic auth with an empty username. Empty credentials leave the request unauthenticated.
+func Authorize(req *http.Request, credential models.Credential, scheme string) {
+	switch {
+	case credential.IsZero():
+		// Unauthenticated
+	case credential.AuthMethod() == models.AuthBasic || credential.AuthMethod() == models.AuthAppPassword:
+		req.SetBasicAuth(credential.Username, credential.Password)
+	case scheme == "":
+		req.SetBasicAuth("", credential.Token)
+	default:
+		req.Header.Set("Authorization", scheme+" "+credential.Token)
+	}
+}
+
+// Clients creates the API clients of one provider and caches them per API base URL and
+// credential, so repositories on the same server with the same credential share a client
+type Clients[C any] struct {
+	provider    models.Provider
+	authMethods []models.AuthMethod
+	credentials credentials.Source
+	newClient   func(baseURL string, credential models.Credential) C
+	clients     map[clientKey]C
+}
+
+// clientKey identifies a cached client
+type clientKey struct {
+	baseURL    string
+	credential models.Credential
+}
+
+// NewClients creates a client cache for a provider that accepts the authMethods, resolving
+// credentials from source, which may be nil
+func NewClients[C any](provider models.Provider, authMethods []models.AuthMethod, source credentials.Source, newClient func(baseURL string, credential models.Credential) C) *Clients[C] {
+	return &Clients[C]{
+		provider:    provider,
+		authMethods: authMethods,
+		credentials: source,
+		newClient:   newClient,
+		clients:     make(map[clientKey]C),
+	}
+}
+
+// For returns the client of a repository served by the API at baseURL and whether it is
+// authenticated. Repositories without a credential get an unauthenticated client; a
+// credential of a method the provider does not accept is an error.
+func (c *Clients[C]) For(repoURL, baseURL string) (C, bool, error) {
+	var credential models.Credential
+	authenticated := false
+	if c.credentials != nil {
+		credential, authenticated = c.credentials.Credential(c.provider, repoURL)
+	}
+	if !authenticated || credential.IsZero() {
+		credential = models.Credential{}
+	}
+
+	if !slices.Contains(c.authMethods, credential.AuthMethod()) {
+		var zero C
+		return zero, false, fmt.Errorf("%s does not support %s authentication", c.provider, credential.Method)
+	}
+
+	key := clientKey{baseURL: baseURL, credential: credential}
+	client, ok := c.clients[key]
+	if !ok {
+		client = c.newClient(baseURL, credential)
+		c.clients[key] = client
+	}
+	return client, !credential.IsZero(), nil
+}
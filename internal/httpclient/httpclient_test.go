@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		w.Header().Set("X-Total-Count", "3")
+		fmt.Fprint(w, `[1, 2, 3]`)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "The target couldn't be found."}`)
+	})
+	mux.HandleFunc("/denied", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	prepare := func(req *http.Request) {
+		Authorize(req, models.Credential{Token: "secret"}, "token")
+	}
+	get := func(path string) ([]byte, http.Header, error) {
+		return Get(context.Background(), server.Client(), server.URL+path, prepare, JSONMessage)
+	}
+
+	data, header, err := get("/ok")
+	assert.NoError(t, err)
+	assert.Equal(t, `[1, 2, 3]`, string(data))
+	assert.Equal(t, "3", header.Get("X-Total-Count"))
+
+	_, _, err = get("/missing")
+	var apiErr *Error
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.EqualError(t, err, "GET "+server.URL+"/missing: 404 The target couldn't be found.")
+
+	_, _, err = get("/denied")
+	assert.ErrorContains(t, err, "401 Unauthorized", "the status text is used without a message")
+}
+
+func TestPost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"path":"/main.go"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	t.Cleanup(server.Close)
+
+	data, _, err := Post(context.Background(), server.Client(), server.URL, map[string]string{"path": "/main.go"}, func(*http.Request) {}, JSONMessage)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ok": true}`, string(data))
+
+	_, _, err = Post(context.Background(), server.Client(), server.URL, map[string]string{}, func(*http.Request) {}, JSONMessage)
+	assert.EqualError(t, err, "POST "+server.URL+": 400 Bad Request")
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name       string
+		credential models.Credential
+		scheme     string
+		header     string
+	}{
+		{name: "unauthenticated", scheme: "Bearer"},
+		{name: "bearer token", credential: models.Credential{Token: "secret"}, scheme: "Bearer", header: "Bearer secret"},
+		{name: "token scheme", credential: models.Credential{Token: "secret"}, scheme: "token", header: "token secret"},
+		// base64(":secret")
+		{name: "token as password", credential: models.Credential{Token: "secret"}, header: "Basic OnNlY3JldA=="},
+		// base64("bot:password")
+		{name: "login", credential: models.Credential{Method: models.AuthBasic, Username: "bot", Password: "password"}, scheme: "Bearer", header: "Basic Ym90OnBhc3N3b3Jk"},
+		{name: "app password", credential: models.Credential{Method: models.AuthAppPassword, Username: "bot", Password: "password"}, scheme: "Bearer", header: "Basic Ym90OnBhc3N3b3Jk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+			Authorize(req, tt.credential, tt.scheme)
+			assert.Equal(t, tt.header, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestClients(t *testing.T) {
+	type client struct {
+		baseURL    string
+		credential models.Credential
+	}
+	clients := NewClients(models.ProviderGitea, []models.AuthMethod{models.AuthBearer, models.AuthBasic},
+		credentials.NewResolver(&models.Config{
+			Credentials: []models.Credential{
+				{Host: "git.example.com", Token: "token"},
+				{Host: "apps.example.com", Method: models.AuthGitHubApp, AppID: 1, PrivateKey: "key"},
+			},
+		}),
+		func(baseURL string, credential models.Credential) *client {
+			return &client{baseURL: baseURL, credential: credential}
+		})
+
+	api, authenticated, err := clients.For("https://git.example.com/acme/api", "https://git.example.com/api/v1")
+	assert.NoError(t, err)
+	assert.True(t, authenticated)
+	assert.Equal(t, "token", api.credential.Token)
+	web, _, _ := clients.For("https://git.example.com/acme/web", "https://git.example.com/api/v1")
+	assert.Same(t, api, web, "repositories on the same server with the same credential share a client")
+
+	public, authenticated, err := clients.For("https://codeberg.org/acme/api", "https://codeberg.org/api/v1")
+	assert.NoError(t, err)
+	assert.False(t, authenticated)
+	assert.Equal(t, "https://codeberg.org/api/v1", public.baseURL)
+	assert.True(t, public.credential.IsZero())
+
+	_, _, err = clients.For("https://apps.example.com/acme/api", "https://apps.example.com/api/v1")
+	assert.EqualError(t, err, "gitea does not support github_app authentication")
+
+	unconfigured := NewClients(models.ProviderGitea, []models.AuthMethod{models.AuthBearer}, nil,
+		func(baseURL string, credential models.Credential) *client { return &client{baseURL: baseURL} })
+	_, authenticated, err = unconfigured.For("https://git.example.com/acme/api", "https://git.example.com/api/v1")
+	assert.NoError(t, err)
+	assert.False(t, authenticated)
+}
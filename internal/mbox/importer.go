@@ -0,0 +1,448 @@
+package mbox
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jesper/review-extractor/internal/diff"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// diffContextRadius is the number of lines around a commented line kept as diff context
+const diffContextRadius = 3
+
+// anchorLines is the number of trailing quoted diff lines matched against the patch to
+// locate a comment; fewer are tried when reviewers trimmed the quote
+const anchorLines = 3
+
+var (
+	// patchSubject matches "[PATCH]", "[PATCH v2 3/5]", "[RFC PATCH net-next 0/2]" and the like
+	patchSubject = regexp.MustCompile(`^\s*\[([^\]]*\bPATCH\b[^\]]*)\]\s*(.*)$`)
+	// patchNumber matches the "3/5" position of a patch in its series
+	patchNumber = regexp.MustCompile(`\b(\d+)/(\d+)\b`)
+	// patchVersion matches the "v2" revision of a series
+	patchVersion = regexp.MustCompile(`(?i)\bv(\d+)\b`)
+	// attribution matches the "On ..., Alice wrote:" line clients put above a quote
+	attribution = regexp.MustCompile(`(?i)\b(wrote|writes):\s*$`)
+	// trailer matches the review tags of the kernel and similar projects
+	trailer = regexp.MustCompile(`(?i)^(reviewed|acked|nacked)-by:`)
+)
+
+// Options configures how mailing-list threads are mapped to the shared model
+type Options struct {
+	// Repo is the repository the patches were sent for
+	Repo models.RepositoryRef
+	// ArchiveURL, if set, is joined with the Message-ID of a series to link to it in a list
+	// archive, e.g. https://lore.kernel.org/r/
+	ArchiveURL string
+}
+
+// patch is a message of a series: the cover letter or a patch with its parsed diff
+type patch struct {
+	message *Message
+	series  *series
+	files   []diff.File
+	lines   []patchLine
+}
+
+// patchLine is a non-blank diff line of a patch, flattened across files and hunks so
+// quoted lines can be matched in sequence
+type patchLine struct {
+	file *diff.File
+	line diff.Line
+}
+
+// series is a set of patches sent together, which maps to a pull request
+type series struct {
+	root        *Message
+	title       string
+	version     int
+	patches     []*patch
+	pullRequest *models.PullRequest
+}
+
+// Import reconstructs [PATCH] series and their review threads from mailing-list messages.
+// Each series becomes a pull request, numbered in the order the series were sent, and each
+// reply in a series' thread becomes one or more reviews: inline comments are anchored to
+// the file and line of the diff lines quoted right above them. Messages outside patch
+// threads are ignored.
+func Import(messages []*Message, opts Options) []models.Review {
+	sorted := append([]*Message(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	byID := make(map[string]*Message, len(sorted))
+	for _, message := range sorted {
+		if _, ok := byID[message.ID]; !ok {
+			byID[message.ID] = message
+		}
+	}
+
+	// Group patches into series; later patches join the series of a patch they reply to
+	patches := make(map[string]*patch)
+	var allSeries []*series
+	for _, message := range sorted {
+		prefix, title, ok := parseSubject(message.Subject)
+		if !ok || patches[message.ID] != nil {
+			continue
+		}
+		number, _ := parsePosition(prefix)
+		version := parseVersion(prefix)
+
+		p := &patch{message: message}
+		if number != 0 {
+			p.files = diff.Parse(message.Body)
+			if len(p.files) == 0 {
+				continue
+			}
+			p.lines = flatten(p.files)
+		}
+
+		parent := nearestPatch(message.Parent(), byID, patches)
+		if parent != nil && parent.series.version == version && number != 0 {
+			p.series = parent.series
+		} else {
+			p.series = &series{root: message, title: title, version: version}
+			allSeries = append(allSeries, p.series)
+		}
+		p.series.patches = append(p.series.patches, p)
+		patches[message.ID] = p
+	}
+
+	for i, s := range allSeries {
+		s.pullRequest = convertSeries(s, i+1, opts)
+	}
+
+	// Every other message of a series' thread is a review of the nearest patch above it
+	var reviews []models.Review
+	for _, message := range sorted {
+		if patches[message.ID] != nil {
+			continue
+		}
+		p := nearestPatch(message.Parent(), byID, patches)
+		if p == nil {
+			continue
+		}
+		reviews = append(reviews, convertReply(message, p, opts)...)
+	}
+
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].PRID < reviews[j].PRID
+	})
+	return reviews
+}
+
+// nearestPatch walks up a thread from the message with the given ID and returns the first
+// patch or cover letter it meets
+func nearestPatch(id string, byID map[string]*Message, patches map[string]*patch) *patch {
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		seen[id] = true
+		if p, ok := patches[id]; ok {
+			return p
+		}
+		message, ok := byID[id]
+		if !ok {
+			return nil
+		}
+		id = message.Parent()
+	}
+	return nil
+}
+
+// convertSeries maps a series to a pull request. Mailing lists do not record whether a
+// series was applied, so the pull request has no state.
+func convertSeries(s *series, number int, opts Options) *models.PullRequest {
+	pullRequest := &models.PullRequest{
+		Number:     number,
+		Repository: opts.Repo,
+		Provider:   models.ProviderMailingList,
+		Title:      s.title,
+		Author:     s.root.From,
+		CreatedAt:  s.root.Date,
+	}
+	if opts.ArchiveURL != "" {
+		pullRequest.URL = strings.TrimSuffix(opts.ArchiveURL, "/") + "/" + s.root.ID
+	}
+
+	paths := make(map[string]bool)
+	for _, p := range s.patches {
+		for _, file := range p.files {
+			paths[filePath(&file)] = true
+			for _, hunk := range file.Hunks {
+				for _, line := range hunk.Lines {
+					switch line.Kind {
+					case diff.LineAdded:
+						pullRequest.Additions++
+					case diff.LineRemoved:
+						pullRequest.Deletions++
+					}
+				}
+			}
+		}
+	}
+	pullRequest.ChangedFiles = len(paths)
+
+	return pullRequest
+}
+
+// block is a run of the reviewer's own lines together with the quoted lines above it
+type block struct {
+	quoted []string
+	text   []string
+}
+
+// convertReply maps a reply to reviews, one per block of the reviewer's own text. Blocks
+// below quoted diff lines are anchored to the last quoted line; the others, such as a
+// top-posted reply or a comment on the commit message, are not anchored to a file. Review
+// tags in the reply set the review state of all its blocks.
+func convertReply(message *Message, p *patch, opts Options) []models.Review {
+	blocks := splitBlocks(message.Body)
+
+	state := models.ReviewStateCommented
+	for _, b := range blocks {
+		for _, line := range b.text {
+			if match := trailer.FindStringSubmatch(line); match != nil {
+				if strings.EqualFold(match[1], "nacked") {
+					state = models.ReviewStateChangesRequested
+				} else if state != models.ReviewStateChangesRequested {
+					state = models.ReviewStateApproved
+				}
+			}
+		}
+	}
+
+	pullRequest := p.series.pullRequest
+	base := models.Review{
+		PRID:           pullRequest.Number,
+		PRTitle:        pullRequest.Title,
+		PRAuthor:       pullRequest.Author,
+		Repository:     opts.Repo.Name,
+		Repo:           opts.Repo,
+		Provider:       models.ProviderMailingList,
+		InReplyTo:      message.Parent(),
+		CommentAuthor:  message.From,
+		CommentCreated: message.Date,
+		ReviewID:       message.ID,
+		ReviewState:    state,
+		PullRequest:    pullRequest,
+	}
+
+	var reviews []models.Review
+	cursor := 0
+	for _, b := range blocks {
+		review := base
+		review.CommentText = strings.Join(b.text, "\n")
+		if onlyTrailers(b.text) {
+			// Tags below a full quote of the patch are not about its last line
+			reviews = append(reviews, review)
+			continue
+		}
+		if index, ok := locate(p.lines, quotedDiff(b.quoted), cursor); ok {
+			cursor = index
+			anchor := p.lines[index]
+			line, old := anchor.line.NewNumber, false
+			if anchor.line.Kind == diff.LineRemoved {
+				line, old = anchor.line.OldNumber, true
+			}
+			review.FilePath = filePath(anchor.file)
+			review.LineNumber = line
+			review.DiffContext = diff.Context(anchor.file.Hunks, line, old, diffContextRadius)
+		}
+		reviews = append(reviews, review)
+	}
+
+	for i := range reviews {
+		reviews[i].CommentID = message.ID
+		if len(reviews) > 1 {
+			reviews[i].CommentID = fmt.Sprintf("%s#%d", message.ID, i+1)
+		}
+	}
+	return reviews
+}
+
+// splitBlocks splits a reply body into the reviewer's blocks of text, each with the quoted
+// lines above it. The signature and the attribution line above the first quote are dropped.
+func splitBlocks(body string) []block {
+	var blocks []block
+	var current block
+	flush := func() {
+		text := trimBlankLines(current.text)
+		if len(text) > 0 {
+			blocks = append(blocks, block{quoted: current.quoted, text: text})
+			current = block{}
+		} else {
+			current.text = nil
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "--" {
+			// Signature separator ("-- " before trailing spaces were trimmed)
+			break
+		}
+		if strings.HasPrefix(line, ">") {
+			if len(current.text) > 0 {
+				// Drop the attribution of the quote that follows
+				if last := len(current.text) - 1; attribution.MatchString(current.text[last]) {
+					current.text = current.text[:last]
+				}
+				flush()
+			}
+			current.quoted = append(current.quoted, line)
+			continue
+		}
+		current.text = append(current.text, line)
+	}
+	flush()
+
+	return blocks
+}
+
+// onlyTrailers reports whether every line of a block is a review tag
+func onlyTrailers(text []string) bool {
+	for _, line := range text {
+		if strings.TrimSpace(line) != "" && !trailer.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// quotedDiff returns the diff lines among quoted lines, unquoted and without blank lines
+func quotedDiff(quoted []string) []string {
+	var lines []string
+	for _, line := range quoted {
+		line = unquote(line)
+		if strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		switch line[0] {
+		case '+', '-', ' ':
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// locate finds the patch line the last quoted diff lines end at, preferring matches at or
+// after cursor so repeated lines such as a closing brace resolve in reading order
+func locate(lines []patchLine, quoted []string, cursor int) (int, bool) {
+	for n := min(anchorLines, len(quoted)); n > 0; n-- {
+		tail := quoted[len(quoted)-n:]
+		for _, from := range []int{cursor, 0} {
+			for i := max(from, n-1); i < len(lines); i++ {
+				if matches(lines[i-n+1:i+1], tail) {
+					return i, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// matches reports whether consecutive patch lines of a single file equal the quoted lines
+func matches(lines []patchLine, quoted []string) bool {
+	for i, l := range lines {
+		if l.file != lines[0].file || render(l.line) != strings.TrimRight(quoted[i], " \t") {
+			return false
+		}
+	}
+	return true
+}
+
+// render formats a diff line as it appears in a patch, without trailing whitespace
+func render(line diff.Line) string {
+	prefix := " "
+	switch line.Kind {
+	case diff.LineAdded:
+		prefix = "+"
+	case diff.LineRemoved:
+		prefix = "-"
+	}
+	return strings.TrimRight(prefix+line.Content, " \t")
+}
+
+// flatten lists the non-blank lines of the files of a patch in order
+func flatten(files []diff.File) []patchLine {
+	var lines []patchLine
+	for i := range files {
+		for _, hunk := range files[i].Hunks {
+			for _, line := range hunk.Lines {
+				if strings.TrimSpace(line.Content) == "" && line.Kind == diff.LineContext {
+					continue
+				}
+				lines = append(lines, patchLine{file: &files[i], line: line})
+			}
+		}
+	}
+	return lines
+}
+
+// unquote strips every level of quoting from a line. Each ">" may be followed by one space
+// added by the quoting client; a second space belongs to the quoted diff line.
+func unquote(line string) string {
+	for strings.HasPrefix(line, ">") {
+		line = strings.TrimPrefix(line[1:], " ")
+	}
+	return line
+}
+
+// trimBlankLines removes leading and trailing blank lines
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// filePath returns the path of a file in a patch, or its old path if it was deleted
+func filePath(file *diff.File) string {
+	if file.NewPath != "" {
+		return file.NewPath
+	}
+	return file.OldPath
+}
+
+// parseSubject splits the subject of a patch into its bracketed prefix and its title.
+// Replies ("Re: [PATCH] ...") are not patches.
+func parseSubject(subject string) (prefix, title string, ok bool) {
+	match := patchSubject.FindStringSubmatch(subject)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], strings.TrimSpace(match[2]), true
+}
+
+// parsePosition returns the position of a patch in its series and the series length. A
+// patch without a position is the only patch of its series.
+func parsePosition(prefix string) (number, total int) {
+	match := patchNumber.FindStringSubmatch(prefix)
+	if match == nil {
+		return 1, 1
+	}
+	number, _ = strconv.Atoi(match[1])
+	total, _ = strconv.Atoi(match[2])
+	return number, total
+}
+
+// parseVersion returns the revision of a series, 1 for the first
+func parseVersion(prefix string) int {
+	match := patchVersion.FindStringSubmatch(prefix)
+	if match == nil {
+		return 1
+	}
+	version, _ := strconv.Atoi(match[1])
+	return version
+}
@@ -0,0 +1,217 @@
+package mbox
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRepo = models.RepositoryRef{Host: "git.kernel.org", Owner: "pub/scm/linux/kernel/git/netdev", Name: "net-next", URL: "https://git.kernel.org/pub/scm/linux/kernel/git/netdev/net-next"}
+
+const testPatch = `Retries are bounded now.
+
+Signed-off-by: Alice <alice@example.com>
+---
+ net/retry.c | 4 +++-
+ 1 file changed, 3 insertions(+), 1 deletion(-)
+
+diff --git a/net/retry.c b/net/retry.c
+--- a/net/retry.c
++++ b/net/retry.c
+@@ -10,5 +10,7 @@ int call(void)
+ {
+ 	for (;;) {
+-		return do_call();
++		if (!do_call())
++			return 0;
++		sleep(1);
+ 	}
+ }
+
+--
+2.40.0
+`
+
+func message(id, parent, from, subject, body string, minutes int) *Message {
+	return &Message{
+		ID:        id,
+		InReplyTo: parent,
+		From:      from,
+		Subject:   subject,
+		Date:      time.Date(2024, 1, 1, 10, minutes, 0, 0, time.UTC),
+		Body:      body,
+	}
+}
+
+func TestImport(t *testing.T) {
+	messages := []*Message{
+		message("reply@x", "patch@x", "bob@example.com", "Re: [PATCH net-next 1/1] net: bound retries",
+			`On Mon, Jan 1, 2024 at 10:00, Alice wrote:
+> Retries are bounded now.
+
+Why now?
+
+> @@ -10,5 +10,7 @@ int call(void)
+>  {
+>  	for (;;) {
+> -		return do_call();
+
+Was this ever reached?
+
+> +		if (!do_call())
+> +			return 0;
+> +		sleep(1);
+
+Sleeping here blocks the caller.
+
+>  	}
+>  }
+
+Reviewed-by: Bob <bob@example.com>
+
+--
+Bob
+`, 30),
+		message("cover@x", "", "alice@example.com", "[PATCH net-next 0/1] net: retry series", "Cover letter", 0),
+		message("patch@x", "cover@x", "alice@example.com", "[PATCH net-next 1/1] net: bound retries", testPatch, 1),
+		message("answer@x", "reply@x", "alice@example.com", "Re: [PATCH net-next 1/1] net: bound retries",
+			`> > +		sleep(1);
+>
+> Sleeping here blocks the caller.
+
+Fixed in v2.`, 45),
+		message("chatter@x", "", "carol@example.com", "Meeting notes", "unrelated", 50),
+		message("cover-reply@x", "cover@x", "dave@example.com", "Re: [PATCH net-next 0/1] net: retry series", "Nacked-by: Dave <dave@example.com>", 55),
+		message("single@x", "", "erin@example.com", "[PATCH] docs: typo", testPatch, 60),
+	}
+
+	reviews := Import(messages, Options{Repo: testRepo, ArchiveURL: "https://lore.kernel.org/r/"})
+	assert.Len(t, reviews, 6)
+
+	why := reviews[0]
+	assert.Equal(t, "reply@x#1", why.CommentID)
+	assert.Equal(t, "reply@x", why.ReviewID)
+	assert.Equal(t, "patch@x", why.InReplyTo)
+	assert.Equal(t, "Why now?", why.CommentText)
+	assert.Empty(t, why.FilePath, "comments on the commit message are not anchored")
+	assert.Equal(t, models.ReviewStateApproved, why.ReviewState)
+	assert.Equal(t, models.ProviderMailingList, why.Provider)
+	assert.Equal(t, testRepo, why.Repo)
+	assert.Equal(t, "net-next", why.Repository)
+
+	removed := reviews[1]
+	assert.Equal(t, "Was this ever reached?", removed.CommentText)
+	assert.Equal(t, "net/retry.c", removed.FilePath)
+	assert.Equal(t, 12, removed.LineNumber)
+	assert.Equal(t, "{\nfor (;;) {\nreturn do_call();\n}\n}", removed.DiffContext)
+
+	added := reviews[2]
+	assert.Equal(t, "Sleeping here blocks the caller.", added.CommentText)
+	assert.Equal(t, 14, added.LineNumber)
+
+	tags := reviews[3]
+	assert.Equal(t, "reply@x#4", tags.CommentID)
+	assert.Equal(t, "Reviewed-by: Bob <bob@example.com>", tags.CommentText)
+	assert.Empty(t, tags.FilePath, "tags below the rest of the patch are not anchored to its last line")
+
+	answer := reviews[4]
+	assert.Equal(t, "answer@x", answer.CommentID)
+	assert.Equal(t, "reply@x", answer.InReplyTo)
+	assert.Equal(t, "alice@example.com", answer.CommentAuthor)
+	assert.Equal(t, "net/retry.c", answer.FilePath, "nested quotes are anchored too")
+	assert.Equal(t, 14, answer.LineNumber)
+	assert.Equal(t, models.ReviewStateCommented, answer.ReviewState)
+
+	nack := reviews[5]
+	assert.Equal(t, models.ReviewStateChangesRequested, nack.ReviewState)
+	assert.Empty(t, nack.FilePath)
+
+	pr := why.PullRequest
+	assert.Equal(t, 1, pr.Number)
+	assert.Equal(t, "net: retry series", pr.Title)
+	assert.Equal(t, "alice@example.com", pr.Author)
+	assert.Equal(t, "https://lore.kernel.org/r/cover@x", pr.URL)
+	assert.Equal(t, 3, pr.Additions)
+	assert.Equal(t, 1, pr.Deletions)
+	assert.Equal(t, 1, pr.ChangedFiles)
+	assert.Empty(t, pr.State)
+	assert.Same(t, pr, nack.PullRequest)
+}
+
+func TestImport_Series(t *testing.T) {
+	messages := []*Message{
+		message("v1@x", "", "alice@example.com", "[PATCH] net: bound retries", testPatch, 0),
+		message("v2@x", "v1@x", "alice@example.com", "[PATCH v2] net: bound retries", testPatch, 10),
+		message("v2-reply@x", "v2@x", "bob@example.com", "Re: [PATCH v2] net: bound retries", "Thanks!", 20),
+		message("p1@x", "", "carol@example.com", "[RFC PATCH 1/2] first", testPatch, 30),
+		message("p2@x", "p1@x", "carol@example.com", "[RFC PATCH 2/2] second", testPatch, 31),
+		message("p2-reply@x", "p2@x", "bob@example.com", "Re: [RFC PATCH 2/2] second", "Nice", 40),
+		message("no-diff@x", "", "dave@example.com", "[PATCH 1/1] nothing", "no diff here", 50),
+	}
+
+	reviews := Import(messages, Options{Repo: testRepo})
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, 2, reviews[0].PRID, "a new revision sent in reply to the old one is a separate series")
+	assert.Equal(t, 3, reviews[1].PRID, "patches threaded under the first patch join its series")
+	assert.Equal(t, "first", reviews[1].PRTitle)
+	assert.Equal(t, 6, reviews[1].PullRequest.Additions, "both patches count towards the series")
+	assert.Equal(t, 2, reviews[1].PullRequest.Deletions)
+	assert.Equal(t, 1, reviews[1].PullRequest.ChangedFiles)
+	assert.Empty(t, reviews[1].PullRequest.URL)
+}
+
+func TestSplitBlocks(t *testing.T) {
+	body := strings.Join([]string{
+		"Top posted",
+		"",
+		"On Mon, Alice wrote:",
+		"> quoted",
+		"",
+		"> more",
+		"inline",
+		"-- ",
+		"signature",
+	}, "\n")
+
+	blocks := splitBlocks(body)
+	assert.Equal(t, []block{
+		{text: []string{"Top posted"}},
+		{quoted: []string{"> quoted", "> more"}, text: []string{"inline"}},
+	}, blocks)
+}
+
+func TestParseSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		prefix  string
+		title   string
+		ok      bool
+		number  int
+		total   int
+		version int
+	}{
+		{subject: "[PATCH] fix", prefix: "PATCH", title: "fix", ok: true, number: 1, total: 1, version: 1},
+		{subject: "[PATCH v3 2/7] net: fix", prefix: "PATCH v3 2/7", title: "net: fix", ok: true, number: 2, total: 7, version: 3},
+		{subject: "[RFC PATCH net-next 0/2] series", prefix: "RFC PATCH net-next 0/2", title: "series", ok: true, number: 0, total: 2, version: 1},
+		{subject: "Re: [PATCH] fix"},
+		{subject: "[ANNOUNCE] v6.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			prefix, title, ok := parseSubject(tt.subject)
+			assert.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.prefix, prefix)
+			assert.Equal(t, tt.title, title)
+			number, total := parsePosition(prefix)
+			assert.Equal(t, tt.number, number)
+			assert.Equal(t, tt.total, total)
+			assert.Equal(t, tt.version, parseVersion(prefix))
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message is an email read from an mbox file, reduced to what patch review needs
+type Message struct {
+	// ID is the Message-ID without angle brackets
+	ID string
+	// InReplyTo is the Message-ID of the parent message, if any
+	InReplyTo string
+	// References lists the Message-IDs of the thread, oldest first
+	References []string
+	// From is the lowercased sender address and Name the sender's display name
+	From    string
+	Name    string
+	Date    time.Time
+	Subject string
+	// Body is the decoded text/plain body with CRLF line endings normalized
+	Body string
+}
+
+// Parent returns the Message-ID of the message this one replies to, falling back to the
+// last reference for clients that only set References
+func (m *Message) Parent() string {
+	if m.InReplyTo != "" {
+		return m.InReplyTo
+	}
+	if len(m.References) > 0 {
+		return m.References[len(m.References)-1]
+	}
+	return ""
+}
+
+// messageIDPattern matches a <message-id> in In-Reply-To and References headers
+var messageIDPattern = regexp.MustCompile(`<([^<>\s]+)>`)
+
+// Read reads all messages of an mbox file. Both mboxo and mboxrd are accepted: body lines
+// escaped as ">From " are unescaped. Messages without a Message-ID are skipped since they
+// cannot be threaded.
+func Read(r io.Reader) ([]*Message, error) {
+	var messages []*Message
+	var raw bytes.Buffer
+	inMessage := false
+	previousBlank := true
+
+	flush := func() error {
+		if !inMessage {
+			return nil
+		}
+		message, err := parseMessage(raw.Bytes())
+		if err != nil {
+			return err
+		}
+		if message.ID != "" {
+			messages = append(messages, message)
+		}
+		raw.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		// A "From " line after a blank line (or at the start) separates messages
+		if strings.HasPrefix(line, "From ") && previousBlank {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			inMessage = true
+			previousBlank = false
+			continue
+		}
+		previousBlank = line == ""
+		if !inMessage {
+			continue
+		}
+
+		if unescaped := strings.TrimLeft(line, ">"); len(unescaped) < len(line) && strings.HasPrefix(unescaped, "From ") {
+			line = line[1:]
+		}
+		raw.WriteString(line)
+		raw.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mbox: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// parseMessage parses the headers and text body of a single message
+func parseMessage(raw []byte) (*Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	message := &Message{
+		ID:         firstMessageID(msg.Header.Get("Message-Id")),
+		InReplyTo:  firstMessageID(msg.Header.Get("In-Reply-To")),
+		References: messageIDs(msg.Header.Get("References")),
+		Subject:    decodeHeader(msg.Header.Get("Subject")),
+	}
+	if from, err := mail.ParseAddress(decodeHeader(msg.Header.Get("From"))); err == nil {
+		message.From = strings.ToLower(from.Address)
+		message.Name = from.Name
+	} else {
+		message.From = strings.TrimSpace(msg.Header.Get("From"))
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		message.Date = date
+	}
+
+	body, err := textBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body of %s: %w", message.ID, err)
+	}
+	message.Body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	return message, nil
+}
+
+// textBody returns the decoded text/plain content of a body, descending into multipart
+// bodies. Bodies without a text/plain part are returned empty.
+func textBody(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := textBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			if text != "" {
+				return text, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decodeHeader decodes RFC 2047 encoded words, returning the header as is if it is malformed
+func decodeHeader(header string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(header)
+	if err != nil {
+		return header
+	}
+	return decoded
+}
+
+// firstMessageID returns the first Message-ID of a header, or the trimmed header if it has
+// no angle brackets
+func firstMessageID(header string) string {
+	if ids := messageIDs(header); len(ids) > 0 {
+		return ids[0]
+	}
+	return strings.TrimSpace(header)
+}
+
+// messageIDs returns all Message-IDs of a header
+func messageIDs(header string) []string {
+	var ids []string
+	for _, match := range messageIDPattern.FindAllStringSubmatch(header, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
@@ -0,0 +1,72 @@
+package mbox
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	raw := `From alice@example.com Mon Jan  1 00:00:00 2024
+Message-Id: <1@example.com>
+From: Alice Example <Alice@Example.com>
+Date: Mon, 1 Jan 2024 10:00:00 +0000
+Subject: =?UTF-8?q?=5BPATCH=5D_r=C3=A9try?=
+
+First line
+>From the mboxrd escape
+
+From bob@example.com Mon Jan  1 00:00:00 2024
+Message-Id: <2@example.com>
+In-Reply-To: <1@example.com>
+References: <0@example.com>
+ <1@example.com>
+From: bob@example.com
+Date: Mon, 1 Jan 2024 12:00:00 +0100
+Subject: Re: [PATCH] retry
+Content-Type: multipart/alternative; boundary="b"
+
+--b
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Looks=20good, but=
+ long
+--b
+Content-Type: text/html
+
+<p>Looks good</p>
+--b--
+
+From nobody Mon Jan  1 00:00:00 2024
+From: carol@example.com
+Subject: no message id
+
+dropped
+`
+	messages, err := Read(strings.NewReader(raw))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	first := messages[0]
+	assert.Equal(t, "1@example.com", first.ID)
+	assert.Equal(t, "alice@example.com", first.From)
+	assert.Equal(t, "Alice Example", first.Name)
+	assert.Equal(t, "[PATCH] rétry", first.Subject)
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), first.Date.UTC())
+	assert.Equal(t, "First line\nFrom the mboxrd escape\n\n", first.Body)
+	assert.Empty(t, first.Parent())
+
+	second := messages[1]
+	assert.Equal(t, "1@example.com", second.InReplyTo)
+	assert.Equal(t, []string{"0@example.com", "1@example.com"}, second.References)
+	assert.Equal(t, "Looks good, but long", second.Body)
+	assert.Equal(t, "1@example.com", second.Parent())
+}
+
+func TestMessage_Parent(t *testing.T) {
+	message := &Message{References: []string{"root@example.com", "parent@example.com"}}
+	assert.Equal(t, "parent@example.com", message.Parent())
+}
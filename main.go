@@ -17,6 +17,13 @@ func main() {
 
 	// Add commands
 	rootCmd.AddCommand(cmd.NewExtractCommand())
+	rootCmd.AddCommand(cmd.NewStatsCommand())
+	rootCmd.AddCommand(cmd.NewMergeCommand())
+	rootCmd.AddCommand(cmd.NewDiffCommand())
+	rootCmd.AddCommand(cmd.NewImportMboxCommand())
+	rootCmd.AddCommand(cmd.NewValidateCommand())
+	rootCmd.AddCommand(cmd.NewDoctorCommand())
+	rootCmd.AddCommand(cmd.NewProvidersCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -0,0 +1,56 @@
+package extractor
+
+import (
+	"github.com/jesper/review-extractor/internal/config"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// Problem is a problem found in a configuration file, with its line number
+type Problem = models.Problem
+
+// ValidationError is returned for configuration files with errors; it lists every problem
+type ValidationError = models.ValidationError
+
+// Severity classifies a problem as an error or a warning
+type Severity = models.Severity
+
+// Severities of problems
+const (
+	SeverityError   = models.SeverityError
+	SeverityWarning = models.SeverityWarning
+)
+
+// ConfigOptions configures how configuration files are loaded
+type ConfigOptions struct {
+	// StrictSecrets rejects tokens written literally into the configuration; they must come
+	// from the environment, a file or a command
+	StrictSecrets bool
+	// Providers are accepted in addition to the registered providers, as for Options.Providers
+	Providers []ProviderFactory
+}
+
+// LoadConfig reads, decodes strictly and validates a configuration file. Tokens are read from
+// the environment, files and commands, and repositories without a provider get the provider
+// their URL identifies. All problems found are returned; if any of them is an error, a
+// *ValidationError is returned as well.
+func LoadConfig(path string, opts ConfigOptions) (*models.Config, []Problem, error) {
+	options := config.Options{
+		URLValidators: make(map[models.Provider]config.URLValidator),
+		AuthMethods:   make(map[models.Provider][]models.AuthMethod),
+		StrictSecrets: opts.StrictSecrets,
+	}
+	registry, err := newRegistry(opts.Providers, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	options.InferProvider = registry.InferProvider
+	for _, factory := range registry.Providers() {
+		options.URLValidators[factory.Name] = factory.ValidateURL
+		// Providers that do not list their credential methods accept any
+		if len(factory.AuthMethods) > 0 {
+			options.AuthMethods[factory.Name] = factory.AuthMethods
+		}
+	}
+
+	return config.Load(path, options)
+}
@@ -0,0 +1,40 @@
+// Package extractor is the library API of review-extractor. It extracts code review comments
+// from the configured repositories of GitHub, Gitea, Forgejo, Azure DevOps, Gerrit, Bitbucket
+// Cloud and plugin providers, and is what the review-extractor command runs on.
+//
+// A Client is created from an Options struct holding the configuration, either loaded with
+// LoadConfig or built in code:
+//
+//	config, _, err := extractor.LoadConfig("config/customer-a.yaml", extractor.ConfigOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	client, err := extractor.New(extractor.Options{Config: config})
+//	if err != nil {
+//		return err
+//	}
+//	for review, err := range client.Reviews(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(review.CommentAuthor, review.CommentText)
+//	}
+//
+// Reviews and Run stream reviews as they are fetched, pull request by pull request for the
+// adapters that support it; Extract collects them into a models.ExtractionResult with
+// statistics, the document the command writes as JSON.
+//
+// Further providers are added with Register, from the init function of the package
+// implementing them, or for a single client with Options.Providers. The interfaces they
+// implement are defined in pkg/provider and re-exported here.
+//
+// # Compatibility
+//
+// This package, pkg/provider and the models it uses from pkg/models follow the semantic version of the
+// module: within a major version, exported identifiers are neither removed nor changed
+// incompatibly, and fields are only added. The JSON layout of results is versioned separately
+// by models.SchemaVersion, which is raised whenever the layout changes; readers should accept
+// documents of older schema versions. Packages under internal/ carry no guarantees.
+//
+// A Client is not safe for concurrent use; create one per extraction running in parallel.
+package extractor
@@ -0,0 +1,112 @@
+package extractor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jesper/review-extractor/pkg/extractor"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// notes stands in for a review system of your own
+type notes struct{}
+
+func (notes) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	pr := &models.PullRequest{Number: 1, Provider: "notes", Title: "Add retries"}
+	return []models.Review{
+		{PRID: 1, CommentID: "1", CommentAuthor: "bob", CommentText: "Retry forever?", Provider: "notes", PullRequest: pr},
+		{PRID: 1, CommentID: "2", CommentAuthor: "carol", CommentText: "LGTM", Provider: "notes", PullRequest: pr},
+	}, nil
+}
+
+// notesProvider recognizes notes:// URLs, so repositories can leave out their provider
+var notesProvider = extractor.ProviderFactory{
+	Name:        "notes",
+	Description: "In-house review notes",
+	Example:     "notes://<team>/<repo>",
+	MatchURL: func(url string) bool {
+		return strings.HasPrefix(url, "notes://")
+	},
+	ValidateURL: func(url string) error {
+		if !strings.HasPrefix(url, "notes://") {
+			return errors.New("not a notes URL")
+		}
+		return nil
+	},
+	New: func(config *models.Config, source extractor.CredentialSource) extractor.ProviderExtractor {
+		return notes{}
+	},
+}
+
+func Example() {
+	client, err := extractor.New(extractor.Options{
+		Config: &models.Config{
+			Repositories: []models.RepositoryConfig{{URL: "notes://platform/api"}},
+		},
+		Providers: []extractor.ProviderFactory{notesProvider},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for review, err := range client.Reviews(context.Background()) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %s\n", review.CommentAuthor, review.CommentText)
+	}
+	// Output:
+	// bob: Retry forever?
+	// carol: LGTM
+}
+
+func ExampleClient_Extract() {
+	client, err := extractor.New(extractor.Options{
+		Config: &models.Config{
+			Repositories: []models.RepositoryConfig{{Provider: "notes", URL: "notes://platform/api"}},
+		},
+		Providers: []extractor.ProviderFactory{notesProvider},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.Extract(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("schema version:", result.SchemaVersion)
+	fmt.Println("comments:", result.TotalComments)
+	fmt.Println("pull requests:", result.Statistics.TotalPRs)
+	fmt.Println("comments per pull request:", result.Statistics.ReviewFrequency)
+	// Output:
+	// schema version: 2
+	// comments: 2
+	// pull requests: 1
+	// comments per pull request: 2
+}
+
+func ExampleClient_Run() {
+	client, err := extractor.New(extractor.Options{
+		Config: &models.Config{
+			Repositories: []models.RepositoryConfig{{URL: "notes://platform/api"}},
+		},
+		Providers: []extractor.ProviderFactory{notesProvider},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Sinks receive each review as soon as it is extracted
+	var collector extractor.Collector
+	repositories, err := client.Run(context.Background(), &collector)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d reviews from %d repository\n", len(collector.Reviews), repositories)
+	// Output:
+	// 2 reviews from 1 repository
+}
@@ -0,0 +1,167 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/internal/credentials"
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// Sink receives the reviews of a run as they are extracted
+type Sink = models.Sink
+
+// Collector is a sink keeping the reviews in memory
+type Collector = models.Collector
+
+// ReviewFilter selects reviews from a result; see Filter
+type ReviewFilter = models.ReviewFilter
+
+// Options configures a Client
+type Options struct {
+	// Config lists the repositories to extract and how; it is required. Repositories
+	// without a provider get the provider their URL identifies.
+	Config *models.Config
+	// Credentials returns the credential of each repository. By default they are resolved
+	// from the credentials of Config.
+	Credentials CredentialSource
+	// Providers are added to the registered providers for this client only
+	Providers []ProviderFactory
+}
+
+// Client extracts the reviews of the repositories of a configuration
+type Client struct {
+	config    *models.Config
+	registry  *core.Registry
+	extractor *core.ReviewExtractor
+}
+
+// New creates a client for a configuration. It fails if a provider is given twice or a
+// repository's provider is neither set nor identified by its URL.
+func New(opts Options) (*Client, error) {
+	if opts.Config == nil {
+		return nil, errors.New("config is required")
+	}
+
+	registry, err := newRegistry(opts.Providers, opts.Config.Plugins)
+	if err != nil {
+		return nil, err
+	}
+
+	// Infer missing providers on a copy, leaving the caller's configuration untouched
+	config := *opts.Config
+	config.Repositories = slices.Clone(config.Repositories)
+	for i, repo := range config.Repositories {
+		if repo.Provider != "" {
+			continue
+		}
+		provider, err := registry.InferProvider(repo.URL)
+		if err != nil {
+			return nil, fmt.Errorf("repository %s: %w", repo.URL, err)
+		}
+		if provider == "" {
+			return nil, fmt.Errorf("repository %s: provider is required; it cannot be inferred from the URL", repo.URL)
+		}
+		config.Repositories[i].Provider = provider
+	}
+
+	source := opts.Credentials
+	if source == nil {
+		source = credentials.NewResolver(&config)
+	}
+
+	extractor := core.NewReviewExtractor(&config, registry.Extractors(&config, source))
+	extractor.SetCredentials(source)
+	return &Client{
+		config:    &config,
+		registry:  registry,
+		extractor: extractor,
+	}, nil
+}
+
+// Providers returns the providers available to the client, including those of plugins,
+// ordered by name
+func (c *Client) Providers() []ProviderFactory {
+	return c.registry.Providers()
+}
+
+// Repositories returns the configured repositories followed by those found by discovery
+func (c *Client) Repositories(ctx context.Context) ([]models.RepositoryConfig, error) {
+	return c.extractor.Repositories(ctx)
+}
+
+// Extract extracts the reviews of all repositories into a result with statistics
+func (c *Client) Extract(ctx context.Context) (*models.ExtractionResult, error) {
+	return c.extractor.ExtractReviews(ctx)
+}
+
+// Run extracts the reviews of all repositories into the sinks as they are fetched and returns
+// the number of repositories processed. If it fails, the sinks have received the reviews
+// extracted until then.
+func (c *Client) Run(ctx context.Context, sinks ...Sink) (int, error) {
+	return c.extractor.Run(ctx, sinks...)
+}
+
+// Reviews returns the reviews of all repositories as they are fetched. An error ends the
+// sequence; stopping early stops the extraction.
+func (c *Client) Reviews(ctx context.Context) iter.Seq2[models.Review, error] {
+	return func(yield func(models.Review, error) bool) {
+		_, err := c.Run(ctx, yieldSink(yield))
+		if err != nil && !errors.Is(err, errStopped) {
+			yield(models.Review{}, err)
+		}
+	}
+}
+
+// errStopped ends a run whose reviews are no longer wanted
+var errStopped = errors.New("stopped reading reviews")
+
+// yieldSink passes reviews on to the consumer of a sequence
+type yieldSink func(models.Review, error) bool
+
+// WriteReview implements the Sink interface
+func (y yieldSink) WriteReview(review models.Review) error {
+	if !y(review, nil) {
+		return errStopped
+	}
+	return nil
+}
+
+// Plan estimates the pull requests, API requests and duration of an extraction without
+// running it
+func (c *Client) Plan(ctx context.Context) (*models.Plan, error) {
+	return c.extractor.Plan(ctx)
+}
+
+// Check verifies access to every repository: authentication, visibility, listing pull
+// requests and fetching a diff
+func (c *Client) Check(ctx context.Context) ([]models.RepositoryCheck, error) {
+	return c.extractor.Check(ctx)
+}
+
+// Budgets sums the requests of checked repositories per rate limit budget: per provider,
+// host and credential, as in plans
+func (c *Client) Budgets(results []models.RepositoryCheck) []models.ProviderPlan {
+	return c.extractor.CheckBudgets(results)
+}
+
+// NewResult builds an extraction result from reviews of the given number of repositories,
+// gathering their pull requests and generating statistics
+func NewResult(reviews []models.Review, repositories int) *models.ExtractionResult {
+	return core.NewResult(reviews, repositories)
+}
+
+// Statistics summarizes reviews and the pull requests they belong to
+func Statistics(reviews []models.Review, pullRequests []models.PullRequest) models.Statistics {
+	return core.GenerateStatistics(reviews, pullRequests)
+}
+
+// Filter returns a copy of a result with only the matching reviews and the pull requests
+// they reference, with statistics recomputed
+func Filter(result *models.ExtractionResult, filter ReviewFilter) *models.ExtractionResult {
+	return core.FilterResult(result, filter)
+}
@@ -0,0 +1,136 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingExtractor returns its reviews, or an error for URLs ending in /broken
+type countingExtractor struct {
+	reviews []models.Review
+}
+
+func (c countingExtractor) ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error) {
+	if filepath.Base(repoURL) == "broken" {
+		return nil, errors.New("API error")
+	}
+	return c.reviews, nil
+}
+
+func testProvider(reviews ...models.Review) ProviderFactory {
+	return ProviderFactory{
+		Name:        "test",
+		ValidateURL: func(string) error { return nil },
+		New: func(*models.Config, CredentialSource) ProviderExtractor {
+			return countingExtractor{reviews: reviews}
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Options{})
+	assert.EqualError(t, err, "config is required")
+
+	config := &models.Config{Repositories: []models.RepositoryConfig{{URL: "https://github.com/acme/api"}}}
+	client, err := New(Options{Config: config})
+	assert.NoError(t, err)
+	repositories, err := client.Repositories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, models.ProviderGitHub, repositories[0].Provider, "the provider is inferred from the URL")
+	assert.Empty(t, config.Repositories[0].Provider, "the caller's configuration is left untouched")
+
+	_, err = New(Options{Config: &models.Config{Repositories: []models.RepositoryConfig{{URL: "https://git.example.com/acme/api"}}}})
+	assert.EqualError(t, err, "repository https://git.example.com/acme/api: provider is required; it cannot be inferred from the URL")
+
+	github := testProvider()
+	github.Name = models.ProviderGitHub
+	_, err = New(Options{Config: config, Providers: []ProviderFactory{github}})
+	assert.EqualError(t, err, "provider github is already registered")
+
+	_, err = New(Options{Config: config, Providers: []ProviderFactory{{Name: "incomplete"}}})
+	assert.EqualError(t, err, `provider "incomplete" needs a name, constructor and URL validator`)
+}
+
+func TestClient_Providers(t *testing.T) {
+	client, err := New(Options{
+		Config:    &models.Config{Plugins: []models.PluginConfig{{Provider: "critic", Command: "review-extractor-critic"}}},
+		Providers: []ProviderFactory{testProvider()},
+	})
+	assert.NoError(t, err)
+
+	var names []models.Provider
+	for _, factory := range client.Providers() {
+		names = append(names, factory.Name)
+	}
+	assert.Contains(t, names, models.Provider("critic"))
+	assert.Contains(t, names, models.Provider("test"))
+	assert.Contains(t, names, models.ProviderGitHub)
+	assert.Len(t, names, len(Providers())+2, "client providers are not registered globally")
+}
+
+func TestClient_Reviews(t *testing.T) {
+	client, err := New(Options{
+		Config: &models.Config{Repositories: []models.RepositoryConfig{
+			{Provider: "test", URL: "https://review.example.com/api"},
+			{Provider: "test", URL: "https://review.example.com/broken"},
+		}},
+		Providers: []ProviderFactory{testProvider(models.Review{CommentID: "1"}, models.Review{CommentID: "2"})},
+	})
+	assert.NoError(t, err)
+
+	var ids []string
+	for review, err := range client.Reviews(context.Background()) {
+		assert.NoError(t, err)
+		ids = append(ids, review.CommentID)
+		break
+	}
+	assert.Equal(t, []string{"1"}, ids, "stopping early is not an error")
+
+	ids = nil
+	var errs []error
+	for review, err := range client.Reviews(context.Background()) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, review.CommentID)
+	}
+	assert.Equal(t, []string{"1", "2"}, ids)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "failed to extract reviews from https://review.example.com/broken: API error")
+}
+
+func TestLoadConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	data := `credentials:
+  - provider: test
+    method: basic
+    username: alice
+    password: secret
+repositories:
+  - url: https://github.com/acme/api
+    credential:
+      token: secret
+  - provider: test
+    url: https://review.example.com/api
+`
+	assert.NoError(t, os.WriteFile(configPath, []byte(data), 0644))
+
+	_, _, err := LoadConfig(configPath, ConfigOptions{})
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []Problem{
+		{Line: 10, Severity: SeverityError, Message: `repositories[1]: unsupported provider "test" (supported: azure_devops, bitbucket_cloud, forgejo, gerrit, gitea, github)`},
+	}, validationErr.Problems)
+
+	config, problems, err := LoadConfig(configPath, ConfigOptions{Providers: []ProviderFactory{testProvider()}})
+	assert.NoError(t, err)
+	assert.Empty(t, problems, "providers without credential methods accept any")
+	assert.Equal(t, models.ProviderGitHub, config.Repositories[0].Provider)
+}
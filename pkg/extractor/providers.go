@@ -0,0 +1,94 @@
+package extractor
+
+import (
+	"fmt"
+
+	// Adapters add their provider to the registry when imported
+	_ "github.com/jesper/review-extractor/internal/adapters/azuredevops"
+	_ "github.com/jesper/review-extractor/internal/adapters/bitbucketcloud"
+	_ "github.com/jesper/review-extractor/internal/adapters/gerrit"
+	_ "github.com/jesper/review-extractor/internal/adapters/gitea"
+	_ "github.com/jesper/review-extractor/internal/adapters/github"
+	"github.com/jesper/review-extractor/internal/adapters/plugin"
+	"github.com/jesper/review-extractor/internal/core"
+	"github.com/jesper/review-extractor/pkg/models"
+	"github.com/jesper/review-extractor/pkg/provider"
+)
+
+// ProviderExtractor extracts the reviews of a repository from one provider
+type ProviderExtractor = provider.Extractor
+
+// StreamExtractor is implemented by provider extractors that yield reviews as they are
+// fetched instead of returning a whole repository at once
+type StreamExtractor = provider.StreamExtractor
+
+// Checker, Planner and Discoverer are optional interfaces of provider extractors, used by
+// Client.Check, Client.Plan and discovery entries of the configuration
+type (
+	Checker    = provider.Checker
+	Planner    = provider.Planner
+	Discoverer = provider.Discoverer
+)
+
+// ProviderFactory describes a provider: how to recognize and validate its repository URLs,
+// the credential methods it accepts and how to create its extractor
+type ProviderFactory = provider.Factory
+
+// CredentialSource returns the credential to use for a repository
+type CredentialSource = provider.CredentialSource
+
+// Register adds a provider for every client, usually from the init function of the package
+// implementing it. It panics if the provider is incomplete or already registered.
+func Register(factory ProviderFactory) {
+	core.Register(factory)
+}
+
+// Providers returns the registered providers ordered by name
+func Providers() []ProviderFactory {
+	return core.DefaultRegistry.Providers()
+}
+
+// newRegistry returns a registry with the registered providers, the given ones and the
+// providers of the configured plugins
+func newRegistry(providers []ProviderFactory, plugins []models.PluginConfig) (*core.Registry, error) {
+	registry := core.NewRegistry()
+	for _, factory := range core.DefaultRegistry.Providers() {
+		registry.Register(factory)
+	}
+	for _, factory := range providers {
+		if err := add(registry, factory); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range plugins {
+		if err := add(registry, pluginFactory(p)); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// add registers a provider, reporting problems as errors rather than panicking
+func add(registry *core.Registry, factory ProviderFactory) error {
+	if factory.Name == "" || factory.New == nil || factory.ValidateURL == nil {
+		return fmt.Errorf("provider %q needs a name, constructor and URL validator", factory.Name)
+	}
+	if _, ok := registry.Lookup(factory.Name); ok {
+		return fmt.Errorf("provider %s is already registered", factory.Name)
+	}
+	registry.Register(factory)
+	return nil
+}
+
+// pluginFactory describes the provider of a plugin. Plugins accept any URL and credential
+// method and check them themselves.
+func pluginFactory(config models.PluginConfig) ProviderFactory {
+	return ProviderFactory{
+		Name:        config.Provider,
+		Description: "plugin " + config.Command,
+		ValidateURL: func(string) error { return nil },
+		New: func(_ *models.Config, source CredentialSource) ProviderExtractor {
+			return plugin.NewExtractor(config, source)
+		},
+	}
+}
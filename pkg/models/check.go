@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// CheckStatus is the outcome of a preflight check
+type CheckStatus string
+
+const (
+	CheckOK      CheckStatus = "ok"
+	CheckWarning CheckStatus = "warning"
+	CheckFailed  CheckStatus = "failed"
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// Check is the outcome of a single preflight check against a repository
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+// RateLimit is the API request budget available to a token
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// RepositoryCheck collects the preflight checks run against one configured repository
+type RepositoryCheck struct {
+	URL       string     `json:"url"`
+	Provider  Provider   `json:"provider"`
+	Checks    []Check    `json:"checks"`
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// EstimatedCalls is the approximate number of API requests a full extraction needs
+	EstimatedCalls int `json:"estimated_calls,omitempty"`
+	// EstimatedQueryCost is the approximate cost of the GraphQL queries of a full extraction,
+	// in points of QueryRateLimit, a budget separate from RateLimit
+	EstimatedQueryCost int        `json:"estimated_query_cost,omitempty"`
+	QueryRateLimit     *RateLimit `json:"query_rate_limit,omitempty"`
+}
+
+// Failed reports whether any of the checks failed
+func (c RepositoryCheck) Failed() bool {
+	for _, check := range c.Checks {
+		if check.Status == CheckFailed {
+			return true
+		}
+	}
+	return false
+}
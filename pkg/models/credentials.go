@@ -0,0 +1,51 @@
+package models
+
+// AuthMethod is the way a credential authenticates against a Git platform
+type AuthMethod string
+
+const (
+	// AuthBearer sends a personal access or OAuth token
+	AuthBearer AuthMethod = "bearer"
+	// AuthBasic sends a username and password
+	AuthBasic AuthMethod = "basic"
+	// AuthAppPassword sends a username and an app password, as Bitbucket Cloud expects
+	AuthAppPassword AuthMethod = "app_password"
+	// AuthGitHubApp authenticates as a GitHub App installation
+	AuthGitHubApp AuthMethod = "github_app"
+)
+
+// Credential describes how to authenticate. In the credentials section it applies to
+// every repository on Host or, without a host, of Provider; on a repository it overrides
+// them. Tokens, passwords and private keys can also be read from a file or a command's
+// output. GitHub Apps are identified by AppID and authenticate with PrivateKey; without
+// an InstallationID, the installation is looked up for each repository owner.
+type Credential struct {
+	Host           string     `yaml:"host,omitempty"`
+	Provider       Provider   `yaml:"provider,omitempty"`
+	Method         AuthMethod `yaml:"method,omitempty"`
+	Token          string     `yaml:"token,omitempty"`
+	TokenFile      string     `yaml:"token_file,omitempty"`
+	TokenCmd       string     `yaml:"token_cmd,omitempty"`
+	Username       string     `yaml:"username,omitempty"`
+	Password       string     `yaml:"password,omitempty"`
+	PasswordFile   string     `yaml:"password_file,omitempty"`
+	PasswordCmd    string     `yaml:"password_cmd,omitempty"`
+	AppID          int64      `yaml:"app_id,omitempty"`
+	InstallationID int64      `yaml:"installation_id,omitempty"`
+	PrivateKey     string     `yaml:"private_key,omitempty"`
+	PrivateKeyFile string     `yaml:"private_key_file,omitempty"`
+	PrivateKeyCmd  string     `yaml:"private_key_cmd,omitempty"`
+}
+
+// AuthMethod returns the method of the credential, defaulting to a bearer token
+func (c Credential) AuthMethod() AuthMethod {
+	if c.Method == "" {
+		return AuthBearer
+	}
+	return c.Method
+}
+
+// IsZero reports whether the credential carries no secret
+func (c Credential) IsZero() bool {
+	return c.Token == "" && c.Password == "" && c.PrivateKey == ""
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ReviewFilter selects reviews from an extraction result. Zero-valued fields match everything.
+type ReviewFilter struct {
+	// Repository matches the repository key, full name or bare name
+	Repository string
+	// Author matches the comment author
+	Author string
+	// Since and Until bound the comment creation time; Until is exclusive
+	Since time.Time
+	Until time.Time
+	// SuggestionsOnly keeps only comments containing suggested changes
+	SuggestionsOnly bool
+}
+
+// Match reports whether the review passes the filter
+func (f ReviewFilter) Match(review Review) bool {
+	if f.Repository != "" && !matchRepository(review, f.Repository) {
+		return false
+	}
+	if f.Author != "" && review.CommentAuthor != f.Author {
+		return false
+	}
+	if !f.Since.IsZero() && review.CommentCreated.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !review.CommentCreated.Before(f.Until) {
+		return false
+	}
+	if f.SuggestionsOnly && !review.HasSuggestions() {
+		return false
+	}
+	return true
+}
+
+// matchRepository reports whether the review belongs to the named repository
+func matchRepository(review Review, name string) bool {
+	if review.Repository == name {
+		return true
+	}
+	if review.Repo.IsZero() {
+		return false
+	}
+	return review.Repo.Key() == name || review.Repo.FullName() == name || review.Repo.Name == name
+}
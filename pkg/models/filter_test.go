@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewFilter_Match(t *testing.T) {
+	june := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	review := Review{
+		Repository:     "api",
+		Repo:           RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"},
+		CommentAuthor:  "jane",
+		CommentCreated: june,
+	}
+
+	tests := []struct {
+		name   string
+		filter ReviewFilter
+		want   bool
+	}{
+		{name: "empty filter", filter: ReviewFilter{}, want: true},
+		{name: "repository key", filter: ReviewFilter{Repository: "github.com/customer-a/api"}, want: true},
+		{name: "repository full name", filter: ReviewFilter{Repository: "customer-a/api"}, want: true},
+		{name: "repository name", filter: ReviewFilter{Repository: "api"}, want: true},
+		{name: "other owner", filter: ReviewFilter{Repository: "customer-b/api"}, want: false},
+		{name: "author", filter: ReviewFilter{Author: "jane"}, want: true},
+		{name: "other author", filter: ReviewFilter{Author: "john"}, want: false},
+		{name: "since before", filter: ReviewFilter{Since: june.Add(-time.Hour)}, want: true},
+		{name: "since after", filter: ReviewFilter{Since: june.Add(time.Hour)}, want: false},
+		{name: "until after", filter: ReviewFilter{Until: june.Add(time.Hour)}, want: true},
+		{name: "until is exclusive", filter: ReviewFilter{Until: june}, want: false},
+		{name: "suggestions only", filter: ReviewFilter{SuggestionsOnly: true}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(review))
+		})
+	}
+}
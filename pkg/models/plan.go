@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// RepositoryPlan is the expected cost of extracting one repository, found with cheap list calls
+type RepositoryPlan struct {
+	URL          string   `json:"url"`
+	Provider     Provider `json:"provider"`
+	PullRequests int      `json:"pull_requests"`
+	// EstimatedCalls is the approximate number of API requests a full extraction needs
+	EstimatedCalls int        `json:"estimated_calls"`
+	RateLimit      *RateLimit `json:"rate_limit,omitempty"`
+	// EstimatedQueryCost is the approximate cost of the GraphQL queries of a full extraction,
+	// in points of QueryRateLimit, a budget separate from RateLimit
+	EstimatedQueryCost int        `json:"estimated_query_cost,omitempty"`
+	QueryRateLimit     *RateLimit `json:"query_rate_limit,omitempty"`
+	// Error explains why the repository could not be planned
+	Error string `json:"error,omitempty"`
+}
+
+// ProviderPlan sums the expected cost of the repositories whose requests share a rate limit
+// budget: those of one provider and host extracted with the same credential
+type ProviderPlan struct {
+	Provider Provider `json:"provider"`
+	Host     string   `json:"host,omitempty"`
+	// Repositories lists the URLs of the repositories sharing the budget
+	Repositories       []string      `json:"repositories"`
+	EstimatedCalls     int           `json:"estimated_calls"`
+	RateLimit          *RateLimit    `json:"rate_limit,omitempty"`
+	EstimatedQueryCost int           `json:"estimated_query_cost,omitempty"`
+	QueryRateLimit     *RateLimit    `json:"query_rate_limit,omitempty"`
+	EstimatedDuration  time.Duration `json:"estimated_duration"`
+}
+
+// Plan is what an extraction run is expected to do, without fetching comments or diffs
+type Plan struct {
+	Repositories      []RepositoryPlan `json:"repositories"`
+	Providers         []ProviderPlan   `json:"providers"`
+	PullRequests      int              `json:"pull_requests"`
+	EstimatedCalls    int              `json:"estimated_calls"`
+	EstimatedDuration time.Duration    `json:"estimated_duration"`
+}
+
+// Failed returns the number of repositories that could not be planned
+func (p Plan) Failed() int {
+	failed := 0
+	for _, repo := range p.Repositories {
+		if repo.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
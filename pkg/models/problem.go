@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity classifies a configuration problem
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a single issue found in a configuration file
+type Problem struct {
+	Line     int
+	Severity Severity
+	Message  string
+}
+
+// String formats the problem as "line N: severity: message"
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+}
+
+// ValidationError reports all errors found in a configuration file
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+// Error lists every problem on its own line
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("invalid config file %s:", e.Path))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SchemaVersion is the version of the ExtractionResult JSON layout.
+// Version 2 added the structured "repo" identity to reviews and pull requests;
+// files without a schema_version were written by version 1.
+const SchemaVersion = 2
+
+// RepositoryRef identifies a repository independently of the platform it is hosted on
+type RepositoryRef struct {
+	Host  string `json:"host"`
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// FullName returns the owner-qualified repository name, e.g. "customer-a/api"
+func (r RepositoryRef) FullName() string {
+	if r.Owner == "" {
+		return r.Name
+	}
+	return r.Owner + "/" + r.Name
+}
+
+// Key returns an identifier that is unique across hosts and owners, e.g. "github.com/customer-a/api"
+func (r RepositoryRef) Key() string {
+	if r.Host == "" {
+		return r.FullName()
+	}
+	return r.Host + "/" + r.FullName()
+}
+
+// IsZero reports whether the reference is empty
+func (r RepositoryRef) IsZero() bool {
+	return r == RepositoryRef{}
+}
+
+// ParseRepositoryURL builds a reference from a repository URL of any host, for repositories
+// no adapter knows the layout of: the last path segment is the name and the segments before
+// it are the owner
+func ParseRepositoryURL(repoURL string) (RepositoryRef, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(repoURL), "/"))
+	if err != nil || u.Host == "" {
+		return RepositoryRef{}, fmt.Errorf("expected a repository URL, got %q", repoURL)
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return RepositoryRef{}, fmt.Errorf("expected a repository URL, got %q", repoURL)
+	}
+	ref := legacyRepositoryRef(path)
+	ref.Host = strings.ToLower(u.Host)
+	ref.URL = u.Scheme + "://" + u.Host + "/" + path
+	return ref, nil
+}
+
+// RepositoryKey returns the key used to aggregate the review by repository
+func (r Review) RepositoryKey() string {
+	if r.Repo.IsZero() {
+		return r.Repository
+	}
+	return r.Repo.Key()
+}
+
+// UnmarshalJSON reads reviews written before the structured repository identity
+// existed by deriving it from the bare repository name
+func (r *Review) UnmarshalJSON(data []byte) error {
+	type review Review
+	if err := json.Unmarshal(data, (*review)(r)); err != nil {
+		return err
+	}
+
+	if r.Repo.IsZero() && r.Repository != "" {
+		r.Repo = legacyRepositoryRef(r.Repository)
+	}
+	return nil
+}
+
+// UnmarshalJSON reads pull requests whose repository was written as a bare name
+func (p *PullRequest) UnmarshalJSON(data []byte) error {
+	type pullRequest PullRequest
+	aux := struct {
+		*pullRequest
+		Repository json.RawMessage `json:"repository"`
+	}{pullRequest: (*pullRequest)(p)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Repository) == 0 {
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(aux.Repository, &name); err == nil {
+		p.Repository = legacyRepositoryRef(name)
+		return nil
+	}
+	return json.Unmarshal(aux.Repository, &p.Repository)
+}
+
+// legacyRepositoryRef builds a reference from a bare "name" or "owner/name"
+func legacyRepositoryRef(name string) RepositoryRef {
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		return RepositoryRef{Owner: name[:idx], Name: name[idx+1:]}
+	}
+	return RepositoryRef{Name: name}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryRef(t *testing.T) {
+	ref := RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}
+	assert.Equal(t, "customer-a/api", ref.FullName())
+	assert.Equal(t, "github.com/customer-a/api", ref.Key())
+	assert.False(t, ref.IsZero())
+
+	assert.Equal(t, "api", RepositoryRef{Name: "api"}.Key())
+	assert.True(t, RepositoryRef{}.IsZero())
+}
+
+func TestParseRepositoryURL(t *testing.T) {
+	ref, err := ParseRepositoryURL("https://git.example.com/libs/core/util.git/")
+	assert.NoError(t, err)
+	assert.Equal(t, RepositoryRef{Host: "git.example.com", Owner: "libs/core", Name: "util", URL: "https://git.example.com/libs/core/util"}, ref)
+
+	ref, err = ParseRepositoryURL("https://Review.example.com/tools")
+	assert.NoError(t, err)
+	assert.Equal(t, RepositoryRef{Host: "review.example.com", Name: "tools", URL: "https://Review.example.com/tools"}, ref)
+
+	_, err = ParseRepositoryURL("util")
+	assert.Error(t, err)
+	_, err = ParseRepositoryURL("https://git.example.com/")
+	assert.Error(t, err)
+}
+
+func TestReviewRepositoryKey(t *testing.T) {
+	assert.Equal(t, "api", Review{Repository: "api"}.RepositoryKey())
+	assert.Equal(t, "github.com/customer-a/api", Review{
+		Repository: "api",
+		Repo:       RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"},
+	}.RepositoryKey())
+}
+
+func TestReviewUnmarshalJSON(t *testing.T) {
+	t.Run("legacy output without repo", func(t *testing.T) {
+		var review Review
+		err := json.Unmarshal([]byte(`{"pr_id":1,"repository":"api","comment_id":"42"}`), &review)
+		assert.NoError(t, err)
+		assert.Equal(t, "api", review.Repository)
+		assert.Equal(t, RepositoryRef{Name: "api"}, review.Repo)
+		assert.Equal(t, "42", review.CommentID)
+	})
+
+	t.Run("current output", func(t *testing.T) {
+		original := Review{
+			PRID:       1,
+			Repository: "api",
+			Repo:       RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api", URL: "https://github.com/customer-a/api"},
+		}
+		data, err := json.Marshal(original)
+		assert.NoError(t, err)
+
+		var review Review
+		assert.NoError(t, json.Unmarshal(data, &review))
+		assert.Equal(t, original, review)
+	})
+}
+
+func TestPullRequestUnmarshalJSON(t *testing.T) {
+	var legacy PullRequest
+	err := json.Unmarshal([]byte(`{"number":3,"repository":"customer-a/api","title":"Fix"}`), &legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, legacy.Number)
+	assert.Equal(t, "Fix", legacy.Title)
+	assert.Equal(t, RepositoryRef{Owner: "customer-a", Name: "api"}, legacy.Repository)
+
+	var current PullRequest
+	err = json.Unmarshal([]byte(`{"number":3,"repository":{"host":"github.com","owner":"customer-a","name":"api"}}`), &current)
+	assert.NoError(t, err)
+	assert.Equal(t, RepositoryRef{Host: "github.com", Owner: "customer-a", Name: "api"}, current.Repository)
+}
@@ -6,60 +6,191 @@ import "time"
 type Provider string
 
 const (
-	ProviderGitHub Provider = "github"
-	ProviderGitLab Provider = "gitlab"
+	ProviderGitHub      Provider = "github"
+	ProviderGitLab      Provider = "gitlab"
+	ProviderGitea       Provider = "gitea"
+	ProviderForgejo     Provider = "forgejo"
+	ProviderAzureDevOps Provider = "azure_devops"
+	ProviderGerrit      Provider = "gerrit"
+	// ProviderBitbucketCloud is bitbucket.org; "bitbucket" refers to Bitbucket Server
+	ProviderBitbucketCloud Provider = "bitbucket_cloud"
+	// ProviderMailingList is patch review over email, imported from mbox files
+	ProviderMailingList Provider = "mailing_list"
+)
+
+// ReviewState represents the verdict of a submitted review
+type ReviewState string
+
+const (
+	ReviewStateApproved         ReviewState = "APPROVED"
+	ReviewStateChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewStateCommented        ReviewState = "COMMENTED"
+	ReviewStateDismissed        ReviewState = "DISMISSED"
+	ReviewStatePending          ReviewState = "PENDING"
+)
+
+// ThreadStatus represents the resolution of a comment thread on platforms that track it
+type ThreadStatus string
+
+const (
+	ThreadStatusActive   ThreadStatus = "active"
+	ThreadStatusPending  ThreadStatus = "pending"
+	ThreadStatusFixed    ThreadStatus = "fixed"
+	ThreadStatusWontFix  ThreadStatus = "wont_fix"
+	ThreadStatusByDesign ThreadStatus = "by_design"
+	ThreadStatusClosed   ThreadStatus = "closed"
+	ThreadStatusResolved ThreadStatus = "resolved"
 )
 
 // Review represents a code review comment
 type Review struct {
-	PRID           int       `json:"pr_id"`
-	PRTitle        string    `json:"pr_title"`
-	PRAuthor       string    `json:"pr_author"`
-	Repository     string    `json:"repository"`
-	Provider       Provider  `json:"provider"`
-	CommentID      string    `json:"comment_id"`
-	CommentAuthor  string    `json:"comment_author"`
-	CommentText    string    `json:"comment_text"`
-	CommentCreated time.Time `json:"comment_created"`
-	FilePath       string    `json:"file_path"`
-	LineNumber     int       `json:"line_number"`
-	DiffContext    string    `json:"diff_context"`
+	PRID     int    `json:"pr_id"`
+	PRTitle  string `json:"pr_title"`
+	PRAuthor string `json:"pr_author"`
+	// Repository is the bare repository name; it is ambiguous across owners
+	// and hosts and only kept for existing consumers, use Repo instead
+	Repository     string        `json:"repository"`
+	Repo           RepositoryRef `json:"repo"`
+	Provider       Provider      `json:"provider"`
+	CommentID      string        `json:"comment_id"`
+	InReplyTo      string        `json:"in_reply_to,omitempty"`
+	CommentAuthor  string        `json:"comment_author"`
+	CommentText    string        `json:"comment_text"`
+	CommentCreated time.Time     `json:"comment_created"`
+	ReviewID       string        `json:"review_id,omitempty"`
+	ReviewState    ReviewState   `json:"review_state,omitempty"`
+	ThreadStatus   ThreadStatus  `json:"thread_status,omitempty"`
+	FilePath       string        `json:"file_path"`
+	StartLine      int           `json:"start_line,omitempty"`
+	LineNumber     int           `json:"line_number"`
+	DiffContext    string        `json:"diff_context"`
+	Suggestions    []Suggestion  `json:"suggestions,omitempty"`
+	Addressed      *bool         `json:"addressed,omitempty"`
+	AddressedBy    string        `json:"addressed_by,omitempty"`
+	// PullRequest is shared by all reviews of the same pull request and is written
+	// once to ExtractionResult.PullRequests rather than with every review
+	PullRequest *PullRequest `json:"-"`
+}
+
+// HasSuggestions reports whether the comment contains at least one suggested change
+func (r Review) HasSuggestions() bool {
+	return len(r.Suggestions) > 0
 }
 
-// RepositoryConfig represents a repository configuration
+// Suggestion represents a suggested change embedded in a review comment,
+// pairing the code the comment was anchored to with the proposed replacement
+type Suggestion struct {
+	OriginalCode  string `json:"original_code"`
+	SuggestedCode string `json:"suggested_code"`
+}
+
+// PullRequest represents the metadata of a reviewed pull request
+type PullRequest struct {
+	Number       int           `json:"number"`
+	Repository   RepositoryRef `json:"repository"`
+	Provider     Provider      `json:"provider"`
+	Title        string        `json:"title"`
+	Author       string        `json:"author"`
+	URL          string        `json:"url"`
+	State        string        `json:"state"`
+	Merged       bool          `json:"merged"`
+	Draft        bool          `json:"draft"`
+	CreatedAt    time.Time     `json:"created_at"`
+	MergedAt     *time.Time    `json:"merged_at,omitempty"`
+	ClosedAt     *time.Time    `json:"closed_at,omitempty"`
+	BaseBranch   string        `json:"base_branch"`
+	HeadBranch   string        `json:"head_branch"`
+	HeadSHA      string        `json:"head_sha"`
+	Labels       []string      `json:"labels,omitempty"`
+	Additions    int           `json:"additions"`
+	Deletions    int           `json:"deletions"`
+	ChangedFiles int           `json:"changed_files"`
+}
+
+// RepositoryConfig represents a repository configuration. Credential overrides the
+// credentials section for this repository.
 type RepositoryConfig struct {
-	URL      string   `yaml:"url"`
-	Provider Provider `yaml:"provider"`
+	URL        string      `yaml:"url"`
+	Provider   Provider    `yaml:"provider"`
+	Credential *Credential `yaml:"credential,omitempty"`
 }
 
-// GitHubConfig represents GitHub-specific configuration
-type GitHubConfig struct {
-	Token string `yaml:"token"`
+// DiscoveryConfig expands into the repositories of an organization or user at the start of
+// a run. Include and Exclude are glob patterns matched against repository names; with
+// Topics, only repositories having at least one of the topics are kept. Archived
+// repositories and forks are skipped unless enabled. Credential overrides the credentials
+// section for the organization and its repositories.
+type DiscoveryConfig struct {
+	Provider        Provider    `yaml:"provider"`
+	Org             string      `yaml:"org"`
+	Include         []string    `yaml:"include,omitempty"`
+	Exclude         []string    `yaml:"exclude,omitempty"`
+	Topics          []string    `yaml:"topics,omitempty"`
+	IncludeArchived bool        `yaml:"include_archived,omitempty"`
+	IncludeForks    bool        `yaml:"include_forks,omitempty"`
+	Credential      *Credential `yaml:"credential,omitempty"`
 }
 
-// Config represents the application configuration
-type Config struct {
-	Repositories []RepositoryConfig `yaml:"repositories"`
-	GitHub       GitHubConfig       `yaml:"github"`
-	OutputFile   string             `yaml:"output_file"`
-	APIToken     string             `yaml:"api_token"`
+// PluginConfig registers an external extractor for a provider the tool has no adapter for.
+// Command is an executable speaking the plugin protocol (docs/plugin-protocol.md) over
+// stdio; if it contains a slash it is relative to the configuration file, otherwise it is
+// looked up on PATH. Env adds variables to the plugin's environment.
+type PluginConfig struct {
+	Provider Provider          `yaml:"provider"`
+	Command  string            `yaml:"command"`
+	Args     []string          `yaml:"args,omitempty"`
+	Env      map[string]string `yaml:"env,omitempty"`
+}
+
+// RemoteRepository is a repository listed by a provider during discovery
+type RemoteRepository struct {
+	Name     string
+	URL      string
+	Topics   []string
+	Archived bool
+	Fork     bool
+}
+
+// GitHub APIs pull requests, reviews and comments can be fetched with
+const (
+	GitHubAPIREST    = "rest"
+	GitHubAPIGraphQL = "graphql"
+)
+
+// GitHubConfig represents GitHub-specific configuration. The token can instead be read
+// from a file (token_file) or from the output of a shell command (token_cmd). API selects
+// the REST API (the default) or the GraphQL API, which needs far fewer requests.
+type GitHubConfig struct {
+	Token               string `yaml:"token"`
+	TokenFile           string `yaml:"token_file"`
+	TokenCmd            string `yaml:"token_cmd"`
+	DetectAddressed     bool   `yaml:"detect_addressed"`
+	IncludeEmptyReviews bool   `yaml:"include_empty_reviews"`
+	API                 string `yaml:"api"`
 }
 
-// Statistics represents aggregated review statistics
-type Statistics struct {
-	TotalReviews    int      `json:"total_reviews"`
-	TotalPRs        int      `json:"total_prs"`
-	TopReviewers    []string `json:"top_reviewers"`
-	TopRepositories []string `json:"top_repositories"`
-	AveragePRSize   float64  `json:"average_pr_size"`
-	ReviewFrequency float64  `json:"review_frequency"`
+// Config represents the application configuration. APIToken and GitHub.Token predate the
+// credentials section and are only used for repositories it does not cover.
+type Config struct {
+	Repositories    []RepositoryConfig `yaml:"repositories"`
+	Discover        []DiscoveryConfig  `yaml:"discover"`
+	Plugins         []PluginConfig     `yaml:"plugins"`
+	GitHub          GitHubConfig       `yaml:"github"`
+	Credentials     []Credential       `yaml:"credentials"`
+	OutputFile      string             `yaml:"output_file"`
+	APIToken        string             `yaml:"api_token"`
+	APITokenFile    string             `yaml:"api_token_file"`
+	APITokenCmd     string             `yaml:"api_token_cmd"`
+	SuggestionsOnly bool               `yaml:"suggestions_only"`
 }
 
 // ExtractionResult represents the result of a review extraction
 type ExtractionResult struct {
-	Reviews               []Review   `json:"reviews"`
-	Statistics            Statistics `json:"statistics"`
-	ExtractedAt           time.Time  `json:"extracted_at"`
-	TotalComments         int        `json:"total_comments"`
-	RepositoriesProcessed int        `json:"repositories_processed"`
+	SchemaVersion         int           `json:"schema_version"`
+	Reviews               []Review      `json:"reviews"`
+	PullRequests          []PullRequest `json:"pull_requests,omitempty"`
+	Statistics            Statistics    `json:"statistics"`
+	ExtractedAt           time.Time     `json:"extracted_at"`
+	TotalComments         int           `json:"total_comments"`
+	RepositoriesProcessed int           `json:"repositories_processed"`
 }
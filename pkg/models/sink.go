@@ -0,0 +1,17 @@
+package models
+
+// Sink receives the reviews of a run as they are extracted
+type Sink interface {
+	WriteReview(review Review) error
+}
+
+// Collector is a sink keeping the reviews in memory
+type Collector struct {
+	Reviews []Review
+}
+
+// WriteReview implements the Sink interface
+func (c *Collector) WriteReview(review Review) error {
+	c.Reviews = append(c.Reviews, review)
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Statistics represents aggregated review statistics
+type Statistics struct {
+	TotalReviews    int      `json:"total_reviews"`
+	TotalPRs        int      `json:"total_prs"`
+	TopReviewers    []string `json:"top_reviewers"`
+	TopRepositories []string `json:"top_repositories"`
+	// AveragePRSize is the average number of changed lines (additions + deletions)
+	// of the pull requests with metadata
+	AveragePRSize float64 `json:"average_pr_size"`
+	// ReviewFrequency is the average number of review comments per pull request
+	ReviewFrequency float64 `json:"review_frequency"`
+
+	Reviewers    []Count           `json:"reviewers"`
+	Repositories []Count           `json:"repositories"`
+	Files        []Count           `json:"files"`
+	Directories  []Count           `json:"directories"`
+	Languages    []LanguageHotspot `json:"languages"`
+	// CommentDensity is the number of inline comments per changed diff line
+	CommentDensity    float64       `json:"comment_density"`
+	TimeToFirstReview Distribution  `json:"time_to_first_review_hours"`
+	TimeToMerge       Distribution  `json:"time_to_merge_hours"`
+	Interactions      []Interaction `json:"interactions"`
+	Weekly            []WeeklyCount `json:"weekly"`
+}
+
+// Count is a named occurrence count
+type Count struct {
+	// Repository is set on files and directories, whose paths are only unique within a repository
+	Repository string `json:"repository,omitempty"`
+	Name       string `json:"name"`
+	Count      int    `json:"count"`
+}
+
+// LanguageHotspot represents the comments made on files of a single language
+type LanguageHotspot struct {
+	Language string  `json:"language"`
+	Comments int     `json:"comments"`
+	Files    []Count `json:"files"`
+}
+
+// Distribution summarizes a set of measurements
+type Distribution struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P75   float64 `json:"p75"`
+	P90   float64 `json:"p90"`
+	P95   float64 `json:"p95"`
+}
+
+// Interaction counts the comments a reviewer left on pull requests of an author
+type Interaction struct {
+	Reviewer string `json:"reviewer"`
+	Author   string `json:"author"`
+	Comments int    `json:"comments"`
+}
+
+// WeeklyCount represents review activity in the week starting on Monday WeekStart (UTC)
+type WeeklyCount struct {
+	WeekStart    time.Time `json:"week_start"`
+	Comments     int       `json:"comments"`
+	PullRequests int       `json:"pull_requests"`
+	Reviewers    int       `json:"reviewers"`
+}
@@ -0,0 +1,70 @@
+// Package provider defines what an adapter of a review platform implements: a Factory
+// describing the provider and the Extractor it creates, with the optional interfaces an
+// extractor may add to stream, check, plan and discover repositories.
+package provider
+
+import (
+	"context"
+	"iter"
+	"slices"
+
+	"github.com/jesper/review-extractor/pkg/models"
+)
+
+// Extractor extracts the reviews of a repository from one provider
+type Extractor interface {
+	ExtractReviews(ctx context.Context, repoURL string) ([]models.Review, error)
+}
+
+// StreamExtractor is implemented by extractors that yield the reviews of a repository as they
+// are fetched, pull request by pull request, instead of returning them all at the end
+type StreamExtractor interface {
+	StreamReviews(ctx context.Context, repoURL string) iter.Seq2[models.Review, error]
+}
+
+// Checker is implemented by extractors that can verify access to a repository before extracting it
+type Checker interface {
+	Check(ctx context.Context, repoURL string) (*models.RepositoryCheck, error)
+}
+
+// Planner is implemented by extractors that can estimate the cost of extracting a repository
+type Planner interface {
+	Plan(ctx context.Context, repoURL string) (*models.RepositoryPlan, error)
+}
+
+// Discoverer is implemented by extractors that can list the repositories of an organization
+type Discoverer interface {
+	ListRepositories(ctx context.Context, discovery models.DiscoveryConfig) ([]models.RemoteRepository, error)
+}
+
+// CredentialSource resolves the credential to use for a repository
+type CredentialSource interface {
+	Credential(provider models.Provider, repoURL string) (models.Credential, bool)
+}
+
+// Factory describes the adapter of a provider: how to recognize and check its repository
+// URLs, what it can be configured with and how to create its extractor
+type Factory struct {
+	// Name is the provider name used in configuration files
+	Name models.Provider
+	// Description is a one-line summary of the platforms the adapter supports
+	Description string
+	// Example is an example repository URL
+	Example string
+	// AuthMethods lists the credential methods the adapter accepts
+	AuthMethods []models.AuthMethod
+	// Settings lists the provider-specific configuration keys the adapter reads
+	Settings []string
+	// MatchURL reports whether a repository URL certainly belongs to the provider, e.g.
+	// because of its public host; it is nil for platforms that are only self-hosted
+	MatchURL func(url string) bool
+	// ValidateURL checks that a repository URL can be handled by the adapter
+	ValidateURL func(url string) error
+	// New creates the extractor for a configuration
+	New func(config *models.Config, source CredentialSource) Extractor
+}
+
+// SupportsAuth reports whether the adapter accepts credentials of a method
+func (f Factory) SupportsAuth(method models.AuthMethod) bool {
+	return slices.Contains(f.AuthMethods, method)
+}